@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/yourusername/oden/internal/auth"
+)
+
+// runKeygen runs the keygen subcommand, which writes a new private key for
+// signing access tokens to stdout. Save it as <key id>.pem in auth.keys_dir.
+func runKeygen(args []string) error {
+	alg := jwt.SigningMethodEdDSA.Alg()
+	switch len(args) {
+	case 0:
+	case 1:
+		alg = args[0]
+	default:
+		return errors.New("expected: keygen [EdDSA|RS256]")
+	}
+
+	key, err := auth.GenerateKey(alg)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(key)
+	return err
+}
@@ -1,60 +1,249 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	_ "time/tzdata" // mission reset time zones must load on hosts without zoneinfo
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/yourusername/oden/internal/api"
+	"github.com/yourusername/oden/internal/auth"
 	"github.com/yourusername/oden/internal/config"
 	"github.com/yourusername/oden/internal/db"
+	"github.com/yourusername/oden/internal/health"
+	"github.com/yourusername/oden/internal/idempotency"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/mail"
+	"github.com/yourusername/oden/internal/metrics"
+	"github.com/yourusername/oden/internal/mission"
+	"github.com/yourusername/oden/internal/ratelimit"
 	"github.com/yourusername/oden/internal/storage"
+	"github.com/yourusername/oden/internal/store"
+	"github.com/yourusername/oden/internal/tracing"
 )
 
 func main() {
 	// Parse command line flags
-	configPath := flag.String("config", "internal/config/config.json", "Path to configuration file")
+	configPath := flag.String("config", "internal/config/config.json", "Path to the JSON or YAML configuration file, or empty to use only defaults, environment variables and flags")
+	config.RegisterFlags(flag.CommandLine)
+	flag.Usage = usage
 	flag.Parse()
 
+	// Generating a key needs no configuration
+	if flag.Arg(0) == "keygen" {
+		if err := runKeygen(flag.Args()[1:]); err != nil {
+			logrus.WithError(err).Fatal("Key generation failed")
+		}
+		return
+	}
+
 	// Load configuration
-	cfg, err := config.LoadConfig(*configPath)
+	loader := config.NewLoader(*configPath, flag.CommandLine)
+	cfg, err := loader.Load()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load config")
+	}
+	if err := logging.Configure(cfg.Log); err != nil {
+		logrus.WithError(err).Fatal("Invalid log config")
+	}
+
+	// Run a subcommand instead of the server if one was given
+	switch flag.Arg(0) {
+	case "":
+	case "migrate":
+		if err := runMigrate(cfg, flag.Args()[1:]); err != nil {
+			logrus.WithError(err).Fatal("Migration failed")
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Send spans of requests, SQL statements and storage calls to the exporter
+	shutdownTracing, err := tracing.Configure(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		logrus.WithError(err).Fatal("Failed to set up tracing")
 	}
 
-	// Initialize database
-	database, err := db.NewDB(cfg)
+	// Load the keys access tokens are signed and verified with
+	keys, err := auth.LoadKeySet(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		logrus.WithError(err).Fatal("Failed to load auth keys")
 	}
 
-	// Initialize storage client
-	storageClient, err := storage.NewClient(cfg)
+	// Initialize the mailer for account emails
+	mailer, err := mail.New(cfg.Mail)
 	if err != nil {
-		log.Fatalf("Failed to initialize storage client: %v", err)
+		logrus.WithError(err).Fatal("Invalid mail config")
 	}
 
-	// Set up Gin
-	router := gin.Default()
+	// Initialize the data store
+	st, database, err := newStore(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to connect to database")
+	}
+
+	// Initialize the storage client, if storage is configured
+	var storageClient *storage.Client
+	if cfg.Storage.Endpoint != "" {
+		storageClient, err = storage.NewClient(cfg)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to initialize storage client")
+		}
+	} else {
+		logrus.Warn("Storage is not configured; set storage.endpoint to serve assets")
+	}
+
+	// Initialize the store of rate limit counts
+	var limits ratelimit.Store
+	if cfg.RateLimit.Enabled {
+		limits, err = ratelimit.NewStore(cfg.RateLimit)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to initialize rate limiting")
+		}
+	}
+
+	// Initialize the mission scheduler and sweep expired missions in the background
+	missions, err := mission.NewService(st, cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid mission config")
+	}
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		missions.Run(workersCtx)
+	}()
+
+	// Keep the responses to requests sent with an idempotency key, deleting
+	// expired ones in the background
+	idempotencyKeys := idempotency.NewService(st, cfg)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		idempotencyKeys.Run(workersCtx)
+	}()
+
+	// Apply game balance changes to the config file without a restart
+	loader.Watch(cfg)
+
+	// Collect request, database pool and gameplay metrics for Prometheus
+	var serverMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		serverMetrics = metrics.New()
+		if database != nil {
+			if err := serverMetrics.WatchDB(database.DB, cfg.Database.DBName); err != nil {
+				logrus.WithError(err).Fatal("Failed to collect database metrics")
+			}
+		}
+	}
+
+	// Set up Gin, logging requests and Gin's own messages as structured logs
+	gin.DefaultWriter = logrus.StandardLogger().WriterLevel(logrus.DebugLevel)
+	gin.DefaultErrorWriter = logrus.StandardLogger().WriterLevel(logrus.ErrorLevel)
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logrus.WithError(err).Fatal("Invalid trusted proxies")
+	}
+	router.Use(api.RequestLogger(), api.Recovery(), api.Tracing(cfg.Tracing.ServiceName))
+	if serverMetrics != nil {
+		router.Use(api.RequestMetrics(serverMetrics))
+	}
 
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", api.RequestIDHeader, api.IdempotencyKeyHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", api.RequestIDHeader, api.IdempotentReplayedHeader},
 		AllowCredentials: true,
 	}))
 
 	// Initialize API handlers
-	api.RegisterHandlers(router, database, storageClient, cfg)
+	api.RegisterHandlers(router, st, storageClient, cfg, keys, mailer, missions, serverMetrics, limits, idempotencyKeys)
+
+	// Liveness and readiness probes for the orchestrator and load balancer
+	checks, err := readinessChecks(database, storageClient)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to set up readiness checks")
+	}
+	api.RegisterProbes(router, health.NewChecker(seconds(cfg.Server.HealthCheckTimeout), checks...))
+	if serverMetrics != nil {
+		api.RegisterMetrics(router, serverMetrics, cfg.Metrics.BearerToken)
+	}
+
+	// Serve until SIGINT or SIGTERM, as sent by docker stop and ECS. A second
+	// signal stops the server without waiting for requests.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	serveErr := serve(ctx, newHTTPServer(cfg, router), seconds(cfg.Server.ShutdownTimeout))
+	if serveErr != nil {
+		logrus.WithError(serveErr).Error("Server error")
+	}
+
+	// Clean up in the reverse order of startup, once nothing uses what is
+	// being closed: requests have finished, then the background workers stop,
+	// then the storage client, the rate limit store and the database are
+	// closed, and the last spans are sent
+	stopWorkers()
+	workers.Wait()
+	if storageClient != nil {
+		storageClient.Close()
+	}
+	if limits != nil {
+		if err := limits.Close(); err != nil {
+			logrus.WithError(err).Error("Error closing rate limit store")
+		}
+	}
+	if database != nil {
+		if err := database.Close(); err != nil {
+			logrus.WithError(err).Error("Error closing database")
+		}
+	}
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), traceFlushTimeout)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logrus.WithError(err).Error("Error sending the last spans")
+	}
+	cancelFlush()
+	logrus.Info("Server stopped")
+
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
 
-	// Start the server
-	serverAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	log.Printf("Starting server on %s", serverAddr)
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+// newStore creates the store selected by the database driver setting, and
+// returns the database connection to close on shutdown, if it has one
+func newStore(cfg *config.Config) (store.Store, *db.DB, error) {
+	switch cfg.Database.Driver {
+	case "", "mysql":
+		database, err := db.NewDB(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := migrateUp(database); err != nil {
+			database.Close()
+			return nil, nil, err
+		}
+		return store.NewMySQL(database), database, nil
+	case "memory":
+		logrus.Warn("Using in-memory store with sample data; nothing will be persisted")
+		mem := store.NewMemory()
+		mem.LoadSampleData()
+		return mem, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver %q", cfg.Database.Driver)
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/db"
+)
+
+// usage prints the command line help
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [migrate up|down|status|baseline <version> | keygen [EdDSA|RS256]]\n\n", os.Args[0])
+	fmt.Fprintf(out, "Without a command the API server is started, after applying pending migrations.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// migrateUp applies pending migrations before the server starts. It refuses
+// to continue if an applied migration no longer matches this build.
+func migrateUp(database *db.DB) error {
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		logrus.WithField("migration", m.Name).Info("Applied migration")
+	}
+	return err
+}
+
+// runMigrate runs the migrate subcommand
+func runMigrate(cfg *config.Config, args []string) error {
+	if cfg.Database.Driver != "" && cfg.Database.Driver != "mysql" {
+		return fmt.Errorf("migrations only apply to the mysql driver, not %q", cfg.Database.Driver)
+	}
+	wantArgs := 1
+	if len(args) > 0 && args[0] == "baseline" {
+		wantArgs = 2
+	}
+	if len(args) != wantArgs {
+		return errors.New("expected one of: migrate up, migrate down, migrate status, migrate baseline <version>")
+	}
+
+	database, err := db.NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			logrus.WithField("migration", m.Name).Info("Applied migration")
+		}
+		if err == nil && len(applied) == 0 {
+			logrus.Info("Database is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if reverted != nil {
+			logrus.WithField("migration", reverted.Name).Info("Reverted migration")
+		} else if err == nil {
+			logrus.Info("No migrations to revert")
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-30s %s\n", st.Name, applied)
+		}
+		return err
+	case "baseline":
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("migrate baseline needs a version number, not %q", args[1])
+		}
+		recorded, err := migrator.Baseline(ctx, version)
+		for _, m := range recorded {
+			logrus.WithField("migration", m.Name).Info("Recorded migration as applied")
+		}
+		return err
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
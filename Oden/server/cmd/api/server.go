@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/db"
+	"github.com/yourusername/oden/internal/health"
+	"github.com/yourusername/oden/internal/storage"
+)
+
+// traceFlushTimeout bounds how long shutdown waits to send the last spans,
+// so an unreachable collector cannot hold up a deployment
+const traceFlushTimeout = 5 * time.Second
+
+// seconds converts a duration configured in seconds
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// newHTTPServer creates the HTTP server for handler with the configured
+// address and timeouts
+func newHTTPServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: seconds(cfg.Server.ReadHeaderTimeout),
+		ReadTimeout:       seconds(cfg.Server.ReadTimeout),
+		WriteTimeout:      seconds(cfg.Server.WriteTimeout),
+		IdleTimeout:       seconds(cfg.Server.IdleTimeout),
+	}
+}
+
+// readinessChecks returns the checks of the dependencies requests need: the
+// storage bucket, if storage is configured, and, unless the store is in
+// memory, the database and its schema
+func readinessChecks(database *db.DB, storageClient *storage.Client) ([]health.Check, error) {
+	var checks []health.Check
+	if storageClient != nil {
+		checks = append(checks, health.Check{Name: "storage", Run: storageClient.Ping})
+	}
+	if database == nil {
+		return checks, nil
+	}
+
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		return nil, err
+	}
+	return append(checks,
+		health.Check{Name: "database", Run: database.PingContext},
+		health.Check{Name: "migrations", Run: func(ctx context.Context) error {
+			missing, err := migrator.Missing(ctx)
+			if err != nil {
+				return err
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("%d migrations not applied, starting with %s", len(missing), missing[0].Name)
+			}
+			return nil
+		}},
+	), nil
+}
+
+// serve runs srv until ctx is cancelled. It then stops accepting connections
+// and waits up to timeout for in-flight requests to finish, so a deployment
+// does not cut off players in the middle of a request. Requests still running
+// after that are cut off.
+func serve(ctx context.Context, srv *http.Server, timeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		logrus.WithField("addr", srv.Addr).Info("Starting server")
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	logrus.WithField("timeout", timeout.String()).Info("Shutting down, waiting for in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("requests still running after %s were cut off: %w", timeout, err)
+	}
+	return nil
+}
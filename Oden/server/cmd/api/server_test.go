@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+// freeAddr returns a local address nothing listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// startServe runs serve for handler in the background and waits until it
+// accepts connections. The returned channel receives the result of serve.
+func startServe(t *testing.T, ctx context.Context, handler http.Handler, timeout time.Duration) (string, <-chan error) {
+	t.Helper()
+	addr := freeAddr(t)
+	errc := make(chan error, 1)
+	go func() { errc <- serve(ctx, &http.Server{Addr: addr, Handler: handler}, timeout) }()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return addr, errc
+		}
+	}
+	t.Fatal("server did not start")
+	return "", nil
+}
+
+// slowHandler answers after release is closed, signalling started first
+func slowHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	})
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	addr, errc := startServe(t, ctx, slowHandler(started, release), 5*time.Second)
+
+	type result struct {
+		body string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + addr)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		done <- result{string(body), err}
+	}()
+	<-started
+
+	// Shutting down stops new connections but lets the request finish
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Error("server accepted a connection while shutting down")
+	}
+	close(release)
+
+	if r := <-done; r.err != nil || r.body != "done" {
+		t.Errorf("in-flight request = %q, %v; want it answered", r.body, r.err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+}
+
+func TestServeCutsOffRequestsAfterTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	addr, errc := startServe(t, ctx, slowHandler(started, release), 100*time.Millisecond)
+
+	go http.Get("http://" + addr)
+	<-started
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Error("serve returned no error after cutting off a request")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after the shutdown timeout")
+	}
+}
+
+func TestServeReportsListenErrors(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	srv := &http.Server{Addr: l.Addr().String(), Handler: http.NotFoundHandler()}
+	if err := serve(context.Background(), srv, time.Second); err == nil {
+		t.Error("serve on an address in use succeeded")
+	}
+}
+
+func TestNewHTTPServerSetsTimeouts(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Host = "127.0.0.1"
+	srv := newHTTPServer(cfg, http.NotFoundHandler())
+
+	if srv.Addr != "127.0.0.1:8080" {
+		t.Errorf("Addr = %q", srv.Addr)
+	}
+	if srv.ReadHeaderTimeout != 5*time.Second || srv.ReadTimeout != 15*time.Second ||
+		srv.WriteTimeout != 30*time.Second || srv.IdleTimeout != 120*time.Second {
+		t.Errorf("timeouts = %v, %v, %v, %v; want the configured ones",
+			srv.ReadHeaderTimeout, srv.ReadTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+}
+
+func TestReadinessChecksOfMemoryStore(t *testing.T) {
+	checks, err := readinessChecks(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 0 {
+		t.Errorf("%d readiness checks without a database or storage, want none", len(checks))
+	}
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/auth"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/mail"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// Errors returned by the email verification and password reset flows
+var (
+	errInvalidEmailToken = model.CustomError{Message: "Invalid or expired link", Code: "invalid_token"}
+	errNoEmail           = model.CustomError{Message: "Account has no email address", Code: "invalid_request"}
+	errAlreadyVerified   = model.CustomError{Message: "Email is already verified", Code: "already_verified"}
+)
+
+// EmailTokenRequest represents a request carrying a token from an email link
+type EmailTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PasswordResetRequest represents the request to send a password reset link
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the request to set a new password with a
+// password reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// requestEmailVerificationHandler sends the authenticated user a new email
+// verification link
+func (h *handler) requestEmailVerificationHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, err := h.store.Users().GetByID(ctx, currentUserID(c))
+	if err == nil {
+		switch {
+		case user.Email == "":
+			err = errNoEmail
+		case user.EmailVerifiedAt != nil:
+			err = errAlreadyVerified
+		default:
+			err = h.sendVerificationEmail(ctx, user)
+		}
+	}
+	if err != nil {
+		respondTxError(c, err, "Error sending verification email")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// verifyEmailHandler marks the email address a verification token was sent
+// to as verified. The token only works while the account still has that
+// address and until it has been used.
+func (h *handler) verifyEmailHandler(c *gin.Context) {
+	var req EmailTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	claims, err := h.keys.ParseEmailToken(req.Token, auth.PurposeVerifyEmail)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidEmailToken.Code, errInvalidEmailToken.Message)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		user, err := tx.Users().GetByID(ctx, claims.Subject)
+		if errors.Is(err, store.ErrNotFound) {
+			return errInvalidEmailToken
+		}
+		if err != nil {
+			return err
+		}
+		if user.Email == "" || user.EmailVerifiedAt != nil || !claims.Matches(user.Email) {
+			return errInvalidEmailToken
+		}
+
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Users().Update(ctx, user)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error verifying email")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// requestPasswordResetHandler sends a password reset link to the account
+// with the email address. It responds the same whether or not there is one,
+// so it cannot be used to find out who has an account.
+func (h *handler) requestPasswordResetHandler(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	user, err := h.store.Users().GetByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		respondServerError(c, "Error looking up user", err)
+		return
+	}
+	if user != nil {
+		if err := h.sendPasswordResetEmail(ctx, user); err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("user_id", user.ID).Error("Error sending password reset email")
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "If an account uses this email, a password reset link has been sent to it",
+	})
+}
+
+// resetPasswordHandler sets a new password with a password reset token and
+// revokes every session of the user. The token stops working once the
+// password has changed, so it can only be used once.
+func (h *handler) resetPasswordHandler(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	claims, err := h.keys.ParseEmailToken(req.Token, auth.PurposePasswordReset)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidEmailToken.Code, errInvalidEmailToken.Message)
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		respondServerError(c, "Error hashing password", err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		user, err := tx.Users().GetByID(ctx, claims.Subject)
+		if errors.Is(err, store.ErrNotFound) {
+			return errInvalidEmailToken
+		}
+		if err != nil {
+			return err
+		}
+		if user.IsGuest() || !claims.Matches(user.PasswordHash) {
+			return errInvalidEmailToken
+		}
+
+		now := time.Now()
+		user.PasswordHash = passwordHash
+		// Proving ownership of the email address also ends a lockout
+		user.ResetFailedLogins()
+		if user.EmailVerifiedAt == nil {
+			// Following the link proves the user owns the address
+			user.EmailVerifiedAt = &now
+		}
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return err
+		}
+		return tx.Sessions().RevokeByUser(ctx, user.ID, now)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error resetting password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// sendVerificationEmail sends the user a link to verify their email address
+func (h *handler) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, err := h.keys.GenerateEmailToken(auth.PurposeVerifyEmail, user.ID, user.Email, auth.VerifyEmailExpiry(h.cfg))
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm this is your email address by opening the link below:\n\n%s\n\n"+
+			"The link expires in %s. If you did not create an Oden account, you can ignore this email.\n",
+			user.Username, h.emailLink("verify-email", token), describeDuration(auth.VerifyEmailExpiry(h.cfg))),
+	})
+}
+
+// sendPasswordResetEmail sends the user a link to choose a new password
+func (h *handler) sendPasswordResetEmail(ctx context.Context, user *model.User) error {
+	token, err := h.keys.GenerateEmailToken(auth.PurposePasswordReset, user.ID, user.PasswordHash, auth.PasswordResetExpiry(h.cfg))
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your Oden account. To choose a new password, open the link below:\n\n%s\n\n"+
+			"The link expires in %s. Resetting your password signs you out on every device. If you did not ask for this, you can ignore this email.\n",
+			user.Username, h.emailLink("reset-password", token), describeDuration(auth.PasswordResetExpiry(h.cfg))),
+	})
+}
+
+// emailLink returns the link to a page of the account website for a token
+func (h *handler) emailLink(page, token string) string {
+	return strings.TrimSuffix(h.cfg.Mail.LinkBaseURL, "/") + "/" + page + "?token=" + url.QueryEscape(token)
+}
+
+// describeDuration formats the lifetime of a link for an email, e.g. "48 hours"
+func describeDuration(d time.Duration) string {
+	n, unit := int(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unit = int(d/time.Hour), "hour"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPasswordResetLinkWorksOnce(t *testing.T) {
+	s := newTestServer(t)
+	creds, session := s.register(t)
+
+	if w := s.do(t, http.MethodPost, "/v1/auth/password-reset/request", "", PasswordResetRequest{Email: creds.Email}, nil); w.Code != http.StatusOK {
+		t.Fatalf("requesting reset: %d %s", w.Code, w.Body.String())
+	}
+	token := s.mailer.lastToken(t, creds.Email)
+
+	reset := ResetPasswordRequest{Token: token, Password: "new password"}
+	if w := s.do(t, http.MethodPost, "/v1/auth/password-reset", "", reset, nil); w.Code != http.StatusOK {
+		t.Fatalf("resetting password: %d %s", w.Code, w.Body.String())
+	}
+	if w := s.do(t, http.MethodPost, "/v1/auth/login", "", LoginRequest{Username: creds.Username, Password: "new password"}, nil); w.Code != http.StatusOK {
+		t.Errorf("login with the new password: %d %s", w.Code, w.Body.String())
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", session.Token, nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("access token from before the reset: %d, want 401", w.Code)
+	}
+
+	// Using the link again, as someone who found it later would, fails
+	var res AuthResponse
+	reset.Password = "attacker password"
+	w := s.do(t, http.MethodPost, "/v1/auth/password-reset", "", reset, &res)
+	if w.Code != http.StatusBadRequest || res.Error != errInvalidEmailToken.Code {
+		t.Errorf("second reset: %d %s, want 400 %s", w.Code, res.Error, errInvalidEmailToken.Code)
+	}
+}
+
+func TestVerifyEmailLinkWorksOnce(t *testing.T) {
+	s := newTestServer(t)
+	creds, _ := s.register(t)
+	token := s.mailer.lastToken(t, creds.Email)
+
+	if w := s.do(t, http.MethodPost, "/v1/auth/verify-email", "", EmailTokenRequest{Token: token}, nil); w.Code != http.StatusOK {
+		t.Fatalf("verifying email: %d %s", w.Code, w.Body.String())
+	}
+
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/auth/verify-email", "", EmailTokenRequest{Token: token}, &res)
+	if w.Code != http.StatusBadRequest || res.Error != errInvalidEmailToken.Code {
+		t.Errorf("second verification: %d %s, want 400 %s", w.Code, res.Error, errInvalidEmailToken.Code)
+	}
+
+	// A verification link cannot reset the password
+	w = s.do(t, http.MethodPost, "/v1/auth/password-reset", "", ResetPasswordRequest{Token: token, Password: "new password"}, &res)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("reset with a verification token: %d, want 400", w.Code)
+	}
+}
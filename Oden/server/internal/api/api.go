@@ -1,85 +1,238 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/auth"
 	"github.com/yourusername/oden/internal/config"
-	"github.com/yourusername/oden/internal/db"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/gacha"
+	"github.com/yourusername/oden/internal/idempotency"
+	"github.com/yourusername/oden/internal/idle"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/mail"
+	"github.com/yourusername/oden/internal/metrics"
+	"github.com/yourusername/oden/internal/mission"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/ratelimit"
 	"github.com/yourusername/oden/internal/storage"
+	"github.com/yourusername/oden/internal/store"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// RegisterHandlers registers all API handlers
-func RegisterHandlers(router *gin.Engine, db *db.DB, storage *storage.Client, cfg *config.Config) {
-	// Add health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+// errInsufficientResources is returned when the player cannot afford an action
+var errInsufficientResources = model.CustomError{Message: "Not enough resources", Code: "insufficient_resources"}
+
+// lockPlayer locks the user's resources row and returns it. Every transaction
+// that changes a player's state takes this lock before any other row, so
+// concurrent actions of the same player run one after the other instead of
+// deadlocking on their heroes, items and missions, which they lock in
+// different orders.
+func lockPlayer(ctx context.Context, tx store.Store, userID string) (*model.PlayerResources, error) {
+	return tx.Resources().Get(ctx, userID)
+}
+
+// handler holds the dependencies shared by the API handlers
+type handler struct {
+	store    store.Store
+	storage  *storage.Client // nil if storage is not configured
+	cfg      *config.Config
+	keys     *auth.KeySet
+	mailer   mail.Mailer
+	events   *events.Bus
+	gacha    *gacha.Service
+	idle     *idle.Service
+	missions *mission.Service
+}
+
+// RegisterHandlers registers all API handlers. storage is nil when it is not
+// configured. Access tokens are signed and verified with keys, and account
+// emails are sent with mailer. Mission
+// progress is tracked by subscribing missions to the gameplay events the
+// handlers publish, and they are counted in gameMetrics, which may be nil.
+// Requests are rate limited with the counts in limits, unless it is nil.
+// Requests of logged in users that carry an Idempotency-Key header are
+// answered only once, with the responses kept in idempotencyKeys.
+func RegisterHandlers(router *gin.Engine, st store.Store, storage *storage.Client, cfg *config.Config, keys *auth.KeySet, mailer mail.Mailer, missions *mission.Service, gameMetrics *metrics.Metrics, limits ratelimit.Store, idempotencyKeys *idempotency.Service) {
+	bus := events.NewBus()
+	bus.Subscribe(missions.HandleEvent)
+	bus.Subscribe(gameMetrics.HandleEvent)
+
+	h := &handler{
+		store:    st,
+		storage:  storage,
+		cfg:      cfg,
+		keys:     keys,
+		mailer:   mailer,
+		events:   bus,
+		gacha:    gacha.NewService(st, bus, missions, time.Now().UnixNano()),
+		idle:     idle.NewService(st, cfg, bus),
+		missions: missions,
+	}
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", h.jwksHandler)
 
 	// API v1 routes
 	v1 := router.Group("/v1")
 	{
 		// Auth routes
 		authRoutes := v1.Group("/auth")
+		if limits != nil {
+			// By IP, as most of these routes are used before logging in
+			authRoutes.Use(rateLimit(ratelimit.NewLimiter(limits, "auth", cfg.RateLimit.Auth), (*gin.Context).ClientIP))
+		}
 		{
-			authRoutes.POST("/register", registerHandler)
-			authRoutes.POST("/login", loginHandler)
+			// Idempotency keys belong to a user, so only the routes that
+			// need a login take them
+			idempotentAuth := []gin.HandlerFunc{h.authMiddleware(), idempotent(idempotencyKeys)}
+			authRoutes.POST("/register", h.registerHandler)
+			authRoutes.POST("/login", h.loginHandler)
+			authRoutes.POST("/guest", h.guestHandler)
+			authRoutes.POST("/upgrade", append(idempotentAuth, h.upgradeHandler)...)
+			authRoutes.POST("/verify-email/request", append(idempotentAuth, h.requestEmailVerificationHandler)...)
+			authRoutes.POST("/verify-email", h.verifyEmailHandler)
+			authRoutes.POST("/password-reset/request", h.requestPasswordResetHandler)
+			authRoutes.POST("/password-reset", h.resetPasswordHandler)
+			authRoutes.POST("/refresh", h.refreshHandler)
+			authRoutes.POST("/logout", append(idempotentAuth, h.logoutHandler)...)
+			authRoutes.GET("/sessions", h.authMiddleware(), h.listSessionsHandler)
 		}
 
 		// Protected routes
 		protected := v1.Group("/")
-		protected.Use(authMiddleware(cfg))
+		protected.Use(h.authMiddleware())
+		if limits != nil {
+			protected.Use(rateLimit(ratelimit.NewLimiter(limits, "user", cfg.RateLimit.Protected), currentUserID))
+		}
+		// So retried requests do not spend or grant anything twice
+		protected.Use(idempotent(idempotencyKeys))
 		{
 			// Heroes routes
 			heroesRoutes := protected.Group("/heroes")
 			{
-				heroesRoutes.GET("/list", listHeroesHandler)
-				heroesRoutes.POST("/summon", summonHeroHandler)
+				heroesRoutes.GET("/list", h.listHeroesHandler)
+				heroesRoutes.POST("/summon", h.summonHeroHandler)
 			}
 
 			// Team routes
 			teamRoutes := protected.Group("/team")
 			{
-				teamRoutes.GET("/get", getTeamHandler)
-				teamRoutes.POST("/save", saveTeamHandler)
+				teamRoutes.GET("/get", h.getTeamHandler)
+				teamRoutes.POST("/save", h.saveTeamHandler)
 			}
 
 			// Battle routes
 			battleRoutes := protected.Group("/battle")
 			{
-				battleRoutes.POST("/start", startBattleHandler)
+				battleRoutes.POST("/start", h.startBattleHandler)
 			}
 
 			// Idle routes
 			idleRoutes := protected.Group("/idle")
 			{
-				idleRoutes.GET("/rewards", getIdleRewardsHandler)
-				idleRoutes.POST("/claim", claimIdleRewardsHandler)
+				idleRoutes.GET("/rewards", h.getIdleRewardsHandler)
+				idleRoutes.POST("/claim", h.claimIdleRewardsHandler)
 			}
 
 			// Items routes
 			itemsRoutes := protected.Group("/items")
 			{
-				itemsRoutes.GET("/list", listItemsHandler)
-				itemsRoutes.POST("/use", useItemHandler)
-				itemsRoutes.POST("/equip", equipItemHandler)
-				itemsRoutes.POST("/unequip", unequipItemHandler)
+				itemsRoutes.GET("/list", h.listItemsHandler)
+				itemsRoutes.POST("/use", h.useItemHandler)
+				itemsRoutes.POST("/equip", h.equipItemHandler)
+				itemsRoutes.POST("/unequip", h.unequipItemHandler)
 			}
 
 			// Missions routes
 			missionsRoutes := protected.Group("/missions")
 			{
-				missionsRoutes.GET("/list", listMissionsHandler)
-				missionsRoutes.POST("/claim", claimMissionRewardHandler)
+				missionsRoutes.GET("/list", h.listMissionsHandler)
+				missionsRoutes.POST("/claim", h.claimMissionRewardHandler)
 			}
 
 			// Gacha routes
 			gachaRoutes := protected.Group("/gacha")
 			{
-				gachaRoutes.GET("/banners", listBannersHandler)
-				gachaRoutes.POST("/summon", summonGachaHandler)
-				gachaRoutes.GET("/rates", getBannerRatesHandler)
+				gachaRoutes.GET("/banners", h.listBannersHandler)
+				gachaRoutes.POST("/summon", h.summonGachaHandler)
+				gachaRoutes.GET("/rates", h.getBannerRatesHandler)
 			}
 		}
 	}
-} 
\ No newline at end of file
+}
+
+// respondError writes the standard error response described in docs/api.md
+func respondError(c *gin.Context, status int, code, message string) {
+	res := gin.H{
+		"success": false,
+		"error":   code,
+		"message": message,
+	}
+	// The request ID lets support find the request in the logs
+	if id := c.GetString("requestID"); id != "" {
+		res["request_id"] = id
+	}
+	c.JSON(status, res)
+}
+
+// respondTxError responds to an error returned from a handler transaction.
+// A model.CustomError is the client's fault and is reported with its code;
+// anything else is logged and reported as a server error.
+func respondTxError(c *gin.Context, err error, message string) {
+	var ce model.CustomError
+	if errors.As(err, &ce) {
+		status := http.StatusBadRequest
+		if ce.Code == "resource_not_found" {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, ce.Code, ce.Message)
+		return
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, "resource_not_found", "Resource not found")
+		return
+	}
+	respondServerError(c, message, err)
+}
+
+// respondServerError logs err and writes a generic server_error response
+func respondServerError(c *gin.Context, message string, err error) {
+	logging.FromContext(c.Request.Context()).WithError(err).Error(message)
+	trace.SpanFromContext(c.Request.Context()).RecordError(err)
+	respondError(c, http.StatusInternalServerError, "server_error", message)
+}
+
+// currentUserID returns the ID of the user authenticated by authMiddleware
+func currentUserID(c *gin.Context) string {
+	return c.GetString("userID")
+}
+
+// lockedRand is a math/rand generator that is safe for concurrent use
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// rng is the random source for handlers that roll rewards
+var rng = &lockedRand{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// Intn returns a random int in [0, n)
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+// Int63 returns a random non-negative int64
+func (l *lockedRand) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63()
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yourusername/oden/internal/auth"
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/idempotency"
+	"github.com/yourusername/oden/internal/mail"
+	"github.com/yourusername/oden/internal/metrics"
+	"github.com/yourusername/oden/internal/mission"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// testServer is the API over a memory store with sample data
+type testServer struct {
+	router *gin.Engine
+	store  store.Store
+	cfg    *config.Config
+	mailer *outbox
+}
+
+// newTestServer starts the API with the default config, changed by the
+// optional configure func, and without rate limits
+func newTestServer(t *testing.T, configure ...func(*config.Config)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	cfg.Database.Driver = "memory"
+	pem, err := auth.GenerateKey("EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Auth.KeysDir = ""
+	cfg.Auth.Keys = []config.KeyConfig{{ID: "test", PEM: string(pem)}}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	keys, err := auth.LoadKeySet(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mem := store.NewMemory()
+	mem.LoadSampleData()
+	missions, err := mission.NewService(mem, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailer := &outbox{}
+
+	router := gin.New()
+	RegisterHandlers(router, mem, nil, cfg, keys, mailer, missions, metrics.New(), nil, idempotency.NewService(mem, cfg))
+	return &testServer{router: router, store: mem, cfg: cfg, mailer: mailer}
+}
+
+// do sends a request with a JSON body, authenticated with token unless it
+// is empty, and decodes the JSON response into res unless it is nil
+func (s *testServer) do(t *testing.T, method, path, token string, body, res interface{}, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if res != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+			t.Fatalf("%s %s: decoding %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w
+}
+
+// register registers a new player and returns its credentials
+func (s *testServer) register(t *testing.T) (RegisterRequest, AuthResponse) {
+	t.Helper()
+	name := "player_" + uuid.New().String()[:8]
+	req := RegisterRequest{Username: name, Email: name + "@example.com", Password: "password123"}
+	var res AuthResponse
+	if w := s.do(t, http.MethodPost, "/v1/auth/register", "", req, &res); w.Code != http.StatusOK {
+		t.Fatalf("register: %d %s", w.Code, w.Body.String())
+	}
+	return req, res
+}
+
+// linkToken matches the token of a link in an email
+var linkToken = regexp.MustCompile(`\?token=(\S+)`)
+
+// outbox is a mailer that keeps the emails it is given
+type outbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// count returns how many emails with the subject were sent to address
+func (o *outbox) count(to, subject string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for _, m := range o.messages {
+		if m.To == to && m.Subject == subject {
+			n++
+		}
+	}
+	return n
+}
+
+// lastToken returns the token of the link in the last email to address
+func (o *outbox) lastToken(t *testing.T, to string) string {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To != to {
+			continue
+		}
+		m := linkToken.FindStringSubmatch(o.messages[i].Body)
+		if m == nil {
+			t.Fatalf("no link in email %q", o.messages[i].Subject)
+		}
+		token, err := url.QueryUnescape(m[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	t.Fatalf("no email to %s", to)
+	return ""
+}
@@ -1,15 +1,32 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"github.com/yourusername/oden/internal/auth"
-	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/mail"
 	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+const (
+	// familiarSessions is how many of the user's latest sessions a login is
+	// compared with to tell whether it comes from a new device or country
+	familiarSessions = 50
+	// recentSessions is how many sessions the user can list
+	recentSessions = 20
+	// maxUserAgentLength is the longest user agent stored with a session
+	maxUserAgentLength = 255
 )
 
 // RegisterRequest represents the request to register a new user
@@ -25,136 +42,683 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// AuthResponse represents the response for auth operations
+// GuestRequest represents the request to play as a guest. DeviceID should be
+// a random ID the client generates once per install and keeps, not a hardware
+// identifier: it is all a guest needs to log in.
+type GuestRequest struct {
+	DeviceID string `json:"device_id" binding:"required,min=16,max=128"`
+}
+
+// RefreshRequest represents the request to exchange a refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest represents the request to log out. All ends every session of
+// the user instead of only the current one.
+type LogoutRequest struct {
+	All bool `json:"all"`
+}
+
+// AuthResponse represents the response for auth operations. Token is the
+// access token and ExpiresIn its lifetime in seconds.
 type AuthResponse struct {
-	Success bool   `json:"success"`
-	UserID  string `json:"user_id,omitempty"`
-	Token   string `json:"token,omitempty"`
-	Message string `json:"message,omitempty"`
+	Success      bool   `json:"success"`
+	UserID       string `json:"user_id,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+	Guest        bool   `json:"guest,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Message      string `json:"message,omitempty"`
 }
 
+// SessionInfo is a session as listed to its user. Current marks the session
+// the request was made with.
+type SessionInfo struct {
+	*model.Session
+	Current bool `json:"current"`
+}
+
+// Errors returned by auth handlers
+var (
+	// errInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	errInvalidRefreshToken = model.CustomError{Message: "Invalid or expired refresh token", Code: "invalid_token"}
+	errAlreadyRegistered   = model.CustomError{Message: "Account is already registered", Code: "already_registered"}
+)
+
 // registerHandler handles user registration
-func registerHandler(c *gin.Context) {
+func (h *handler) registerHandler(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, AuthResponse{
 			Success: false,
+			Error:   "invalid_request",
 			Message: "Invalid request: " + err.Error(),
 		})
 		return
 	}
 
+	ctx := c.Request.Context()
+
 	// Check if username or email already exists
-	// This would normally be done via DB call
-	// For now, let's assume they don't exist
+	if !h.checkCredentialsAvailable(c, req.Username, req.Email) {
+		return
+	}
 
 	// Hash the password
 	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, AuthResponse{
 			Success: false,
+			Error:   "server_error",
 			Message: "Error hashing password",
 		})
 		return
 	}
 
-	// Create a new user
+	// Create the user and their starting resources together
 	userID := uuid.New().String()
 	user := model.NewUser(userID, req.Username, req.Email, passwordHash)
 
-	// Save the user to the database
-	// This would normally be done via DB call
-	// For now, let's assume it was saved successfully
+	var res *AuthResponse
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		if err := createPlayer(ctx, tx, user); err != nil {
+			return err
+		}
+		res, err = h.startSession(ctx, tx, h.newSession(c, userID))
+		return err
+	})
+	if errors.Is(err, store.ErrDuplicate) {
+		// Lost a race with another registration for the same name or email
+		c.JSON(http.StatusConflict, AuthResponse{
+			Success: false,
+			Error:   "user_exists",
+			Message: "Username or email is already taken",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Error:   "server_error",
+			Message: "Error creating user",
+		})
+		return
+	}
+
+	// The account works without a verified email, so a failed send is only logged
+	withLogField(c, "user_id", userID)
+	if err := h.sendVerificationEmail(ctx, user); err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).Error("Error sending verification email")
+	}
+
+	c.JSON(http.StatusOK, res)
+}
 
-	// Create initial player resources
-	resources := model.NewPlayerResources(userID, 1000, 100)
+// checkCredentialsAvailable responds with a conflict and returns false if the
+// username or email is already taken
+func (h *handler) checkCredentialsAvailable(c *gin.Context, username, email string) bool {
+	ctx := c.Request.Context()
 
-	// Save the resources to the database
-	// This would normally be done via DB call
+	if _, err := h.store.Users().GetByUsername(ctx, username); err == nil {
+		c.JSON(http.StatusConflict, AuthResponse{
+			Success: false,
+			Error:   "username_taken",
+			Message: "Username is already taken",
+		})
+		return false
+	} else if !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Error:   "server_error",
+			Message: "Error checking username",
+		})
+		return false
+	}
+	if _, err := h.store.Users().GetByEmail(ctx, email); err == nil {
+		c.JSON(http.StatusConflict, AuthResponse{
+			Success: false,
+			Error:   "email_taken",
+			Message: "Email is already registered",
+		})
+		return false
+	} else if !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Error:   "server_error",
+			Message: "Error checking email",
+		})
+		return false
+	}
+	return true
+}
 
-	// Generate a JWT token
-	token, err := generateToken(userID, c)
+// createPlayer creates a user with their starting resources
+func createPlayer(ctx context.Context, tx store.Store, user *model.User) error {
+	if err := tx.Users().Create(ctx, user); err != nil {
+		return err
+	}
+	return tx.Resources().Create(ctx, model.NewPlayerResources(user.ID, 1000, 100))
+}
+
+// guestHandler logs in the guest playing on a device, creating the guest on
+// the device's first visit
+func (h *handler) guestHandler(c *gin.Context) {
+	var req GuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var res *AuthResponse
+	var err error
+	// A concurrent first visit from the same device can create the guest
+	// between our lookup and insert; the second attempt then logs in to it
+	for attempt := 0; attempt < 2; attempt++ {
+		err = h.store.WithTx(ctx, func(tx store.Store) error {
+			user, err := tx.Users().GetByDeviceID(ctx, req.DeviceID)
+			if errors.Is(err, store.ErrNotFound) {
+				user = model.NewGuestUser(uuid.New().String(), req.DeviceID)
+				err = createPlayer(ctx, tx, user)
+			} else if err == nil {
+				err = tx.Users().UpdateLastLogin(ctx, user.ID, time.Now())
+			}
+			if err != nil {
+				return err
+			}
+
+			res, err = h.startSession(ctx, tx, h.newSession(c, user.ID))
+			return err
+		})
+		if !errors.Is(err, store.ErrDuplicate) {
+			break
+		}
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, AuthResponse{
 			Success: false,
-			Message: "Error generating token",
+			Error:   "server_error",
+			Message: "Error logging in guest",
 		})
 		return
 	}
 
+	res.Guest = true
+	withLogField(c, "user_id", res.UserID)
+	c.JSON(http.StatusOK, res)
+}
+
+// upgradeHandler registers credentials for the authenticated guest. The user
+// keeps its ID, so heroes, items, resources and sessions all carry over.
+func (h *handler) upgradeHandler(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	if !h.checkCredentialsAvailable(c, req.Username, req.Email) {
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		respondServerError(c, "Error hashing password", err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	var user *model.User
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		user, err = tx.Users().GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.IsGuest() {
+			return errAlreadyRegistered
+		}
+		user.Upgrade(req.Username, req.Email, passwordHash)
+		return tx.Users().Update(ctx, user)
+	})
+	if errors.Is(err, store.ErrDuplicate) {
+		// Lost a race with a registration for the same name or email
+		respondError(c, http.StatusConflict, "user_exists", "Username or email is already taken")
+		return
+	}
+	if errors.Is(err, errAlreadyRegistered) {
+		respondError(c, http.StatusConflict, errAlreadyRegistered.Code, errAlreadyRegistered.Message)
+		return
+	}
+	if err != nil {
+		respondTxError(c, err, "Error upgrading account")
+		return
+	}
+
+	if err := h.sendVerificationEmail(ctx, user); err != nil {
+		logging.FromContext(c.Request.Context()).WithError(err).Error("Error sending verification email")
+	}
+
 	c.JSON(http.StatusOK, AuthResponse{
 		Success: true,
 		UserID:  userID,
-		Token:   token,
 	})
 }
 
 // loginHandler handles user login
-func loginHandler(c *gin.Context) {
+func (h *handler) loginHandler(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, AuthResponse{
 			Success: false,
+			Error:   "invalid_request",
 			Message: "Invalid request: " + err.Error(),
 		})
 		return
 	}
 
+	ctx := c.Request.Context()
+
 	// Check if user exists and password is correct
-	// This would normally be done via DB call
-	// For now, let's assume the username is "demo" and password is "password"
-	if req.Username != "demo" || req.Password != "password" {
+	user, err := h.store.Users().GetByUsername(ctx, req.Username)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Error:   "server_error",
+			Message: "Error looking up user",
+		})
+		return
+	}
+	// A locked account refuses every password, so guesses cannot be checked
+	// until the lockout ends. Unknown usernames, wrong passwords and locked
+	// accounts get the same response after the same password check, so
+	// neither reveals which accounts exist or are locked.
+	hash := auth.DummyHash()
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	valid := auth.CheckPasswordHash(req.Password, hash)
+	locked := user != nil && user.IsLocked(time.Now())
+	if user == nil || !valid || locked {
+		if user != nil && !valid && !locked {
+			h.failLogin(c, user.ID)
+		}
 		c.JSON(http.StatusUnauthorized, AuthResponse{
 			Success: false,
+			Error:   "invalid_credentials",
 			Message: "Invalid credentials",
 		})
 		return
 	}
+	userID := user.ID
+	withLogField(c, "user_id", userID)
+
+	// Forget earlier wrong passwords, update last login time and start a new
+	// session, flagged if it looks unlike the user's earlier ones
+	session := h.newSession(c, userID)
+	var res *AuthResponse
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		if user.FailedLogins > 0 || user.LockedUntil != nil {
+			account, err := tx.Users().GetByID(ctx, userID)
+			if err != nil {
+				return err
+			}
+			account.ResetFailedLogins()
+			if err := tx.Users().Update(ctx, account); err != nil {
+				return err
+			}
+		}
+		if err := tx.Users().UpdateLastLogin(ctx, userID, time.Now()); err != nil {
+			return err
+		}
+		previous, err := tx.Sessions().ListByUser(ctx, userID, familiarSessions)
+		if err != nil {
+			return err
+		}
+		session.FlagUnfamiliar(previous)
+		res, err = h.startSession(ctx, tx, session)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Error:   "server_error",
+			Message: "Error starting session",
+		})
+		return
+	}
+
+	if session.NewDevice || session.NewCountry {
+		h.reportUnfamiliarLogin(c, user, session)
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// failLogin counts a wrong password for the user and locks the account once
+// there have been too many in a row. A failure to count it is only logged, as
+// the login is refused either way.
+func (h *handler) failLogin(c *gin.Context, userID string) {
+	ctx := c.Request.Context()
+
+	var user *model.User
+	var lockFor time.Duration
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		user, err = tx.Users().GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if user.IsLocked(now) {
+			// A concurrent attempt locked the account already
+			return nil
+		}
+		lockFor = auth.LockoutDuration(h.cfg, user.FailedLogins+1)
+		user.FailLogin(now, lockFor)
+		return tx.Users().Update(ctx, user)
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).WithField("user_id", userID).Error("Error counting failed login")
+		return
+	}
+
+	if lockFor > 0 {
+		logging.FromContext(ctx).WithFields(logrus.Fields{
+			"user_id":       userID,
+			"failed_logins": user.FailedLogins,
+			"locked_for":    lockFor.String(),
+		}).Warn("Locked account after failed logins")
+	}
+}
+
+// reportUnfamiliarLogin logs a login from a new device or country and tells
+// the user by email, so they can act quickly if it was not them. A failed
+// send is only logged, as the login itself succeeded.
+func (h *handler) reportUnfamiliarLogin(c *gin.Context, user *model.User, session *model.Session) {
+	ctx := c.Request.Context()
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"session_id":  session.ID,
+		"ip":          session.IP,
+		"country":     session.Country,
+		"user_agent":  session.UserAgent,
+		"new_device":  session.NewDevice,
+		"new_country": session.NewCountry,
+	}).Warn("Login from an unfamiliar device or country")
+
+	if user.Email == "" {
+		return
+	}
+
+	var from string
+	switch {
+	case session.NewDevice && session.NewCountry:
+		from = "a new device in another country"
+	case session.NewDevice:
+		from = "a new device"
+	default:
+		from = "another country"
+	}
+	country := session.Country
+	if country == "" {
+		country = "unknown"
+	}
+
+	err := h.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "New login to your Oden account",
+		Body: fmt.Sprintf("Hi %s,\n\nYour Oden account was just logged in to from %s:\n\n"+
+			"Time: %s\nIP address: %s\nCountry: %s\nDevice: %s\n\n"+
+			"If this was you, you can ignore this email. If not, reset your password right away; "+
+			"resetting it signs you out on every device.\n",
+			user.Username, from, session.CreatedAt.UTC().Format(time.RFC1123), session.IP, country, session.UserAgent),
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Error("Error sending login alert email")
+	}
+}
+
+// refreshHandler exchanges a refresh token for a new access token and a new
+// refresh token. Each refresh token can be used once: presenting one that was
+// already exchanged means it was copied, so the whole session is revoked and
+// both the thief and the legitimate client have to log in again.
+func (h *handler) refreshHandler(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Error:   "invalid_request",
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
 
-	// Get user by username
-	// This would normally be done via DB call
-	// For now, let's assume a user ID
-	userID := "user_123456"
+	ctx := c.Request.Context()
+	now := time.Now()
 
-	// Update last login time
-	// This would normally be done via DB call
+	var res *AuthResponse
+	reused := false
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		token, err := tx.Sessions().GetRefreshToken(ctx, auth.HashRefreshToken(req.RefreshToken))
+		if errors.Is(err, store.ErrNotFound) {
+			return errInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		session, err := tx.Sessions().Get(ctx, token.SessionID)
+		if err != nil {
+			return err
+		}
 
-	// Generate a JWT token
-	token, err := generateToken(userID, c)
+		if token.UsedAt != nil {
+			// Commit the revocation, then reject the request
+			reused = true
+			session.Revoke(now)
+			return tx.Sessions().Update(ctx, session)
+		}
+		if !session.IsActive(now) || !now.Before(token.ExpiresAt) {
+			return errInvalidRefreshToken
+		}
+
+		token.UsedAt = &now
+		if err := tx.Sessions().UpdateRefreshToken(ctx, token); err != nil {
+			return err
+		}
+		res, err = h.issueTokens(ctx, tx, session)
+		return err
+	})
+	if errors.Is(err, errInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Error:   errInvalidRefreshToken.Code,
+			Message: errInvalidRefreshToken.Message,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, AuthResponse{
 			Success: false,
-			Message: "Error generating token",
+			Error:   "server_error",
+			Message: "Error refreshing session",
+		})
+		return
+	}
+	if reused {
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Error:   "refresh_token_reused",
+			Message: "Refresh token was already used; the session has been revoked",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, AuthResponse{
-		Success: true,
-		UserID:  userID,
-		Token:   token,
+	withLogField(c, "user_id", res.UserID)
+	c.JSON(http.StatusOK, res)
+}
+
+// logoutHandler revokes the session of the access token, or every session of
+// the user. Access tokens of revoked sessions are rejected immediately.
+func (h *handler) logoutHandler(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now()
+
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		if req.All {
+			return tx.Sessions().RevokeByUser(ctx, currentUserID(c), now)
+		}
+		session, err := tx.Sessions().Get(ctx, c.GetString("sessionID"))
+		if err != nil {
+			return err
+		}
+		session.Revoke(now)
+		return tx.Sessions().Update(ctx, session)
+	})
+	if err != nil {
+		respondServerError(c, "Error logging out", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
 	})
 }
 
-// generateToken generates a JWT token for the user
-func generateToken(userID string, c *gin.Context) (string, error) {
-	// Get the config from the context
-	cfg, exists := c.Get("config")
-	if !exists {
-		// If not in the context, use a default secret (should not happen in production)
-		return auth.GenerateToken(userID, &config.Config{
-			Auth: config.AuthConfig{
-				JWTSecret:   "default-secret-change-in-production",
-				TokenExpiry: 24,
-			},
-		})
+// listSessionsHandler lists the user's latest sessions with where they were
+// started from, so players can spot logins that were not theirs
+func (h *handler) listSessionsHandler(c *gin.Context) {
+	sessions, err := h.store.Sessions().ListByUser(c.Request.Context(), currentUserID(c), recentSessions)
+	if err != nil {
+		respondServerError(c, "Error listing sessions", err)
+		return
+	}
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, SessionInfo{Session: s, Current: s.ID == c.GetString("sessionID")})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"sessions": infos,
+	})
+}
+
+// newSession creates a session for the user that records where the request
+// came from
+func (h *handler) newSession(c *gin.Context, userID string) *model.Session {
+	session := model.NewSession(model.NewID("session"), userID, time.Now().Add(auth.RefreshTokenExpiry(h.cfg)))
+	session.IP = c.ClientIP()
+	session.UserAgent = truncate(strings.ToValidUTF8(c.Request.UserAgent(), ""), maxUserAgentLength)
+	session.Country = h.clientCountry(c)
+	return session
+}
+
+// clientCountry returns the country code the proxy in front of the server
+// reports for the client, or "" if it is unknown
+func (h *handler) clientCountry(c *gin.Context) string {
+	if h.cfg.Auth.CountryHeader == "" {
+		return ""
+	}
+	country := strings.ToUpper(strings.TrimSpace(c.GetHeader(h.cfg.Auth.CountryHeader)))
+	// Cloudflare reports XX for addresses it has no country for
+	if len(country) != 2 || country == "XX" {
+		return ""
+	}
+	for _, r := range country {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return country
+}
+
+// truncate shortens s to at most max bytes without splitting a character
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for i := max; i > 0; i-- {
+		if utf8.RuneStart(s[i]) {
+			return s[:i]
+		}
 	}
+	return ""
+}
 
-	return auth.GenerateToken(userID, cfg.(*config.Config))
+// startSession saves a new session and issues its first tokens
+func (h *handler) startSession(ctx context.Context, tx store.Store, session *model.Session) (*AuthResponse, error) {
+	if err := tx.Sessions().Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return h.issueTokens(ctx, tx, session)
 }
 
-// authMiddleware is a middleware to authenticate requests
-func authMiddleware(cfg *config.Config) gin.HandlerFunc {
+// issueTokens extends the session and issues a new refresh token for it,
+// along with an access token naming the session
+func (h *handler) issueTokens(ctx context.Context, tx store.Store, session *model.Session) (*AuthResponse, error) {
+	now := time.Now()
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(auth.RefreshTokenExpiry(h.cfg))
+	if err := tx.Sessions().Update(ctx, session); err != nil {
+		return nil, err
+	}
+
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Sessions().CreateRefreshToken(ctx, &model.RefreshToken{
+		Hash:      hash,
+		SessionID: session.ID,
+		CreatedAt: now,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := h.keys.GenerateToken(session.UserID, session.ID, h.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		Success:      true,
+		UserID:       session.UserID,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.AccessTokenExpiry(h.cfg).Seconds()),
+	}, nil
+}
+
+// jwksHandler publishes the public keys access tokens are verified with, so
+// other services can verify player tokens themselves
+func (h *handler) jwksHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
+
+// authMiddleware is a middleware to authenticate requests. Besides checking
+// the access token, it rejects tokens whose session has been revoked.
+func (h *handler) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -183,7 +747,7 @@ func authMiddleware(cfg *config.Config) gin.HandlerFunc {
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// Validate the token
-		claims, err := auth.ValidateToken(token, cfg)
+		claims, err := h.keys.ValidateToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
@@ -194,11 +758,31 @@ func authMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		// Store the user ID in the context
+		// Check that the session has not been revoked
+		session, err := h.store.Sessions().Get(c.Request.Context(), claims.SessionID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			respondServerError(c, "Error checking session", err)
+			c.Abort()
+			return
+		}
+		if session == nil || session.UserID != claims.UserID || session.RevokedAt != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "invalid_token",
+				"message": "Session is no longer valid",
+			})
+			c.Abort()
+			return
+		}
+
+		// Store the user and session IDs in the context, and log the user ID
+		// with everything logged for the request
 		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.SessionID)
+		withLogField(c, "user_id", claims.UserID)
 
 		// Store the config in the context
-		c.Set("config", cfg)
+		c.Set("config", h.cfg)
 
 		// Continue
 		c.Next()
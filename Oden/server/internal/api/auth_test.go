@@ -0,0 +1,395 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/oden/internal/auth"
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/model"
+)
+
+// loginAlertSubject is the subject of the email about an unfamiliar login
+const loginAlertSubject = "New login to your Oden account"
+
+// login logs in and returns the response
+func (s *testServer) login(t *testing.T, username, password string, header ...string) (*httptest.ResponseRecorder, AuthResponse) {
+	t.Helper()
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/auth/login", "", LoginRequest{Username: username, Password: password}, &res, header...)
+	return w, res
+}
+
+func TestRegisterCreatesPlayer(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	creds, session := s.register(t)
+
+	user, err := s.store.Users().GetByUsername(ctx, creds.Username)
+	if err != nil {
+		t.Fatalf("registered user: %v", err)
+	}
+	if user.ID != session.UserID || user.Email != creds.Email {
+		t.Errorf("stored user %s with email %s, want %s with %s", user.ID, user.Email, session.UserID, creds.Email)
+	}
+	if user.PasswordHash == creds.Password || !auth.CheckPasswordHash(creds.Password, user.PasswordHash) {
+		t.Error("password not stored as a hash of itself")
+	}
+	resources, err := s.store.Resources().Get(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("starting resources: %v", err)
+	}
+	if resources.Gold != 1000 || resources.PremiumCurrency != 100 {
+		t.Errorf("starting resources %d gold and %d gems, want 1000 and 100", resources.Gold, resources.PremiumCurrency)
+	}
+}
+
+func TestRegisterRejectsTakenCredentials(t *testing.T) {
+	s := newTestServer(t)
+	creds, _ := s.register(t)
+
+	tests := []struct {
+		name string
+		req  RegisterRequest
+		want string
+	}{
+		{"username", RegisterRequest{Username: creds.Username, Email: "other_" + creds.Email, Password: "password123"}, "username_taken"},
+		{"email", RegisterRequest{Username: "other_" + creds.Username, Email: creds.Email, Password: "password123"}, "email_taken"},
+	}
+	for _, tt := range tests {
+		var res AuthResponse
+		if w := s.do(t, http.MethodPost, "/v1/auth/register", "", tt.req, &res); w.Code != http.StatusConflict || res.Error != tt.want {
+			t.Errorf("taken %s: %d %s, want 409 %s", tt.name, w.Code, res.Error, tt.want)
+		}
+	}
+}
+
+func TestLoginChecksPassword(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	creds, session := s.register(t)
+
+	if w, res := s.login(t, creds.Username, "wrong password"); w.Code != http.StatusUnauthorized || res.Error != "invalid_credentials" {
+		t.Errorf("wrong password: %d %s, want 401 invalid_credentials", w.Code, res.Error)
+	}
+	if w, res := s.login(t, "nobody_"+creds.Username, creds.Password); w.Code != http.StatusUnauthorized || res.Error != "invalid_credentials" {
+		t.Errorf("unknown username: %d %s, want 401 invalid_credentials", w.Code, res.Error)
+	}
+
+	earlier := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := s.store.Users().UpdateLastLogin(ctx, session.UserID, earlier); err != nil {
+		t.Fatal(err)
+	}
+	w, res := s.login(t, creds.Username, creds.Password)
+	if w.Code != http.StatusOK || res.UserID != session.UserID || res.Token == "" {
+		t.Fatalf("login: %d %s", w.Code, w.Body.String())
+	}
+	user, err := s.store.Users().GetByID(ctx, session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.LastLogin.After(earlier) {
+		t.Errorf("last login %v not updated", user.LastLogin)
+	}
+}
+
+func TestLoginLocksAccountAfterThreshold(t *testing.T) {
+	s := newTestServer(t)
+	creds, _ := s.register(t)
+
+	for i := 0; i < s.cfg.Auth.LockoutThreshold; i++ {
+		if w, res := s.login(t, creds.Username, "wrong password"); w.Code != http.StatusUnauthorized || res.Error != "invalid_credentials" {
+			t.Fatalf("wrong password %d: %d %s, want 401 invalid_credentials", i+1, w.Code, res.Error)
+		}
+	}
+	user, err := s.store.Users().GetByUsername(context.Background(), creds.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsLocked(time.Now()) {
+		t.Fatalf("account not locked after %d wrong passwords", s.cfg.Auth.LockoutThreshold)
+	}
+
+	// The right password is refused like a wrong password or an unknown
+	// username, so the response does not tell that the account is locked
+	locked, _ := s.login(t, creds.Username, creds.Password)
+	wrong, _ := s.login(t, creds.Username, "wrong password")
+	unknown, _ := s.login(t, "nobody_"+creds.Username, creds.Password)
+	for name, w := range map[string]*httptest.ResponseRecorder{"locked": locked, "wrong password": wrong, "unknown username": unknown} {
+		if w.Code != http.StatusUnauthorized || w.Body.String() != unknown.Body.String() {
+			t.Errorf("%s: %d %s, want 401 %s", name, w.Code, w.Body.String(), unknown.Body.String())
+		}
+		if retry := w.Header().Get("Retry-After"); retry != "" {
+			t.Errorf("%s: Retry-After %s", name, retry)
+		}
+	}
+}
+
+func TestLoginAfterLockoutEnds(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	creds, _ := s.register(t)
+	for i := 0; i < s.cfg.Auth.LockoutThreshold; i++ {
+		s.login(t, creds.Username, "wrong password")
+	}
+
+	// Let the lockout end
+	user, err := s.store.Users().GetByUsername(ctx, creds.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ended := time.Now().Add(-time.Second)
+	user.LockedUntil = &ended
+	if err := s.store.Users().Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	if w, res := s.login(t, creds.Username, creds.Password); w.Code != http.StatusOK || res.Token == "" {
+		t.Fatalf("login after the lockout: %d %s", w.Code, w.Body.String())
+	}
+	user, err = s.store.Users().GetByUsername(ctx, creds.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.FailedLogins != 0 || user.LockedUntil != nil {
+		t.Errorf("after a login: %d failed logins, locked until %v; want the count reset", user.FailedLogins, user.LockedUntil)
+	}
+}
+
+func TestLoginFlagsUnfamiliarDevicesAndCountries(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.Auth.CountryHeader = "CF-IPCountry" })
+	creds, _ := s.register(t)
+
+	tests := []struct {
+		name      string
+		userAgent string
+		country   string
+		alert     bool
+	}{
+		{"same device, first country", "", "SE", false},
+		{"new device", "phone/1.0", "SE", true},
+		{"same device", "phone/1.0", "SE", false},
+		{"new country", "phone/1.0", "US", true},
+		{"unknown country", "phone/1.0", "XX", false},
+	}
+	for _, tt := range tests {
+		before := s.mailer.count(creds.Email, loginAlertSubject)
+		w, _ := s.login(t, creds.Username, creds.Password, "User-Agent", tt.userAgent, "CF-IPCountry", tt.country)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: login %d %s", tt.name, w.Code, w.Body.String())
+		}
+		if alert := s.mailer.count(creds.Email, loginAlertSubject) > before; alert != tt.alert {
+			t.Errorf("%s: alert sent %v, want %v", tt.name, alert, tt.alert)
+		}
+	}
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	var refreshed AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken}, &refreshed)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: %d %s", w.Code, w.Body.String())
+	}
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == session.RefreshToken {
+		t.Fatal("refresh did not issue a new refresh token")
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", refreshed.Token, nil, nil); w.Code != http.StatusOK {
+		t.Errorf("new access token: %d %s", w.Code, w.Body.String())
+	}
+
+	var res AuthResponse
+	w = s.do(t, http.MethodPost, "/v1/auth/refresh", "", RefreshRequest{RefreshToken: "unknown"}, &res)
+	if w.Code != http.StatusUnauthorized || res.Error != errInvalidRefreshToken.Code {
+		t.Errorf("unknown refresh token: %d %s, want 401 %s", w.Code, res.Error, errInvalidRefreshToken.Code)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	var refreshed AuthResponse
+	if w := s.do(t, http.MethodPost, "/v1/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken}, &refreshed); w.Code != http.StatusOK {
+		t.Fatalf("refresh: %d %s", w.Code, w.Body.String())
+	}
+
+	// The old refresh token was copied and is presented again
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken}, &res)
+	if w.Code != http.StatusUnauthorized || res.Error != "refresh_token_reused" {
+		t.Fatalf("reused refresh token: %d %s, want 401 refresh_token_reused", w.Code, res.Error)
+	}
+
+	// Neither the thief nor the legitimate client can go on with the session
+	w = s.do(t, http.MethodPost, "/v1/auth/refresh", "", RefreshRequest{RefreshToken: refreshed.RefreshToken}, &res)
+	if w.Code != http.StatusUnauthorized || res.Error != errInvalidRefreshToken.Code {
+		t.Errorf("refresh token issued before the reuse: %d %s, want 401 %s", w.Code, res.Error, errInvalidRefreshToken.Code)
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", refreshed.Token, nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("access token of the revoked session: %d, want 401", w.Code)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	s := newTestServer(t)
+	creds, first := s.register(t)
+	_, second := s.login(t, creds.Username, creds.Password)
+
+	var sessions struct {
+		Sessions []SessionInfo `json:"sessions"`
+	}
+	if w := s.do(t, http.MethodGet, "/v1/auth/sessions", second.Token, nil, &sessions); w.Code != http.StatusOK {
+		t.Fatalf("list sessions: %d %s", w.Code, w.Body.String())
+	}
+	current := 0
+	for _, info := range sessions.Sessions {
+		if info.Current {
+			current++
+		}
+	}
+	if len(sessions.Sessions) != 2 || current != 1 {
+		t.Errorf("listed %d sessions with %d current, want 2 with 1", len(sessions.Sessions), current)
+	}
+
+	if w := s.do(t, http.MethodPost, "/v1/auth/logout", first.Token, nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("logout: %d %s", w.Code, w.Body.String())
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", first.Token, nil, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("access token after logout: %d, want 401", w.Code)
+	}
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/auth/refresh", "", RefreshRequest{RefreshToken: first.RefreshToken}, &res)
+	if w.Code != http.StatusUnauthorized || res.Error != errInvalidRefreshToken.Code {
+		t.Errorf("refresh token after logout: %d %s, want 401 %s", w.Code, res.Error, errInvalidRefreshToken.Code)
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", second.Token, nil, nil); w.Code != http.StatusOK {
+		t.Errorf("access token of the other session: %d, want 200", w.Code)
+	}
+}
+
+func TestLogoutAllRevokesEverySession(t *testing.T) {
+	s := newTestServer(t)
+	creds, first := s.register(t)
+	_, second := s.login(t, creds.Username, creds.Password)
+
+	if w := s.do(t, http.MethodPost, "/v1/auth/logout", second.Token, LogoutRequest{All: true}, nil); w.Code != http.StatusOK {
+		t.Fatalf("logout everywhere: %d %s", w.Code, w.Body.String())
+	}
+	for name, session := range map[string]AuthResponse{"first": first, "second": second} {
+		if w := s.do(t, http.MethodGet, "/v1/heroes/list", session.Token, nil, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("access token of the %s session: %d, want 401", name, w.Code)
+		}
+		w := s.do(t, http.MethodPost, "/v1/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken}, nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("refresh token of the %s session: %d, want 401", name, w.Code)
+		}
+	}
+}
+
+func TestJWKSPublishesTheSigningKey(t *testing.T) {
+	s := newTestServer(t)
+
+	var set auth.JWKS
+	w := s.do(t, http.MethodGet, "/.well-known/jwks.json", "", nil, &set)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET jwks = %d, want 200", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=300" {
+		t.Errorf("Cache-Control = %q, want it cacheable", cc)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].KeyID != "test" || set.Keys[0].KeyType != "OKP" || set.Keys[0].X == "" {
+		t.Errorf("JWKS = %+v, want the test key", set.Keys)
+	}
+}
+
+func TestGuestLogsInByDevice(t *testing.T) {
+	s := newTestServer(t)
+	req := GuestRequest{DeviceID: uuid.New().String()}
+
+	var first, second AuthResponse
+	if w := s.do(t, http.MethodPost, "/v1/auth/guest", "", req, &first); w.Code != http.StatusOK || !first.Guest || first.Token == "" {
+		t.Fatalf("guest: %d %+v", w.Code, first)
+	}
+	if w := s.do(t, http.MethodPost, "/v1/auth/guest", "", req, &second); w.Code != http.StatusOK {
+		t.Fatalf("guest again: %d %s", w.Code, second.Error)
+	}
+	if second.UserID != first.UserID {
+		t.Errorf("guest of the same device is user %s, want %s", second.UserID, first.UserID)
+	}
+
+	resources, err := s.store.Resources().Get(context.Background(), first.UserID)
+	if err != nil || resources.Gold != 1000 {
+		t.Errorf("guest resources = %+v, %v; want a new player's", resources, err)
+	}
+
+	var res AuthResponse
+	if w := s.do(t, http.MethodPost, "/v1/auth/guest", "", GuestRequest{DeviceID: "short"}, &res); w.Code != http.StatusBadRequest {
+		t.Errorf("guest with a short device ID: %d, want 400", w.Code)
+	}
+}
+
+func TestUpgradeKeepsTheGuestsProgress(t *testing.T) {
+	s := newTestServer(t)
+	token := s.guest(t)
+	hero := s.summonHero(t, token)
+
+	name := "player_" + uuid.New().String()[:8]
+	req := RegisterRequest{Username: name, Email: name + "@example.com", Password: "password123"}
+	var upgraded AuthResponse
+	if w := s.do(t, http.MethodPost, "/v1/auth/upgrade", token, req, &upgraded); w.Code != http.StatusOK {
+		t.Fatalf("upgrade: %d %s", w.Code, upgraded.Error)
+	}
+	if n := s.mailer.count(req.Email, "Verify your email address"); n != 1 {
+		t.Errorf("sent %d verification emails, want 1", n)
+	}
+
+	// The guest's session still works and the credentials log in to the same user
+	var heroes struct {
+		Heroes []*model.HeroWithDetails `json:"heroes"`
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", token, nil, &heroes); w.Code != http.StatusOK {
+		t.Fatalf("list heroes as the guest: %d %s", w.Code, w.Body.String())
+	}
+	w, session := s.login(t, req.Username, req.Password)
+	if w.Code != http.StatusOK || session.UserID != upgraded.UserID {
+		t.Fatalf("login after upgrade: %d user %s, want user %s", w.Code, session.UserID, upgraded.UserID)
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", session.Token, nil, &heroes); w.Code != http.StatusOK {
+		t.Fatalf("list heroes: %d %s", w.Code, w.Body.String())
+	}
+	if len(heroes.Heroes) != 1 || heroes.Heroes[0].ID != hero.ID {
+		t.Errorf("heroes after upgrade %+v, want the guest's hero", heroes.Heroes)
+	}
+	resources, err := s.store.Resources().Get(context.Background(), upgraded.UserID)
+	if err != nil || resources.Gold != 1000-heroSummonOptions["basic"].GoldCost {
+		t.Errorf("resources after upgrade = %+v, %v; want the guest's", resources, err)
+	}
+}
+
+func TestUpgradeRejectsRegisteredAccountsAndTakenCredentials(t *testing.T) {
+	s := newTestServer(t)
+	taken, session := s.register(t)
+
+	var res AuthResponse
+	name := "player_" + uuid.New().String()[:8]
+	req := RegisterRequest{Username: name, Email: name + "@example.com", Password: "password123"}
+	if w := s.do(t, http.MethodPost, "/v1/auth/upgrade", session.Token, req, &res); w.Code != http.StatusConflict || res.Error != "already_registered" {
+		t.Errorf("upgrade of a registered account: %d %s, want 409 already_registered", w.Code, res.Error)
+	}
+
+	token := s.guest(t)
+	req.Username = taken.Username
+	if w := s.do(t, http.MethodPost, "/v1/auth/upgrade", token, req, &res); w.Code != http.StatusConflict || res.Error != "username_taken" {
+		t.Errorf("upgrade to a taken username: %d %s, want 409 username_taken", w.Code, res.Error)
+	}
+	if w := s.do(t, http.MethodPost, "/v1/auth/upgrade", "", req, &res); w.Code != http.StatusUnauthorized {
+		t.Errorf("upgrade without a token: %d, want 401", w.Code)
+	}
+}
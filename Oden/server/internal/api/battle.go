@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/battle"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// errNoTeam is returned when a battle is started without a team
+var errNoTeam = model.CustomError{Message: "Save a team before starting a battle", Code: "invalid_request"}
+
+// StartBattleRequest represents the request to start a battle
+type StartBattleRequest struct {
+	StageID string `json:"stage_id" binding:"required"`
+}
+
+// startBattleHandler fights a stage with the user's team and grants the rewards
+func (h *handler) startBattleHandler(c *gin.Context) {
+	var req StartBattleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	stage, err := loadStage(ctx, h.store, req.StageID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, "resource_not_found", "Stage not found")
+		return
+	}
+	if err != nil {
+		respondServerError(c, "Error loading stage", err)
+		return
+	}
+
+	var result *model.BattleResult
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		resources, err := lockPlayer(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		team, err := loadTeam(ctx, tx, userID)
+		if errors.Is(err, store.ErrNotFound) {
+			return errNoTeam
+		}
+		if err != nil {
+			return err
+		}
+
+		_, span := tracer.Start(ctx, "battle.Simulate")
+		sim, err := battle.Simulate(team, stage, rng.Int63())
+		span.End()
+		if errors.Is(err, battle.ErrNoHeroes) {
+			return errNoTeam
+		}
+		if err != nil {
+			return err
+		}
+
+		published := []events.Event{events.BattleCompleted{
+			UserID:           userID,
+			StageID:          stage.ID,
+			Victory:          sim.Outcome == battle.Victory,
+			KilledEnemyTypes: killedEnemyTypes(stage, sim.Log),
+		}}
+
+		rewards := &model.Rewards{
+			Experience: make(map[string]int),
+			Items:      []string{},
+		}
+		if sim.Outcome == battle.Victory {
+			rewards.Gold = stage.GoldReward
+			for pos := 1; pos <= maxTeamSize; pos++ {
+				hero := team.Heroes[pos]
+				if hero == nil {
+					continue
+				}
+				level := hero.Level
+				hero.AddExperience(stage.ExpReward)
+				rewards.Experience[hero.ID] = stage.ExpReward
+				if err := tx.Heroes().Update(ctx, hero); err != nil {
+					return err
+				}
+				published = append(published, events.LevelUp(hero, level)...)
+			}
+
+			resources.Gold += rewards.Gold
+			if err := tx.Resources().Update(ctx, resources); err != nil {
+				return err
+			}
+		}
+
+		result = model.NewBattleResult(model.NewID("battle"), userID, team.ID, stage.ID, sim.Outcome, rewards)
+		result.BattleLog = sim.Log
+		result.Seed = sim.Seed
+		if err := tx.BattleResults().Create(ctx, result); err != nil {
+			return err
+		}
+		return h.events.Publish(ctx, tx, published...)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error running battle")
+		return
+	}
+
+	c.JSON(http.StatusOK, result.ToBattleResponse())
+}
+
+// loadStage loads a stage with its enemies scaled to the stage's enemy level.
+// An enemy type that appears more than once gets its position appended to its
+// ID, so every enemy in the battle log is unique.
+func loadStage(ctx context.Context, st store.Store, stageID string) (*model.Stage, error) {
+	stage, err := st.Stages().Get(ctx, stageID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for i, typeID := range stage.GetEnemyIDs() {
+		et, err := st.EnemyTypes().Get(ctx, typeID)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("stage %s references unknown enemy type %s", stage.ID, typeID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		id := typeID
+		if seen[typeID] {
+			id = fmt.Sprintf("%s_%d", typeID, i+1)
+		}
+		seen[typeID] = true
+		stage.Enemies = append(stage.Enemies, et.NewEnemy(id, stage.EnemyLevel))
+	}
+	return stage, nil
+}
+
+// killedEnemyTypes returns the type ID of every enemy that ends the battle with
+// no HP left
+func killedEnemyTypes(stage *model.Stage, log []model.BattleTurn) []string {
+	remaining := make(map[string]int)
+	for _, turn := range log {
+		for _, action := range turn.Actions {
+			remaining[action.Target] = action.TargetHPRemaining
+		}
+	}
+
+	var killed []string
+	for _, enemy := range stage.Enemies {
+		if hp, hit := remaining[enemy.ID]; hit && hp == 0 {
+			killed = append(killed, enemy.TypeID)
+		}
+	}
+	return killed
+}
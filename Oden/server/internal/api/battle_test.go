@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/oden/internal/battle"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+func TestStartBattle(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	_, session := s.register(t)
+	hero := s.summonHero(t, session.Token)
+	s.saveTeam(t, session.Token, hero.ID)
+	before, err := s.store.Resources().Get(ctx, session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res struct {
+		BattleID  string        `json:"battle_id"`
+		Result    string        `json:"result"`
+		BattleLog []interface{} `json:"battle_log"`
+		Rewards   struct {
+			Gold int `json:"gold"`
+		} `json:"rewards"`
+	}
+	if w := s.do(t, http.MethodPost, "/v1/battle/start", session.Token, StartBattleRequest{StageID: "stage_001"}, &res); w.Code != http.StatusOK {
+		t.Fatalf("start battle: %d %s", w.Code, w.Body.String())
+	}
+	if res.BattleID == "" || len(res.BattleLog) == 0 {
+		t.Errorf("battle %+v, want an ID and a log", res)
+	}
+
+	stage, err := s.store.Stages().Get(ctx, "stage_001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := s.store.Resources().Get(ctx, session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantGold := 0
+	if res.Result == battle.Victory {
+		wantGold = stage.GoldReward
+	}
+	if res.Rewards.Gold != wantGold || after.Gold-before.Gold != wantGold {
+		t.Errorf("%s paid %d gold and granted %d, want %d", res.Result, res.Rewards.Gold, after.Gold-before.Gold, wantGold)
+	}
+}
+
+func TestStartBattleRejectsInvalidBattles(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/battle/start", session.Token, StartBattleRequest{StageID: "stage_001"}, &res)
+	if w.Code != http.StatusBadRequest || res.Error != "invalid_request" {
+		t.Errorf("battle without a team: %d %s, want 400 invalid_request", w.Code, w.Body.String())
+	}
+
+	hero := s.summonHero(t, session.Token)
+	s.saveTeam(t, session.Token, hero.ID)
+	res = AuthResponse{}
+	w = s.do(t, http.MethodPost, "/v1/battle/start", session.Token, StartBattleRequest{StageID: "stage_999"}, &res)
+	if w.Code != http.StatusNotFound || res.Error != "resource_not_found" {
+		t.Errorf("battle on an unknown stage: %d %s, want 404 resource_not_found", w.Code, w.Body.String())
+	}
+}
+
+// stageStore serves a single stage in place of the stored ones
+type stageStore struct {
+	store.Store
+	stage model.Stage
+}
+
+func (s stageStore) Stages() store.StageRepository { return s }
+
+func (s stageStore) Get(ctx context.Context, id string) (*model.Stage, error) {
+	if id != s.stage.ID {
+		return nil, store.ErrNotFound
+	}
+	stage := s.stage
+	return &stage, nil
+}
+
+func (s stageStore) List(ctx context.Context) ([]*model.Stage, error) {
+	stage := s.stage
+	return []*model.Stage{&stage}, nil
+}
+
+func TestLoadStageScalesEnemies(t *testing.T) {
+	ctx := context.Background()
+	mem := store.NewMemory()
+	mem.LoadSampleData()
+	st := stageStore{Store: mem, stage: model.Stage{
+		ID: "stage_test", Enemy1: "enemy_001", Enemy2: "enemy_002", Enemy3: "enemy_001", EnemyLevel: 3,
+	}}
+
+	stage, err := loadStage(ctx, st, "stage_test")
+	if err != nil {
+		t.Fatalf("loadStage: %v", err)
+	}
+	want := []struct {
+		id, typeID string
+		hp, atk    int
+	}{
+		// Base stats plus two levels of growth
+		{"enemy_001", "enemy_001", 180 + 2*20, 18 + 2*2},
+		{"enemy_002", "enemy_002", 150 + 2*15, 25 + 2*3},
+		{"enemy_001_3", "enemy_001", 180 + 2*20, 18 + 2*2},
+	}
+	if len(stage.Enemies) != len(want) {
+		t.Fatalf("loaded %d enemies, want %d", len(stage.Enemies), len(want))
+	}
+	for i, w := range want {
+		e := stage.Enemies[i]
+		if e.ID != w.id || e.TypeID != w.typeID || e.Level != 3 || e.HP != w.hp || e.ATK != w.atk || e.CurrentHP != w.hp {
+			t.Errorf("enemy %d = %+v, want %s of type %s at level 3 with %d HP and %d ATK", i+1, e, w.id, w.typeID, w.hp, w.atk)
+		}
+	}
+	if len(stage.Enemies[1].Skills) != 1 {
+		t.Errorf("Wild Wolf has skills %+v, want those of its type", stage.Enemies[1].Skills)
+	}
+}
+
+func TestLoadStageRejectsUnknownEnemyTypes(t *testing.T) {
+	ctx := context.Background()
+	mem := store.NewMemory()
+	mem.LoadSampleData()
+
+	st := stageStore{Store: mem, stage: model.Stage{ID: "stage_test", Enemy1: "enemy_unknown", EnemyLevel: 1}}
+	if _, err := loadStage(ctx, st, "stage_test"); err == nil || errors.Is(err, store.ErrNotFound) {
+		t.Errorf("loadStage with an unknown enemy type returned %v, want an error that is not ErrNotFound", err)
+	}
+	if _, err := loadStage(ctx, st, "stage_999"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("loadStage of an unknown stage returned %v, want ErrNotFound", err)
+	}
+}
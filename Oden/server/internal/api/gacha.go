@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/gacha"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// GachaSummonRequest represents the request to summon on a banner.
+// Free uses the banner's daily free summon and requires a count of 1.
+type GachaSummonRequest struct {
+	BannerID string `json:"banner_id" binding:"required"`
+	Count    int    `json:"count" binding:"required,oneof=1 10"`
+	Free     bool   `json:"free"`
+}
+
+// listBannersHandler returns the banners that are currently running and can be
+// summoned on
+func (h *handler) listBannersHandler(c *gin.Context) {
+	banners, err := h.store.Banners().List(c.Request.Context())
+	if err != nil {
+		respondServerError(c, "Error loading banners", err)
+		return
+	}
+
+	active := make([]*model.Banner, 0, len(banners))
+	for _, b := range banners {
+		if b.IsActive() && gacha.CheckBanner(b) == nil {
+			active = append(active, b)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"banners": active,
+	})
+}
+
+// getBannerRatesHandler returns the rates of a banner and the user's pity progress on it
+func (h *handler) getBannerRatesHandler(c *gin.Context) {
+	bannerID := c.Query("banner_id")
+	if bannerID == "" {
+		respondError(c, http.StatusBadRequest, "invalid_request", "banner_id is required")
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	banner, err := h.store.Banners().Get(ctx, bannerID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, "resource_not_found", "Banner not found")
+		return
+	}
+	if err != nil {
+		respondServerError(c, "Error loading banner", err)
+		return
+	}
+
+	session, err := h.store.Summons().GetSession(ctx, userID, bannerID)
+	if errors.Is(err, store.ErrNotFound) {
+		session = nil
+	} else if err != nil {
+		respondServerError(c, "Error loading summon history", err)
+		return
+	}
+
+	types, err := heroTypeIndex(ctx, h.store)
+	if err != nil {
+		respondServerError(c, "Error loading hero types", err)
+		return
+	}
+	featured := make([]*model.HeroType, 0, len(banner.FeaturedHeroes))
+	for _, id := range banner.FeaturedHeroes {
+		if ht := types[id]; ht != nil {
+			featured = append(featured, ht)
+		}
+	}
+
+	c.JSON(http.StatusOK, banner.ToSummonRateInfo(session, featured))
+}
+
+// summonGachaHandler performs single or ten-pulls on a banner
+func (h *handler) summonGachaHandler(c *gin.Context) {
+	var req GachaSummonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.gacha.Summon(c.Request.Context(), currentUserID(c), req.BannerID, req.Count, req.Free)
+	if err != nil {
+		respondTxError(c, err, "Error summoning")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+func TestSummonGacha(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	var banners struct {
+		Banners []*model.Banner `json:"banners"`
+	}
+	if w := s.do(t, http.MethodGet, "/v1/gacha/banners", session.Token, nil, &banners); w.Code != http.StatusOK {
+		t.Fatalf("list banners: %d %s", w.Code, w.Body.String())
+	}
+	if len(banners.Banners) != 1 || banners.Banners[0].ID != "banner_001" {
+		t.Fatalf("listed %d banners, want only the running banner_001", len(banners.Banners))
+	}
+
+	// The starting 100 gems do not pay for a 300 gem pull, but the daily
+	// free summon is available
+	var failed AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/gacha/summon", session.Token, GachaSummonRequest{BannerID: "banner_001", Count: 1}, &failed)
+	if w.Code != http.StatusBadRequest || failed.Error != "insufficient_resources" {
+		t.Errorf("unaffordable summon: %d %s, want 400 insufficient_resources", w.Code, w.Body.String())
+	}
+	var res model.SummonMultiResult
+	w = s.do(t, http.MethodPost, "/v1/gacha/summon", session.Token, GachaSummonRequest{BannerID: "banner_001", Count: 1, Free: true}, &res)
+	if w.Code != http.StatusOK || len(res.Results) != 1 || len(res.NewHeroes) != 1 {
+		t.Fatalf("free summon: %d %s", w.Code, w.Body.String())
+	}
+
+	var rates model.SummonRateInfo
+	if w := s.do(t, http.MethodGet, "/v1/gacha/rates?banner_id=banner_001", session.Token, nil, &rates); w.Code != http.StatusOK {
+		t.Fatalf("banner rates: %d %s", w.Code, w.Body.String())
+	}
+	wantPity := 1
+	if res.Results[0].Rarity == "legendary" {
+		wantPity = 0
+	}
+	if rates.CurrentPity != wantPity || rates.GuaranteeThreshold != 100 {
+		t.Errorf("rates %+v, want pity %d of 100", rates, wantPity)
+	}
+}
+
+func TestSummonGachaRejectsEndedBanners(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/gacha/summon", session.Token, GachaSummonRequest{BannerID: "banner_002", Count: 1, Free: true}, &res)
+	if w.Code != http.StatusNotFound || res.Error != "resource_not_found" {
+		t.Errorf("summon on an ended banner: %d %s, want 404 resource_not_found", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/health"
+)
+
+// RegisterProbes registers the liveness and readiness probes. /livez only
+// shows that the process serves requests, so an orchestrator restarts it
+// when it does not. /readyz runs the readiness checks and fails with 503 if
+// any dependency does, so load balancers stop routing to the instance until
+// it recovers. /health is kept as an alias of /livez.
+func RegisterProbes(router *gin.Engine, readiness *health.Checker) {
+	live := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": health.StatusOK,
+		})
+	}
+	router.GET("/livez", live)
+	router.GET("/health", live)
+
+	router.GET("/readyz", func(c *gin.Context) {
+		report := readiness.Run(c.Request.Context())
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(status, report)
+	})
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/health"
+)
+
+// probeRouter registers the probes with a readiness check of the database
+// that returns err
+func probeRouter(err *error) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterProbes(router, health.NewChecker(time.Second,
+		health.Check{Name: "database", Run: func(ctx context.Context) error { return *err }},
+	))
+	return router
+}
+
+func TestProbes(t *testing.T) {
+	var dbErr error
+	router := probeRouter(&dbErr)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	for _, path := range []string{"/livez", "/health", "/readyz"} {
+		if w := get(path); w.Code != http.StatusOK {
+			t.Errorf("GET %s = %d %s, want 200", path, w.Code, w.Body.String())
+		}
+	}
+
+	// Liveness does not depend on the database, readiness does
+	dbErr = errors.New("connection refused")
+	if w := get("/livez"); w.Code != http.StatusOK {
+		t.Errorf("GET /livez with the database down = %d, want 200", w.Code)
+	}
+	w := get("/readyz")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz with the database down = %d, want 503", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if r := report.Checks["database"]; report.Status != health.StatusUnavailable || r.Error != "connection refused" {
+		t.Errorf("readiness report %s, want the database error", w.Body.String())
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/gacha"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// SummonHeroRequest represents the request to summon a hero
+type SummonHeroRequest struct {
+	SummonType string `json:"summon_type" binding:"required,oneof=basic premium"`
+}
+
+// heroSummonOption describes the cost and rarity odds of a hero summon type
+type heroSummonOption struct {
+	GoldCost      int
+	GemCost       int
+	RarityWeights []gacha.RarityWeight
+}
+
+// heroSummonOptions are the summon types accepted by /heroes/summon
+var heroSummonOptions = map[string]heroSummonOption{
+	"basic": {
+		GoldCost:      500,
+		RarityWeights: []gacha.RarityWeight{{Rarity: "common", Weight: 60}, {Rarity: "rare", Weight: 35}, {Rarity: "epic", Weight: 5}},
+	},
+	"premium": {
+		GemCost:       100,
+		RarityWeights: []gacha.RarityWeight{{Rarity: "rare", Weight: 60}, {Rarity: "epic", Weight: 32}, {Rarity: "legendary", Weight: 8}},
+	},
+}
+
+// listHeroesHandler returns the user's hero collection
+func (h *handler) listHeroesHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	heroes, err := h.store.Heroes().ListByUser(ctx, userID)
+	if err != nil {
+		respondServerError(c, "Error loading heroes", err)
+		return
+	}
+
+	types, err := heroTypeIndex(ctx, h.store)
+	if err != nil {
+		respondServerError(c, "Error loading hero types", err)
+		return
+	}
+
+	equipment, err := equipmentIndex(ctx, h.store, userID)
+	if err != nil {
+		respondServerError(c, "Error loading equipment", err)
+		return
+	}
+
+	details := make([]*model.HeroWithDetails, 0, len(heroes))
+	for _, hero := range heroes {
+		details = append(details, withHeroType(hero, types, equipment).ToHeroWithDetails())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"heroes": details,
+	})
+}
+
+// summonHeroHandler spends gold or gems to summon a random hero
+func (h *handler) summonHeroHandler(c *gin.Context) {
+	var req SummonHeroRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+	option := heroSummonOptions[req.SummonType]
+
+	types, err := h.store.HeroTypes().List(ctx)
+	if err != nil {
+		respondServerError(c, "Error loading hero types", err)
+		return
+	}
+	heroType := gacha.PickHeroType(types, gacha.RollRarity(option.RarityWeights, rng), rng)
+	if heroType == nil {
+		respondError(c, http.StatusNotFound, "resource_not_found", "No heroes available to summon")
+		return
+	}
+
+	hero := model.NewHero(model.NewID("hero"), userID, heroType.ID)
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		resources, err := lockPlayer(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if resources.Gold < option.GoldCost || resources.PremiumCurrency < option.GemCost {
+			return errInsufficientResources
+		}
+		resources.Gold -= option.GoldCost
+		resources.PremiumCurrency -= option.GemCost
+		if err := tx.Resources().Update(ctx, resources); err != nil {
+			return err
+		}
+		if err := tx.Heroes().Create(ctx, hero); err != nil {
+			return err
+		}
+		return h.events.Publish(ctx, tx, spentEvents(userID, option.GoldCost, option.GemCost,
+			events.HeroesAcquired{UserID: userID, HeroTypeIDs: []string{heroType.ID}},
+			events.HeroesSummoned{UserID: userID, Rarities: []string{heroType.Rarity}})...)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error summoning hero")
+		return
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"summon_type":  req.SummonType,
+		"hero_id":      hero.ID,
+		"hero_type_id": heroType.ID,
+	}).Info("Summoned hero")
+
+	hero.HeroType = heroType
+	hero.Skills = heroType.Skills
+	hero.CalculateStats()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"heroes":  []*model.HeroWithDetails{hero.ToHeroWithDetails()},
+	})
+}
+
+// spentEvents returns the GoldSpent and GemsSpent events for a payment
+// followed by the given events
+func spentEvents(userID string, gold, gems int, rest ...events.Event) []events.Event {
+	var published []events.Event
+	if gold > 0 {
+		published = append(published, events.GoldSpent{UserID: userID, Amount: gold})
+	}
+	if gems > 0 {
+		published = append(published, events.GemsSpent{UserID: userID, Amount: gems})
+	}
+	return append(published, rest...)
+}
+
+// heroTypeIndex loads every hero type keyed by ID
+func heroTypeIndex(ctx context.Context, st store.Store) (map[string]*model.HeroType, error) {
+	types, err := st.HeroTypes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*model.HeroType, len(types))
+	for _, ht := range types {
+		index[ht.ID] = ht
+	}
+	return index, nil
+}
+
+// withHeroType attaches the hero's type, skills and equipment and calculates
+// its stats. equipment is keyed by hero ID, as returned by equipmentIndex.
+func withHeroType(hero *model.Hero, types map[string]*model.HeroType, equipment map[string][]*model.Item) *model.Hero {
+	hero.Equipment = equipment[hero.ID]
+	if ht := types[hero.HeroTypeID]; ht != nil {
+		hero.HeroType = ht
+		hero.Skills = ht.Skills
+		hero.CalculateStats()
+	}
+	return hero
+}
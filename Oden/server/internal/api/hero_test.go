@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// summonHero summons a basic hero for the player and returns it
+func (s *testServer) summonHero(t *testing.T, token string) *model.HeroWithDetails {
+	t.Helper()
+	var res struct {
+		Heroes []*model.HeroWithDetails `json:"heroes"`
+	}
+	w := s.do(t, http.MethodPost, "/v1/heroes/summon", token, SummonHeroRequest{SummonType: "basic"}, &res)
+	if w.Code != http.StatusOK || len(res.Heroes) != 1 {
+		t.Fatalf("summon: %d %s", w.Code, w.Body.String())
+	}
+	return res.Heroes[0]
+}
+
+func TestSummonHeroChargesAndListsHero(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	hero := s.summonHero(t, session.Token)
+	if hero.Name == "" || hero.HP <= 0 || hero.ATK <= 0 {
+		t.Errorf("summoned hero %+v, want its type and stats", hero)
+	}
+	resources, err := s.store.Resources().Get(context.Background(), session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1000 - heroSummonOptions["basic"].GoldCost; resources.Gold != want {
+		t.Errorf("gold after summon = %d, want %d", resources.Gold, want)
+	}
+
+	var res struct {
+		Heroes []*model.HeroWithDetails `json:"heroes"`
+	}
+	if w := s.do(t, http.MethodGet, "/v1/heroes/list", session.Token, nil, &res); w.Code != http.StatusOK {
+		t.Fatalf("list heroes: %d %s", w.Code, w.Body.String())
+	}
+	if len(res.Heroes) != 1 || res.Heroes[0].ID != hero.ID || res.Heroes[0].HP != hero.HP {
+		t.Errorf("listed heroes %+v, want the summoned hero", res.Heroes)
+	}
+}
+
+func TestSummonHeroRejectsUnaffordableSummons(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	// 1000 gold pays for two basic summons
+	s.summonHero(t, session.Token)
+	s.summonHero(t, session.Token)
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/heroes/summon", session.Token, SummonHeroRequest{SummonType: "basic"}, &res)
+	if w.Code != http.StatusBadRequest || res.Error != "insufficient_resources" {
+		t.Errorf("unaffordable summon: %d %s, want 400 insufficient_resources", w.Code, w.Body.String())
+	}
+
+	heroes, err := s.store.Heroes().ListByUser(context.Background(), session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heroes) != 2 {
+		t.Errorf("player has %d heroes, want 2", len(heroes))
+	}
+}
+
+func TestSummonHeroRejectsUnknownSummonTypes(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/heroes/summon", session.Token, SummonHeroRequest{SummonType: "mythic"}, &res)
+	if w.Code != http.StatusBadRequest || res.Error != "invalid_request" {
+		t.Errorf("unknown summon type: %d %s, want 400 invalid_request", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/idempotency"
+	"github.com/yourusername/oden/internal/logging"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header that makes a request safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a retry
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength is the longest idempotency key accepted
+	maxIdempotencyKeyLength = 255
+	// idempotencySaveTimeout is how long storing a response may take. It is
+	// not bound to the request, so a client that hangs up does not keep its
+	// response from being stored for the retry.
+	idempotencySaveTimeout = 5 * time.Second
+)
+
+// idempotent returns middleware that makes requests which change state safe
+// to retry when they carry an Idempotency-Key header. The first request with
+// a key runs and its response is stored for the user; a retry with the same
+// key and the same method, path and body gets the stored response without
+// running again. A different request with the same key is rejected, and so
+// is a retry while the first request is still running. Responses with a
+// server error are not stored, so the request can be retried. Keys belong to
+// the logged in user, so it must run after authMiddleware.
+func idempotent(keys *idempotency.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			respondError(c, http.StatusBadRequest, "invalid_request", "Idempotency-Key must be 1 to 255 printable ASCII characters")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "invalid_request", "Error reading request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		userID := currentUserID(c)
+		hash := requestHash(c.Request, body)
+		reserved, earlier, err := keys.Begin(ctx, userID, key, hash)
+		if err != nil {
+			respondServerError(c, "Error checking idempotency key", err)
+			c.Abort()
+			return
+		}
+		if earlier != nil {
+			switch {
+			case earlier.RequestHash != hash:
+				respondError(c, http.StatusUnprocessableEntity, "idempotency_key_reused",
+					"Idempotency-Key was already used for a different request")
+			case !earlier.IsComplete():
+				setRetryAfter(c, time.Second)
+				respondError(c, http.StatusConflict, "idempotency_key_in_progress",
+					"A request with this Idempotency-Key is still being processed")
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(earlier.StatusCode, earlier.ContentType, earlier.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		answered := false
+		defer func() {
+			if answered {
+				return
+			}
+			// The request failed or panicked; free the key so it can be retried
+			saveCtx, cancel := context.WithTimeout(context.Background(), idempotencySaveTimeout)
+			defer cancel()
+			if err := keys.Release(saveCtx, reserved); err != nil {
+				logging.FromContext(ctx).WithError(err).Error("Error releasing idempotency key")
+			}
+		}()
+
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		// From here on the key stays reserved even if storing the response
+		// fails: the request may have changed state, so it must not run again
+		answered = true
+		saveCtx, cancel := context.WithTimeout(context.Background(), idempotencySaveTimeout)
+		defer cancel()
+		err = keys.Complete(saveCtx, reserved, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+		if err != nil {
+			logging.FromContext(c.Request.Context()).WithError(err).Error("Error storing idempotent response")
+		}
+	}
+}
+
+// validIdempotencyKey reports whether key is short enough and only holds
+// printable ASCII characters
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHash identifies a request by its method, path, query and body
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body it writes
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
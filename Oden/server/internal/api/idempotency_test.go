@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// guest logs in as the guest of a new device and returns its access token
+func (s *testServer) guest(t *testing.T) string {
+	t.Helper()
+	var res AuthResponse
+	if w := s.do(t, http.MethodPost, "/v1/auth/guest", "", GuestRequest{DeviceID: uuid.New().String()}, &res); w.Code != http.StatusOK {
+		t.Fatalf("guest: %d %s", w.Code, w.Body.String())
+	}
+	return res.Token
+}
+
+func TestIdempotentUpgradeIsReplayed(t *testing.T) {
+	s := newTestServer(t)
+	token := s.guest(t)
+	name := "player_" + uuid.New().String()[:8]
+	req := RegisterRequest{Username: name, Email: name + "@example.com", Password: "password123"}
+	key := uuid.New().String()
+
+	first := s.do(t, http.MethodPost, "/v1/auth/upgrade", token, req, nil, IdempotencyKeyHeader, key)
+	if first.Code != http.StatusOK {
+		t.Fatalf("upgrade: %d %s", first.Code, first.Body.String())
+	}
+	retry := s.do(t, http.MethodPost, "/v1/auth/upgrade", token, req, nil, IdempotencyKeyHeader, key)
+	if retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("retried upgrade: %d %s, want the first response replayed", retry.Code, retry.Body.String())
+	}
+
+	// Without the key the upgrade runs again and finds the account registered
+	var res AuthResponse
+	if w := s.do(t, http.MethodPost, "/v1/auth/upgrade", token, req, &res); w.Code != http.StatusConflict {
+		t.Errorf("upgrade without a key: %d %s, want 409", w.Code, res.Error)
+	}
+}
+
+func TestIdempotencyKeyReusedForAnotherRequest(t *testing.T) {
+	s := newTestServer(t)
+	token := s.guest(t)
+	key := uuid.New().String()
+
+	name := "player_" + uuid.New().String()[:8]
+	req := RegisterRequest{Username: name, Email: name + "@example.com", Password: "password123"}
+	if w := s.do(t, http.MethodPost, "/v1/auth/upgrade", token, req, nil, IdempotencyKeyHeader, key); w.Code != http.StatusOK {
+		t.Fatalf("upgrade: %d %s", w.Code, w.Body.String())
+	}
+
+	req.Username += "x"
+	var res AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/auth/upgrade", token, req, &res, IdempotencyKeyHeader, key)
+	if w.Code != http.StatusUnprocessableEntity || res.Error != "idempotency_key_reused" {
+		t.Errorf("other request with the key: %d %s, want 422 idempotency_key_reused", w.Code, res.Error)
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getIdleRewardsHandler returns the idle rewards the user could claim now
+func (h *handler) getIdleRewardsHandler(c *gin.Context) {
+	timeAway, rewards, err := h.idle.Pending(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		respondServerError(c, "Error calculating idle rewards", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"time_away": int(timeAway.Seconds()),
+		"rewards":   rewards,
+	})
+}
+
+// claimIdleRewardsHandler grants the accrued idle rewards and resets the timer
+func (h *handler) claimIdleRewardsHandler(c *gin.Context) {
+	claimedAt, rewards, err := h.idle.Claim(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		respondTxError(c, err, "Error claiming idle rewards")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"time_claimed": claimedAt.UTC(),
+		"rewards":      rewards,
+	})
+}
@@ -0,0 +1,428 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// errItemNotFound is returned for items that do not exist or belong to someone else
+var errItemNotFound = model.CustomError{Message: "Item not found", Code: "resource_not_found"}
+
+// errHeroNotFound is returned for heroes that do not exist or belong to someone else
+var errHeroNotFound = model.CustomError{Message: "Hero not found", Code: "resource_not_found"}
+
+// UseItemRequest represents the request to use a consumable item
+type UseItemRequest struct {
+	ItemID   string `json:"item_id" binding:"required"`
+	HeroID   string `json:"hero_id"` // Required for hero_exp items
+	Quantity int    `json:"quantity" binding:"omitempty,min=1"`
+}
+
+// EquipItemRequest represents the request to equip an item to a hero
+type EquipItemRequest struct {
+	ItemID string `json:"item_id" binding:"required"`
+	HeroID string `json:"hero_id" binding:"required"`
+}
+
+// UnequipItemRequest represents the request to unequip an item
+type UnequipItemRequest struct {
+	ItemID string `json:"item_id" binding:"required"`
+}
+
+// listItemsHandler returns the user's inventory
+func (h *handler) listItemsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	items, err := h.store.Items().ListByUser(ctx, userID)
+	if err != nil {
+		respondServerError(c, "Error loading items", err)
+		return
+	}
+
+	templates, err := itemTemplateIndex(ctx, h.store)
+	if err != nil {
+		respondServerError(c, "Error loading item templates", err)
+		return
+	}
+
+	list := make([]*model.ItemWithTemplate, 0, len(items))
+	for _, item := range items {
+		item.Template = templates[item.ItemTemplateID]
+		list = append(list, item.ToItemWithTemplate())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": list,
+	})
+}
+
+// useItemHandler consumes items and applies their effect
+func (h *handler) useItemHandler(c *gin.Context) {
+	var req UseItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	var item *model.Item
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		resources, err := lockPlayer(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		item, err = ownedItem(ctx, tx, userID, req.ItemID)
+		if err != nil {
+			return err
+		}
+		if !item.IsConsumable() {
+			return model.CustomError{Message: "Item cannot be used", Code: "invalid_item_type"}
+		}
+		if item.Quantity < req.Quantity {
+			return model.CustomError{Message: "Not enough items", Code: "insufficient_resources"}
+		}
+
+		amount := item.Template.EffectValue * req.Quantity
+		switch item.Template.Effect {
+		case model.ItemEffectGold, model.ItemEffectGems:
+			if item.Template.Effect == model.ItemEffectGold {
+				resources.Gold += amount
+			} else {
+				resources.PremiumCurrency += amount
+			}
+			if err := tx.Resources().Update(ctx, resources); err != nil {
+				return err
+			}
+		case model.ItemEffectHeroExp:
+			hero, err := ownedHero(ctx, tx, userID, req.HeroID)
+			if err != nil {
+				return err
+			}
+			level := hero.Level
+			hero.AddExperience(amount)
+			if err := tx.Heroes().Update(ctx, hero); err != nil {
+				return err
+			}
+			if err := h.events.Publish(ctx, tx, events.LevelUp(hero, level)...); err != nil {
+				return err
+			}
+		default:
+			return model.CustomError{Message: "Item has no usable effect", Code: "invalid_item_type"}
+		}
+
+		item.Quantity -= req.Quantity
+		if item.Quantity == 0 {
+			return tx.Items().Delete(ctx, item.ID)
+		}
+		return tx.Items().Update(ctx, item)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error using item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"item_id":      item.ID,
+		"effect":       item.Template.Effect,
+		"effect_value": item.Template.EffectValue * req.Quantity,
+		"remaining":    item.Quantity,
+	})
+}
+
+// equipItemHandler equips an item to one of the user's heroes. A hero holds
+// one item per slot: the item already in the slot goes back to the inventory.
+// Equipping an item worn by another hero moves it, and equipping from a stack
+// takes a single piece off it.
+func (h *handler) equipItemHandler(c *gin.Context) {
+	var req EquipItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	var item, unequipped *model.Item
+	var hero *model.Hero
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := lockPlayer(ctx, tx, userID); err != nil {
+			return err
+		}
+		var err error
+		item, err = ownedItem(ctx, tx, userID, req.ItemID)
+		if err != nil {
+			return err
+		}
+		hero, err = ownedHero(ctx, tx, userID, req.HeroID)
+		if err != nil {
+			return err
+		}
+		if !item.IsEquipment() {
+			return model.ErrNotEquipment
+		}
+		if item.Template.Slot == "" {
+			return model.ErrNoEquipmentSlot
+		}
+
+		if item.EquippedToHeroID != hero.ID {
+			// A locking read, so an item equipped by a concurrent equip that
+			// held the player first is seen and moved out of the slot
+			if hero.Equipment, err = lockEquipment(ctx, tx, hero.ID); err != nil {
+				return err
+			}
+
+			if current := hero.EquippedInSlot(item.Template.Slot); current != nil {
+				current.UnequipFromHero()
+				if err := tx.Items().Update(ctx, current); err != nil {
+					return err
+				}
+				unequipped = current
+			}
+
+			if item.EquippedToHeroID == "" && item.Quantity > 1 {
+				item.Quantity--
+				if err := tx.Items().Update(ctx, item); err != nil {
+					return err
+				}
+				piece := model.NewItem(model.NewID("item"), userID, item.ItemTemplateID, 1)
+				piece.Template = item.Template
+				if err := piece.EquipToHero(hero.ID); err != nil {
+					return err
+				}
+				if err := tx.Items().Create(ctx, piece); err != nil {
+					return err
+				}
+				item = piece
+			} else {
+				if err := item.EquipToHero(hero.ID); err != nil {
+					return err
+				}
+				if err := tx.Items().Update(ctx, item); err != nil {
+					return err
+				}
+			}
+
+			err = h.events.Publish(ctx, tx, events.ItemEquipped{
+				UserID:         userID,
+				ItemTemplateID: item.ItemTemplateID,
+				HeroID:         hero.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return loadHeroDetails(ctx, tx, userID, hero)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error equipping item")
+		return
+	}
+
+	res := gin.H{
+		"success": true,
+		"item":    item.ToItemWithTemplate(),
+		"hero":    hero.ToHeroWithDetails(),
+	}
+	if unequipped != nil {
+		res["unequipped"] = unequipped.ToItemWithTemplate()
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// unequipItemHandler removes an item from the hero it is equipped to
+func (h *handler) unequipItemHandler(c *gin.Context) {
+	var req UnequipItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	var item *model.Item
+	var hero *model.Hero
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := lockPlayer(ctx, tx, userID); err != nil {
+			return err
+		}
+		var err error
+		item, err = ownedItem(ctx, tx, userID, req.ItemID)
+		if err != nil {
+			return err
+		}
+		if item.EquippedToHeroID == "" {
+			return nil
+		}
+
+		hero, err = ownedHero(ctx, tx, userID, item.EquippedToHeroID)
+		if err != nil {
+			return err
+		}
+		item.UnequipFromHero()
+		if err := tx.Items().Update(ctx, item); err != nil {
+			return err
+		}
+		return loadHeroDetails(ctx, tx, userID, hero)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error unequipping item")
+		return
+	}
+
+	res := gin.H{
+		"success": true,
+		"item":    item.ToItemWithTemplate(),
+	}
+	if hero != nil {
+		res["hero"] = hero.ToHeroWithDetails()
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// itemTemplateIndex loads every item template keyed by ID
+func itemTemplateIndex(ctx context.Context, st store.Store) (map[string]*model.ItemTemplate, error) {
+	templates, err := st.ItemTemplates().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*model.ItemTemplate, len(templates))
+	for _, it := range templates {
+		index[it.ID] = it
+	}
+	return index, nil
+}
+
+// equipmentIndex loads the user's equipped items with their templates, keyed
+// by the ID of the hero they are equipped to
+func equipmentIndex(ctx context.Context, st store.Store, userID string) (map[string][]*model.Item, error) {
+	items, err := st.Items().ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates map[string]*model.ItemTemplate
+	index := make(map[string][]*model.Item)
+	for _, item := range items {
+		if item.EquippedToHeroID == "" {
+			continue
+		}
+		if templates == nil {
+			if templates, err = itemTemplateIndex(ctx, st); err != nil {
+				return nil, err
+			}
+		}
+		item.Template = templates[item.ItemTemplateID]
+		index[item.EquippedToHeroID] = append(index[item.EquippedToHeroID], item)
+	}
+	return index, nil
+}
+
+// lockEquipment locks and returns the items equipped to the hero with their
+// templates
+func lockEquipment(ctx context.Context, tx store.Store, heroID string) ([]*model.Item, error) {
+	items, err := tx.Items().ListEquipped(ctx, heroID)
+	if err != nil || len(items) == 0 {
+		return items, err
+	}
+
+	templates, err := itemTemplateIndex(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item.Template = templates[item.ItemTemplateID]
+	}
+	return items, nil
+}
+
+// loadHeroDetails attaches the hero's type, skills and current equipment and
+// recalculates its stats
+func loadHeroDetails(ctx context.Context, st store.Store, userID string, hero *model.Hero) error {
+	ht, err := st.HeroTypes().Get(ctx, hero.HeroTypeID)
+	if err != nil {
+		return err
+	}
+	equipment, err := equipmentIndex(ctx, st, userID)
+	if err != nil {
+		return err
+	}
+	withHeroType(hero, map[string]*model.HeroType{ht.ID: ht}, equipment)
+	return nil
+}
+
+// grantItem adds items to the user's inventory, merged into an unequipped
+// stack of the same template if the user has one, and returns the stack.
+// st must be a transaction holding lockPlayer, so concurrent grants run one
+// after the other and the second finds the stack the first created instead of
+// creating another.
+func grantItem(ctx context.Context, st store.Store, userID, templateID string, quantity int) (*model.Item, error) {
+	stacks, err := st.Items().ListStacks(ctx, userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stacks) > 0 {
+		stack := stacks[0]
+		stack.Quantity += quantity
+		if err := st.Items().Update(ctx, stack); err != nil {
+			return nil, err
+		}
+		return stack, nil
+	}
+
+	item := model.NewItem(model.NewID("item"), userID, templateID, quantity)
+	if err := st.Items().Create(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ownedItem loads an item with its template and checks that the user owns it
+func ownedItem(ctx context.Context, st store.Store, userID, itemID string) (*model.Item, error) {
+	item, err := st.Items().Get(ctx, itemID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && item.UserID != userID) {
+		return nil, errItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	item.Template, err = st.ItemTemplates().Get(ctx, item.ItemTemplateID)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ownedHero loads a hero and checks that the user owns it
+func ownedHero(ctx context.Context, st store.Store, userID, heroID string) (*model.Hero, error) {
+	if heroID == "" {
+		return nil, model.CustomError{Message: "hero_id is required", Code: "invalid_request"}
+	}
+
+	hero, err := st.Heroes().Get(ctx, heroID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && hero.UserID != userID) {
+		return nil, errHeroNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hero, nil
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// ironSword is a sample weapon with 10 ATK
+const ironSword = "item_template_001"
+
+// giveItem adds an item to the player's inventory
+func (s *testServer) giveItem(t *testing.T, userID, templateID string, quantity int) *model.Item {
+	t.Helper()
+	item := model.NewItem(model.NewID("item"), userID, templateID, quantity)
+	if err := s.store.Items().Create(context.Background(), item); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+// equipResponse is the response to /items/equip
+type equipResponse struct {
+	Item       *model.ItemWithTemplate `json:"item"`
+	Unequipped *model.ItemWithTemplate `json:"unequipped"`
+	Hero       *model.HeroWithDetails  `json:"hero"`
+}
+
+// equipped returns the items equipped to the hero
+func (s *testServer) equipped(t *testing.T, heroID string) []*model.Item {
+	t.Helper()
+	items, err := s.store.Items().ListEquipped(context.Background(), heroID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+func TestEquipItemSwapsSlot(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+	hero := s.summonHero(t, session.Token)
+	first := s.giveItem(t, session.UserID, ironSword, 1)
+	second := s.giveItem(t, session.UserID, ironSword, 1)
+
+	var res equipResponse
+	w := s.do(t, http.MethodPost, "/v1/items/equip", session.Token, EquipItemRequest{ItemID: first.ID, HeroID: hero.ID}, &res)
+	if w.Code != http.StatusOK {
+		t.Fatalf("equip: %d %s", w.Code, w.Body.String())
+	}
+	if res.Hero.ATK != hero.ATK+10 {
+		t.Errorf("hero ATK with the sword = %d, want %d", res.Hero.ATK, hero.ATK+10)
+	}
+
+	res = equipResponse{}
+	w = s.do(t, http.MethodPost, "/v1/items/equip", session.Token, EquipItemRequest{ItemID: second.ID, HeroID: hero.ID}, &res)
+	if w.Code != http.StatusOK {
+		t.Fatalf("equip into a full slot: %d %s", w.Code, w.Body.String())
+	}
+	if res.Unequipped == nil || res.Unequipped.ID != first.ID {
+		t.Errorf("unequipped %+v, want the first sword", res.Unequipped)
+	}
+	if items := s.equipped(t, hero.ID); len(items) != 1 || items[0].ID != second.ID {
+		t.Errorf("hero has %d items equipped, want only the second sword", len(items))
+	}
+}
+
+func TestEquipItemFromStack(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	_, session := s.register(t)
+	hero := s.summonHero(t, session.Token)
+	stack := s.giveItem(t, session.UserID, ironSword, 3)
+
+	var res equipResponse
+	w := s.do(t, http.MethodPost, "/v1/items/equip", session.Token, EquipItemRequest{ItemID: stack.ID, HeroID: hero.ID}, &res)
+	if w.Code != http.StatusOK {
+		t.Fatalf("equip: %d %s", w.Code, w.Body.String())
+	}
+	if res.Item.ID == stack.ID || res.Item.Quantity != 1 {
+		t.Errorf("equipped %+v, want a single piece taken off the stack", res.Item.Item)
+	}
+	left, err := s.store.Items().Get(ctx, stack.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left.Quantity != 2 || left.EquippedToHeroID != "" {
+		t.Errorf("stack after equipping has %d items equipped to %q, want 2 unequipped", left.Quantity, left.EquippedToHeroID)
+	}
+}
+
+func TestConcurrentEquipsFillSlotOnce(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+	hero := s.summonHero(t, session.Token)
+
+	const equips = 8
+	var wg sync.WaitGroup
+	codes := make([]int, equips)
+	for i := 0; i < equips; i++ {
+		sword := s.giveItem(t, session.UserID, ironSword, 1)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = s.do(t, http.MethodPost, "/v1/items/equip", session.Token, EquipItemRequest{ItemID: sword.ID, HeroID: hero.ID}, nil).Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("equip %d: %d, want 200", i, code)
+		}
+	}
+	if items := s.equipped(t, hero.ID); len(items) != 1 {
+		t.Errorf("hero has %d swords equipped, want 1", len(items))
+	}
+}
+
+func TestConcurrentGrantsShareStack(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	_, session := s.register(t)
+
+	const grants = 8
+	var wg sync.WaitGroup
+	errs := make([]error, grants)
+	for i := 0; i < grants; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.store.WithTx(ctx, func(tx store.Store) error {
+				if _, err := lockPlayer(ctx, tx, session.UserID); err != nil {
+					return err
+				}
+				_, err := grantItem(ctx, tx, session.UserID, ironSword, 2)
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("grantItem: %v", err)
+		}
+	}
+	stacks, err := s.store.Items().ListStacks(ctx, session.UserID, ironSword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stacks) != 1 || stacks[0].Quantity != 2*grants {
+		t.Errorf("grants left %d stacks, want one of %d", len(stacks), 2*grants)
+	}
+}
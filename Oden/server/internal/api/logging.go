@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/logging"
+)
+
+// RequestIDHeader carries the ID of a request. A valid ID sent by the client
+// or a proxy is kept, so one ID follows a request across services; otherwise
+// a new one is made. It is always returned in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID matches the request IDs accepted from clients
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// probeRoutes are logged at debug level when they succeed, so load balancer
+// health checks and metrics scrapes do not flood the log
+var probeRoutes = map[string]bool{"/livez": true, "/readyz": true, "/health": true, "/metrics": true}
+
+// RequestLogger returns middleware that gives every request an ID and a
+// logger carrying it, which handlers and services get from the request
+// context with logging.FromContext. authMiddleware adds the user ID to the
+// logger. A line is logged when the request completes.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := logging.WithFields(c.Request.Context(), logrus.Fields{
+			"request_id": requestID,
+			"method":     c.Request.Method,
+			"route":      route,
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		entry := logging.FromContext(c.Request.Context()).WithFields(logrus.Fields{
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"client_ip":  c.ClientIP(),
+			"bytes":      c.Writer.Size(),
+		})
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request failed")
+		case probeRoutes[route] && status < http.StatusBadRequest:
+			entry.Debug("Request completed")
+		default:
+			entry.Info("Request completed")
+		}
+	}
+}
+
+// Recovery returns middleware that turns a panic in a handler into a
+// server_error response and logs it with the request's logger
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered interface{}) {
+		logging.FromContext(c.Request.Context()).
+			WithField("stack", string(debug.Stack())).
+			Errorf("Panic while handling request: %v", recovered)
+		respondError(c, http.StatusInternalServerError, "server_error", "Internal server error")
+		c.Abort()
+	})
+}
+
+// withLogField adds a field to the logger of the request
+func withLogField(c *gin.Context, key string, value interface{}) {
+	c.Request = c.Request.WithContext(logging.WithFields(c.Request.Context(), logrus.Fields{key: value}))
+}
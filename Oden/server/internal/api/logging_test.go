@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// logRouter returns a router with the logging middleware, and a hook that
+// records what the standard logger logs at debug level and above
+func logRouter(t *testing.T) (*gin.Engine, *logtest.Hook) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := logrus.StandardLogger()
+	level, hooks := logger.GetLevel(), logger.Hooks
+	logger.SetLevel(logrus.DebugLevel)
+	hook := logtest.NewLocal(logger)
+	t.Cleanup(func() {
+		logger.SetLevel(level)
+		logger.ReplaceHooks(hooks)
+	})
+
+	router := gin.New()
+	router.Use(RequestLogger(), Recovery())
+	router.GET("/ok", func(c *gin.Context) {
+		withLogField(c, "user_id", "user")
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/livez", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	return router, hook
+}
+
+// getWithID sends a GET request for path with the given request ID, if any
+func getWithID(router *gin.Engine, path, requestID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestLoggerRequestIDs(t *testing.T) {
+	router, hook := logRouter(t)
+
+	w := getWithID(router, "/ok", "trace-123")
+	if got := w.Header().Get(RequestIDHeader); got != "trace-123" {
+		t.Errorf("request ID = %q, want the client's", got)
+	}
+	entry := hook.LastEntry()
+	if entry == nil || entry.Message != "Request completed" || entry.Level != logrus.InfoLevel {
+		t.Fatalf("last log entry = %+v, want the completed request", entry)
+	}
+	for key, want := range map[string]interface{}{"request_id": "trace-123", "user_id": "user", "route": "/ok", "status": http.StatusOK} {
+		if entry.Data[key] != want {
+			t.Errorf("logged %s = %v, want %v", key, entry.Data[key], want)
+		}
+	}
+
+	for _, sent := range []string{"", "not valid\n", string(make([]byte, 200))} {
+		w := getWithID(router, "/ok", sent)
+		got := w.Header().Get(RequestIDHeader)
+		if got == "" || got == sent || !validRequestID.MatchString(got) {
+			t.Errorf("request ID for %q = %q, want a new one", sent, got)
+		}
+		if logged := hook.LastEntry().Data["request_id"]; logged != got {
+			t.Errorf("logged request ID %v, want %q", logged, got)
+		}
+	}
+}
+
+func TestRequestLoggerLevels(t *testing.T) {
+	router, hook := logRouter(t)
+
+	getWithID(router, "/livez", "")
+	if entry := hook.LastEntry(); entry.Level != logrus.DebugLevel {
+		t.Errorf("probe logged at %v, want debug", entry.Level)
+	}
+	getWithID(router, "/missing", "")
+	if entry := hook.LastEntry(); entry.Level != logrus.InfoLevel || entry.Data["route"] != "unmatched" {
+		t.Errorf("unmatched request logged at %v with route %v, want info and unmatched", entry.Level, entry.Data["route"])
+	}
+}
+
+func TestRecoveryLogsPanics(t *testing.T) {
+	router, hook := logRouter(t)
+
+	w := getWithID(router, "/panic", "trace-456")
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("panicking handler = %d, want 500", w.Code)
+	}
+	var panicked bool
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.ErrorLevel && entry.Data["request_id"] == "trace-456" && entry.Data["stack"] != nil {
+			panicked = true
+		}
+	}
+	if !panicked {
+		t.Error("panic not logged with the request ID and stack")
+	}
+	if entry := hook.LastEntry(); entry.Message != "Request failed" || entry.Level != logrus.ErrorLevel {
+		t.Errorf("last log entry = %q at %v, want the failed request", entry.Message, entry.Level)
+	}
+}
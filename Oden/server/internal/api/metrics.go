@@ -0,0 +1,42 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/metrics"
+)
+
+// RequestMetrics returns middleware that records the status and duration of
+// every request by its route template
+func RequestMetrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// RegisterMetrics registers /metrics, which serves the metrics to
+// Prometheus. If bearerToken is not empty, scrapers must send it.
+func RegisterMetrics(router *gin.Engine, m *metrics.Metrics, bearerToken string) {
+	handler := gin.WrapH(m.Handler())
+	router.GET("/metrics", func(c *gin.Context) {
+		if bearerToken != "" {
+			token := c.GetHeader("Authorization")
+			if subtle.ConstantTimeCompare([]byte(token), []byte("Bearer "+bearerToken)) != 1 {
+				respondError(c, http.StatusUnauthorized, "invalid_token", "A valid metrics token is required")
+				return
+			}
+		}
+		c.Header("Cache-Control", "no-store")
+		handler(c)
+	})
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// Errors returned when claiming mission rewards
+var (
+	// errMissionNotFound is returned for missions that do not exist or belong to someone else
+	errMissionNotFound       = model.CustomError{Message: "Mission not found", Code: "resource_not_found"}
+	errMissionExpired        = model.CustomError{Message: "Mission has expired", Code: "mission_expired"}
+	errMissionNotCompleted   = model.CustomError{Message: "Mission is not completed", Code: "mission_not_claimable"}
+	errMissionAlreadyClaimed = model.CustomError{Message: "Mission rewards have already been claimed", Code: "mission_already_claimed"}
+)
+
+// ClaimMissionRequest represents the request to claim a mission's rewards.
+// The experience reward goes to HeroID, or to the account if it is empty.
+type ClaimMissionRequest struct {
+	MissionID string `json:"mission_id" binding:"required"`
+	HeroID    string `json:"hero_id"`
+}
+
+// listMissionsHandler returns the user's active missions with their progress
+func (h *handler) listMissionsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	missions, err := h.missions.Assign(ctx, userID)
+	if err != nil {
+		respondServerError(c, "Error loading missions", err)
+		return
+	}
+
+	itemTemplates, err := itemTemplateIndex(ctx, h.store)
+	if err != nil {
+		respondServerError(c, "Error loading item templates", err)
+		return
+	}
+
+	list := make([]*model.MissionProgress, 0, len(missions))
+	for _, m := range missions {
+		if m.IsExpired() || m.Status == model.MissionStatusClaimed {
+			continue
+		}
+		progress := m.ToMissionProgress()
+		if m.Template != nil {
+			progress.Rewards.Items = namedItemRewards(m.Template.ItemRewards, itemTemplates)
+		}
+		list = append(list, progress)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"missions": list,
+	})
+}
+
+// claimMissionRewardHandler grants every reward of a completed mission in one
+// transaction. The player and then the mission are locked first, so of two
+// concurrent claims the second finds the mission already claimed.
+func (h *handler) claimMissionRewardHandler(c *gin.Context) {
+	var req ClaimMissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	var rewards model.MissionRewards
+	var resources *model.PlayerResources
+	var hero *model.Hero
+	items := []*model.ItemWithTemplate{}
+	leveledUp := false
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		resources, err = lockPlayer(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		mission, err := tx.Missions().Get(ctx, req.MissionID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && mission.UserID != userID) {
+			return errMissionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		mission.Template, err = tx.Missions().GetTemplate(ctx, mission.MissionTemplateID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case mission.Status == model.MissionStatusClaimed:
+			return errMissionAlreadyClaimed
+		case mission.IsExpired():
+			return errMissionExpired
+		case !mission.ClaimRewards():
+			return errMissionNotCompleted
+		}
+		if err := tx.Missions().Update(ctx, mission); err != nil {
+			return err
+		}
+
+		templates, err := itemTemplateIndex(ctx, tx)
+		if err != nil {
+			return err
+		}
+		rewards = model.MissionRewards{
+			Gold:       mission.Template.GoldReward,
+			Gems:       mission.Template.GemsReward,
+			Experience: mission.Template.ExperienceReward,
+			Items:      namedItemRewards(mission.Template.ItemRewards, templates),
+		}
+
+		var published []events.Event
+		if req.HeroID != "" {
+			hero, err = ownedHero(ctx, tx, userID, req.HeroID)
+			if err != nil {
+				return err
+			}
+			level := hero.Level
+			leveledUp = hero.AddExperience(rewards.Experience)
+			if err := tx.Heroes().Update(ctx, hero); err != nil {
+				return err
+			}
+			if err := loadHeroDetails(ctx, tx, userID, hero); err != nil {
+				return err
+			}
+			published = append(published, events.LevelUp(hero, level)...)
+		}
+
+		resources.Gold += rewards.Gold
+		resources.PremiumCurrency += rewards.Gems
+		if hero == nil {
+			leveledUp = resources.AddAccountExperience(rewards.Experience)
+		}
+		if err := tx.Resources().Update(ctx, resources); err != nil {
+			return err
+		}
+
+		for _, reward := range rewards.Items {
+			item, err := grantItem(ctx, tx, userID, reward.ItemID, reward.Quantity)
+			if err != nil {
+				return err
+			}
+			item.Template = templates[item.ItemTemplateID]
+			items = append(items, item.ToItemWithTemplate())
+			published = append(published, events.ItemsCollected{
+				UserID:         userID,
+				ItemTemplateID: reward.ItemID,
+				Quantity:       reward.Quantity,
+			})
+		}
+
+		return h.events.Publish(ctx, tx, published...)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error claiming mission rewards")
+		return
+	}
+
+	res := gin.H{
+		"success":    true,
+		"mission_id": req.MissionID,
+		"rewards":    rewards,
+		"leveled_up": leveledUp,
+		"resources":  resources,
+		"items":      items,
+	}
+	if hero != nil {
+		res["hero"] = hero.ToHeroWithDetails()
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// namedItemRewards copies item rewards with the names of their item templates
+func namedItemRewards(rewards []model.ItemReward, templates map[string]*model.ItemTemplate) []model.ItemReward {
+	var named []model.ItemReward
+	for _, reward := range rewards {
+		if it := templates[reward.ItemID]; it != nil {
+			reward.Name = it.Name
+		}
+		named = append(named, reward)
+	}
+	return named
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// completedMission gives the player a completed mission from the weekly
+// sample template, which rewards 300 gold, 30 gems, 100 XP, a Steel Armor and
+// 5 Iron Ore
+func (s *testServer) completedMission(t *testing.T, userID string) *model.Mission {
+	t.Helper()
+	expiresAt := time.Now().Add(time.Hour)
+	m := model.NewMission(model.NewID("mission"), userID, "mission_template_003", nil, &expiresAt)
+	m.Status = model.MissionStatusCompleted
+	m.CurrentValue = 5
+	if err := s.store.Missions().Create(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestClaimMissionGrantsRewards(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	_, session := s.register(t)
+	hero := s.summonHero(t, session.Token)
+	mission := s.completedMission(t, session.UserID)
+	before, err := s.store.Resources().Get(ctx, session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res struct {
+		LeveledUp bool                      `json:"leveled_up"`
+		Items     []*model.ItemWithTemplate `json:"items"`
+		Hero      *model.HeroWithDetails    `json:"hero"`
+	}
+	req := ClaimMissionRequest{MissionID: mission.ID, HeroID: hero.ID}
+	if w := s.do(t, http.MethodPost, "/v1/missions/claim", session.Token, req, &res); w.Code != http.StatusOK {
+		t.Fatalf("claim: %d %s", w.Code, w.Body.String())
+	}
+	if !res.LeveledUp || res.Hero == nil || res.Hero.Level != 2 {
+		t.Errorf("claim leveled up %v to hero %+v, want the hero at level 2", res.LeveledUp, res.Hero)
+	}
+	if len(res.Items) != 2 || res.Items[0].Name != "Steel Armor" || res.Items[1].Quantity != 5 {
+		t.Errorf("claim granted items %+v, want a Steel Armor and 5 Iron Ore", res.Items)
+	}
+
+	after, err := s.store.Resources().Get(ctx, session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Gold-before.Gold != 300 || after.PremiumCurrency-before.PremiumCurrency != 30 {
+		t.Errorf("claim granted %d gold and %d gems, want 300 and 30",
+			after.Gold-before.Gold, after.PremiumCurrency-before.PremiumCurrency)
+	}
+	if after.AccountExperience != before.AccountExperience {
+		t.Errorf("account XP went from %d to %d, want the hero to get it", before.AccountExperience, after.AccountExperience)
+	}
+
+	var again AuthResponse
+	w := s.do(t, http.MethodPost, "/v1/missions/claim", session.Token, req, &again)
+	if w.Code != http.StatusBadRequest || again.Error != "mission_already_claimed" {
+		t.Errorf("second claim: %d %s, want 400 mission_already_claimed", w.Code, w.Body.String())
+	}
+}
+
+func TestClaimMissionRejectsUnclaimableMissions(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	_, session := s.register(t)
+	_, other := s.register(t)
+
+	inProgress := s.completedMission(t, session.UserID)
+	inProgress.Status = model.MissionStatusInProgress
+	if err := s.store.Missions().Update(ctx, inProgress); err != nil {
+		t.Fatal(err)
+	}
+	expired := s.completedMission(t, session.UserID)
+	ended := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &ended
+	if err := s.store.Missions().Update(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		missionID string
+		status    int
+		code      string
+	}{
+		{"in progress", inProgress.ID, http.StatusBadRequest, "mission_not_claimable"},
+		{"expired", expired.ID, http.StatusBadRequest, "mission_expired"},
+		{"another player's", s.completedMission(t, other.UserID).ID, http.StatusNotFound, "resource_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res AuthResponse
+			w := s.do(t, http.MethodPost, "/v1/missions/claim", session.Token, ClaimMissionRequest{MissionID: tt.missionID}, &res)
+			if w.Code != tt.status || res.Error != tt.code {
+				t.Errorf("claim: %d %s, want %d %s", w.Code, w.Body.String(), tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestConcurrentMissionClaimsPayOnce(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	_, session := s.register(t)
+	mission := s.completedMission(t, session.UserID)
+	before, err := s.store.Resources().Get(ctx, session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Summons run alongside the claims, locking the same player
+	const claims = 8
+	var wg sync.WaitGroup
+	codes := make([]int, claims)
+	for i := 0; i < claims; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = s.do(t, http.MethodPost, "/v1/missions/claim", session.Token, ClaimMissionRequest{MissionID: mission.ID}, nil).Code
+		}(i)
+		go func() {
+			defer wg.Done()
+			s.do(t, http.MethodPost, "/v1/gacha/summon", session.Token, GachaSummonRequest{BannerID: "banner_001", Count: 1, Free: true}, nil)
+		}()
+	}
+	wg.Wait()
+
+	paid := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			paid++
+		}
+	}
+	after, err := s.store.Resources().Get(ctx, session.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid != 1 || after.Gold-before.Gold != 300 {
+		t.Errorf("%d claims succeeded granting %d gold, want 1 granting 300", paid, after.Gold-before.Gold)
+	}
+}
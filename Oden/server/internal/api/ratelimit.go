@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/ratelimit"
+)
+
+// rateLimit returns middleware that counts requests by the key key returns
+// and rejects those over the limit with 429 and a Retry-After header. If the
+// limiter's store fails, requests are let through, so an outage of the shared
+// store does not take the game down with it.
+func rateLimit(limiter *ratelimit.Limiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, retryAfter, err := limiter.Allow(c.Request.Context(), key(c))
+		if err != nil {
+			logging.FromContext(c.Request.Context()).WithError(err).Warn("Rate limit check failed, allowing request")
+			c.Next()
+			return
+		}
+		if !ok {
+			seconds := setRetryAfter(c, retryAfter)
+			respondError(c, http.StatusTooManyRequests, "rate_limited",
+				fmt.Sprintf("Too many requests, try again in %d seconds", seconds))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// setRetryAfter sets the Retry-After header to d, rounded up to whole
+// seconds, and returns the seconds
+func setRetryAfter(c *gin.Context, d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	return seconds
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/ratelimit"
+)
+
+// failingLimits is a rate limit store that is down
+type failingLimits struct{}
+
+func (failingLimits) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("connection refused")
+}
+
+func (failingLimits) Close() error { return nil }
+
+// limitedRouter returns a router allowing two requests a minute per client
+// IP, counted in limits
+func limitedRouter(limits ratelimit.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := ratelimit.NewLimiter(limits, "test", config.RateLimit{Requests: 2, Window: 60})
+	router.GET("/", rateLimit(limiter, (*gin.Context).ClientIP), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+// getFrom sends a GET request from the client IP
+func getFrom(router *gin.Engine, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRejectsRequestsOverTheLimit(t *testing.T) {
+	limits := ratelimit.NewMemoryStore()
+	defer limits.Close()
+	router := limitedRouter(limits)
+
+	for i := 0; i < 2; i++ {
+		if w := getFrom(router, "192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, w.Code)
+		}
+	}
+	w := getFrom(router, "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit = %d, want 429", w.Code)
+	}
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "60" && retryAfter != "59" {
+		t.Errorf("Retry-After = %q, want the rest of the window", retryAfter)
+	}
+
+	// Other clients are counted apart
+	if w := getFrom(router, "192.0.2.2"); w.Code != http.StatusOK {
+		t.Errorf("request from another client = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitAllowsRequestsWhenStoreFails(t *testing.T) {
+	router := limitedRouter(failingLimits{})
+	for i := 0; i < 3; i++ {
+		if w := getFrom(router, "192.0.2.1"); w.Code != http.StatusOK {
+			t.Errorf("request %d with the store down = %d, want 200", i+1, w.Code)
+		}
+	}
+}
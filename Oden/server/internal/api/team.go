@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// maxTeamSize is the number of positions in a team
+const maxTeamSize = 5
+
+// SaveTeamRequest represents the request to save a team formation.
+// Empty positions may be omitted or set to null.
+type SaveTeamRequest struct {
+	Positions map[string]*string `json:"positions" binding:"required"`
+}
+
+// getTeamHandler returns the user's team formation
+func (h *handler) getTeamHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	team, err := loadTeam(ctx, h.store, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		// No team saved yet: every position is empty
+		team = &model.Team{Heroes: map[int]*model.Hero{}}
+	} else if err != nil {
+		respondServerError(c, "Error loading team", err)
+		return
+	}
+
+	res := team.ToTeamResponse()
+	for pos := 1; pos <= maxTeamSize; pos++ {
+		key := strconv.Itoa(pos)
+		if _, ok := res.Positions[key]; !ok {
+			res.Positions[key] = nil
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// saveTeamHandler saves the user's team formation
+func (h *handler) saveTeamHandler(c *gin.Context) {
+	var req SaveTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid_request", "Invalid request: "+err.Error())
+		return
+	}
+
+	positions := make(map[string]string, len(req.Positions))
+	seen := make(map[string]bool)
+	for key, heroID := range req.Positions {
+		pos, err := strconv.Atoi(key)
+		if err != nil || pos < 1 || pos > maxTeamSize {
+			respondError(c, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid team position %q", key))
+			return
+		}
+		if heroID == nil || *heroID == "" {
+			continue
+		}
+		if seen[*heroID] {
+			respondError(c, http.StatusBadRequest, "invalid_request", "A hero can only be placed in one position")
+			return
+		}
+		seen[*heroID] = true
+		positions[key] = *heroID
+	}
+	if len(positions) == 0 {
+		respondError(c, http.StatusBadRequest, "invalid_request", "A team needs at least one hero")
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	var team *model.Team
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := lockPlayer(ctx, tx, userID); err != nil {
+			return err
+		}
+		for heroID := range seen {
+			hero, err := tx.Heroes().Get(ctx, heroID)
+			if errors.Is(err, store.ErrNotFound) || (err == nil && hero.UserID != userID) {
+				return model.CustomError{Message: "Team contains a hero you do not own", Code: "invalid_request"}
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		team, err = tx.Teams().GetByUser(ctx, userID)
+		if errors.Is(err, store.ErrNotFound) {
+			team = model.NewTeam(model.NewID("team"), userID)
+		} else if err != nil {
+			return err
+		}
+
+		team.SetAllPositions(positions)
+		return tx.Teams().Save(ctx, team)
+	})
+	if err != nil {
+		respondTxError(c, err, "Error saving team")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"team_id": team.ID,
+	})
+}
+
+// loadTeam loads the user's team with its heroes, their types, equipment and stats.
+// It returns store.ErrNotFound if the user has not saved a team yet.
+func loadTeam(ctx context.Context, st store.Store, userID string) (*model.Team, error) {
+	team, err := st.Teams().GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := heroTypeIndex(ctx, st)
+	if err != nil {
+		return nil, err
+	}
+
+	equipment, err := equipmentIndex(ctx, st, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	team.Heroes = make(map[int]*model.Hero)
+	for pos, heroID := range team.GetAllPositions() {
+		hero, err := st.Heroes().Get(ctx, heroID)
+		if errors.Is(err, store.ErrNotFound) {
+			// The hero was removed; the position is treated as empty
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		team.Heroes[pos] = withHeroType(hero, types, equipment)
+	}
+	return team, nil
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// saveTeam saves a team with the hero in the first position
+func (s *testServer) saveTeam(t *testing.T, token, heroID string) {
+	t.Helper()
+	req := SaveTeamRequest{Positions: map[string]*string{"1": &heroID}}
+	if w := s.do(t, http.MethodPost, "/v1/team/save", token, req, nil); w.Code != http.StatusOK {
+		t.Fatalf("save team: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetTeamWithoutTeam(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+
+	var res model.TeamResponse
+	if w := s.do(t, http.MethodGet, "/v1/team/get", session.Token, nil, &res); w.Code != http.StatusOK {
+		t.Fatalf("get team: %d %s", w.Code, w.Body.String())
+	}
+	if len(res.Positions) != maxTeamSize {
+		t.Fatalf("got %d positions, want %d", len(res.Positions), maxTeamSize)
+	}
+	for pos, hero := range res.Positions {
+		if hero != nil {
+			t.Errorf("position %s holds %+v, want it empty", pos, hero)
+		}
+	}
+}
+
+func TestSaveTeam(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+	hero := s.summonHero(t, session.Token)
+
+	s.saveTeam(t, session.Token, hero.ID)
+
+	var res model.TeamResponse
+	if w := s.do(t, http.MethodGet, "/v1/team/get", session.Token, nil, &res); w.Code != http.StatusOK {
+		t.Fatalf("get team: %d %s", w.Code, w.Body.String())
+	}
+	if res.TeamID == "" {
+		t.Error("saved team has no ID")
+	}
+	if got := res.Positions["1"]; got == nil || got.HeroID != hero.ID || got.Name != hero.Name {
+		t.Errorf("position 1 holds %+v, want hero %s", got, hero.ID)
+	}
+	if got := res.Positions["2"]; got != nil {
+		t.Errorf("position 2 holds %+v, want it empty", got)
+	}
+}
+
+func TestSaveTeamRejectsInvalidTeams(t *testing.T) {
+	s := newTestServer(t)
+	_, session := s.register(t)
+	hero := s.summonHero(t, session.Token)
+	_, other := s.register(t)
+	otherHero := s.summonHero(t, other.Token)
+
+	tests := []struct {
+		name      string
+		positions map[string]*string
+	}{
+		{"position out of range", map[string]*string{"6": &hero.ID}},
+		{"position not a number", map[string]*string{"front": &hero.ID}},
+		{"hero in two positions", map[string]*string{"1": &hero.ID, "2": &hero.ID}},
+		{"no heroes", map[string]*string{"1": nil}},
+		{"hero of another player", map[string]*string{"1": &otherHero.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res AuthResponse
+			w := s.do(t, http.MethodPost, "/v1/team/save", session.Token, SaveTeamRequest{Positions: tt.positions}, &res)
+			if w.Code != http.StatusBadRequest || res.Error != "invalid_request" {
+				t.Errorf("save team: %d %s, want 400 invalid_request", w.Code, w.Body.String())
+			}
+		})
+	}
+}
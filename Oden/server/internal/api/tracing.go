@@ -0,0 +1,45 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/semconv/v1.17.0/httpconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of requests and of the work handlers do outside
+// the store and storage, such as battle simulations
+var tracer = otel.Tracer("github.com/yourusername/oden/internal/api")
+
+// Tracing returns middleware that handles every request in a span named after
+// its method and route template, such as "POST /v1/battle/start". A trace
+// started by the client or a proxy and sent in the W3C traceparent header is
+// continued. The trace ID is added to the request's logger.
+func Tracing(serviceName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(httpconv.ServerRequest(serviceName, c.Request)...),
+			trace.WithAttributes(semconv.HTTPRoute(route)),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		if sc := span.SpanContext(); sc.IsValid() {
+			withLogField(c, "trace_id", sc.TraceID().String())
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		span.SetStatus(httpconv.ServerStatus(status))
+	}
+}
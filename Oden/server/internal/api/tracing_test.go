@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider that records spans for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return recorder
+}
+
+func TestTracingSpansRequests(t *testing.T) {
+	recorder := recordSpans(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var logged logrus.Fields
+	router.Use(Tracing("oden-test"))
+	router.GET("/v1/heroes/:id", func(c *gin.Context) {
+		logged = logging.FromContext(c.Request.Context()).Data
+		c.Status(http.StatusInternalServerError)
+	})
+
+	// A trace started by the client is continued
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/v1/heroes/hero_1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans ended, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /v1/heroes/:id" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span %q of kind %v, want the server span of the route", span.Name(), span.SpanKind())
+	}
+	if got := span.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want the client's %s", got, traceID)
+	}
+	if span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("parent span = %s, want the client's", span.Parent().SpanID())
+	}
+	attrs := make(map[string]interface{})
+	for _, kv := range span.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs[string(semconv.HTTPRouteKey)] != "/v1/heroes/:id" || attrs[string(semconv.HTTPStatusCodeKey)] != int64(http.StatusInternalServerError) {
+		t.Errorf("span attributes %v, want the route and status code", attrs)
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("span status = %v, want an error for a 500", span.Status())
+	}
+	if logged["trace_id"] != traceID {
+		t.Errorf("logged trace ID %v, want %s", logged["trace_id"], traceID)
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+func TestEmailTokenOnlyMatchesItsState(t *testing.T) {
+	ks := testKeySet(t, "EdDSA")
+
+	token, err := ks.GenerateEmailToken(PurposePasswordReset, "user", "old hash", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateEmailToken: %v", err)
+	}
+	claims, err := ks.ParseEmailToken(token, PurposePasswordReset)
+	if err != nil {
+		t.Fatalf("ParseEmailToken: %v", err)
+	}
+	if claims.Subject != "user" {
+		t.Errorf("subject = %q, want user", claims.Subject)
+	}
+	if !claims.Matches("old hash") {
+		t.Error("token does not match the state it was issued for")
+	}
+	// Once the password has been reset with it, the token is spent
+	if claims.Matches("new hash") {
+		t.Error("token matches a changed state")
+	}
+}
+
+func TestEmailTokenPurpose(t *testing.T) {
+	ks := testKeySet(t, "EdDSA")
+
+	token, err := ks.GenerateEmailToken(PurposeVerifyEmail, "user", "player@example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateEmailToken: %v", err)
+	}
+	if _, err := ks.ParseEmailToken(token, PurposePasswordReset); err == nil {
+		t.Error("email verification token accepted for a password reset")
+	}
+	if _, err := ks.ValidateToken(token); err == nil {
+		t.Error("email verification token accepted as an access token")
+	}
+
+	access, err := ks.GenerateToken("user", "session", config.Default())
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if _, err := ks.ParseEmailToken(access, PurposeVerifyEmail); err == nil {
+		t.Error("access token accepted as an email token")
+	}
+}
+
+func TestEmailTokenExpires(t *testing.T) {
+	ks := testKeySet(t, "EdDSA")
+
+	token, err := ks.GenerateEmailToken(PurposeVerifyEmail, "user", "player@example.com", -time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateEmailToken: %v", err)
+	}
+	if _, err := ks.ParseEmailToken(token, PurposeVerifyEmail); err == nil {
+		t.Error("expired token accepted")
+	}
+}
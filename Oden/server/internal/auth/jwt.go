@@ -5,59 +5,73 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/yourusername/oden/internal/config"
 )
 
+// Token lifetimes used when the config leaves them unset
+const (
+	DefaultAccessTokenExpiry  = 15 * time.Minute
+	DefaultRefreshTokenExpiry = 30 * 24 * time.Hour
+)
+
 // TokenClaims represents the claims in a JWT token
 type TokenClaims struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid"` // the session the token was issued for
 	jwt.StandardClaims
 }
 
-// GenerateToken generates a new JWT token for a user
-func GenerateToken(userID string, cfg *config.Config) (string, error) {
+// AccessTokenExpiry returns how long access tokens are valid
+func AccessTokenExpiry(cfg *config.Config) time.Duration {
+	if cfg.Auth.AccessTokenExpiry <= 0 {
+		return DefaultAccessTokenExpiry
+	}
+	return time.Minute * time.Duration(cfg.Auth.AccessTokenExpiry)
+}
+
+// RefreshTokenExpiry returns how long a session lasts without being refreshed
+func RefreshTokenExpiry(cfg *config.Config) time.Duration {
+	if cfg.Auth.RefreshTokenExpiry <= 0 {
+		return DefaultRefreshTokenExpiry
+	}
+	return time.Hour * time.Duration(cfg.Auth.RefreshTokenExpiry)
+}
+
+// GenerateToken generates a new short-lived access token for a user's
+// session, signed with the signing key of the set
+func (ks *KeySet) GenerateToken(userID, sessionID string, cfg *config.Config) (string, error) {
 	// Set token expiry time
-	expirationTime := time.Now().Add(time.Hour * time.Duration(cfg.Auth.TokenExpiry))
+	expirationTime := time.Now().Add(AccessTokenExpiry(cfg))
 
 	// Create the JWT claims
 	claims := &TokenClaims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
 			ExpiresAt: expirationTime.Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
 
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign the token with the secret key
-	tokenString, err := token.SignedString([]byte(cfg.Auth.JWTSecret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
+	// Sign the token with the current signing key
+	return ks.sign(claims)
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString string, cfg *config.Config) (*TokenClaims, error) {
+// ValidateToken validates a JWT token signed with any key of the set and
+// returns the claims
+func (ks *KeySet) ValidateToken(tokenString string) (*TokenClaims, error) {
 	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid token signing method")
-		}
-
-		return []byte(cfg.Auth.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, ks.verificationKey)
 
 	if err != nil {
 		return nil, err
 	}
 
 	// Validate and extract claims
-	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid {
+	// Tokens with an audience are email tokens, not access tokens
+	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid && claims.Audience == "" {
 		return claims, nil
 	}
 
@@ -65,14 +79,14 @@ func ValidateToken(tokenString string, cfg *config.Config) (*TokenClaims, error)
 }
 
 // GetUserIDFromToken extracts the user ID from a token string
-func GetUserIDFromToken(tokenString string, cfg *config.Config) (string, error) {
+func (ks *KeySet) GetUserIDFromToken(tokenString string) (string, error) {
 	// Remove "Bearer " prefix if present
 	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
 		tokenString = tokenString[7:]
 	}
 
 	// Validate token and get claims
-	claims, err := ValidateToken(tokenString, cfg)
+	claims, err := ks.ValidateToken(tokenString)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/yourusername/oden/internal/config"
+)
+
+// minRSABits is the smallest RSA key accepted for signing or verification
+const minRSABits = 2048
+
+// key is a key access tokens are signed or verified with
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer // nil for keys that only verify
+	public  crypto.PublicKey
+}
+
+// KeySet holds the keys access tokens are signed and verified with. New
+// tokens are signed with a single key, named in their kid header, and
+// tokens signed with any key of the set are accepted. To rotate keys, add
+// the new key everywhere, then make it the signing key, and remove the old
+// key once the tokens it signed have expired.
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+}
+
+// LoadKeySet loads the keys configured in cfg.Auth. There is no fallback:
+// it fails unless exactly one private key can be chosen to sign tokens.
+func LoadKeySet(cfg *config.Config) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*key)}
+
+	for _, kc := range cfg.Auth.Keys {
+		data := []byte(kc.PEM)
+		if kc.Path != "" {
+			var err error
+			if data, err = os.ReadFile(kc.Path); err != nil {
+				return nil, fmt.Errorf("auth key %q: %w", kc.ID, err)
+			}
+		}
+		if err := ks.add(kc.ID, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Auth.KeysDir != "" {
+		paths, err := filepath.Glob(filepath.Join(cfg.Auth.KeysDir, "*.pem"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if err := ks.add(strings.TrimSuffix(filepath.Base(path), ".pem"), data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := ks.chooseSigningKey(cfg.Auth.SigningKey); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// add parses a PEM key and adds it to the set
+func (ks *KeySet) add(id string, data []byte) error {
+	if id == "" {
+		return errors.New("auth key without an id")
+	}
+	if _, ok := ks.keys[id]; ok {
+		return fmt.Errorf("auth key %q is configured twice", id)
+	}
+
+	k, err := parseKey(data)
+	if err != nil {
+		return fmt.Errorf("auth key %q: %w", id, err)
+	}
+	k.id = id
+	ks.keys[id] = k
+	return nil
+}
+
+// chooseSigningKey sets the signing key to the named key, or to the only
+// private key if no name is given
+func (ks *KeySet) chooseSigningKey(id string) error {
+	if id != "" {
+		k, ok := ks.keys[id]
+		if !ok {
+			return fmt.Errorf("auth.signing_key %q is not one of the configured keys", id)
+		}
+		if k.private == nil {
+			return fmt.Errorf("auth.signing_key %q is a public key and cannot sign tokens", id)
+		}
+		ks.signing = k
+		return nil
+	}
+
+	for _, k := range ks.keys {
+		if k.private == nil {
+			continue
+		}
+		if ks.signing != nil {
+			return errors.New("auth.signing_key must name the key to sign tokens with when several private keys are configured")
+		}
+		ks.signing = k
+	}
+	if ks.signing == nil {
+		return errors.New("no private key configured to sign access tokens; see auth.keys and auth.keys_dir")
+	}
+	return nil
+}
+
+// sign signs claims with the signing key, naming it in the kid header
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	return token.SignedString(ks.signing.private)
+}
+
+// verificationKey is a jwt.Keyfunc returning the key named by the token's
+// kid header. The signing method must be the key's: a token cannot choose
+// how it is verified.
+func (ks *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown token signing key")
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, errors.New("invalid token signing method")
+	}
+	return k.public, nil
+}
+
+// parseKey parses an RSA or Ed25519 private or public key in PEM format
+func parseKey(data []byte) (*key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k := &key{}
+	switch pk := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodRS256, pk, &pk.PublicKey
+	case *rsa.PublicKey:
+		k.method, k.public = jwt.SigningMethodRS256, pk
+	case ed25519.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodEdDSA, pk, pk.Public()
+	case ed25519.PublicKey:
+		k.method, k.public = jwt.SigningMethodEdDSA, pk
+	default:
+		return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", parsed)
+	}
+
+	if pub, ok := k.public.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA key is %d bits, at least %d are required", pub.N.BitLen(), minRSABits)
+	}
+	return k, nil
+}
+
+// GenerateKey creates a new private key for signing access tokens, in PEM
+// format. alg is "EdDSA" (Ed25519) or "RS256" (RSA 3072).
+func GenerateKey(alg string) ([]byte, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q; use EdDSA or RS256", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 curve and public key (RFC 8037)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public part of every key in the set, ordered by key ID,
+// so other services can verify access tokens
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, k := range ks.keys {
+		jwk := JWK{KeyID: k.id, Use: "sig", Algorithm: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/yourusername/oden/internal/config"
+)
+
+// testKeySet returns a key set of new keys with the given algorithms, signing
+// with the first. Keys are named after their index, key0, key1 and so on.
+func testKeySet(t *testing.T, algs ...string) *KeySet {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Auth.KeysDir = ""
+	for i, alg := range algs {
+		pem, err := GenerateKey(alg)
+		if err != nil {
+			t.Fatalf("generating %s key: %v", alg, err)
+		}
+		id := "key" + strconv.Itoa(i)
+		cfg.Auth.Keys = append(cfg.Auth.Keys, config.KeyConfig{ID: id, PEM: string(pem)})
+	}
+	cfg.Auth.SigningKey = "key0"
+
+	ks, err := LoadKeySet(cfg)
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	return ks
+}
+
+func TestKeySetAcceptsTokensOfEveryKey(t *testing.T) {
+	ks := testKeySet(t, "EdDSA", "EdDSA")
+	cfg := config.Default()
+
+	token, err := ks.GenerateToken("user", "session", cfg)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	claims, err := ks.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != "user" || claims.SessionID != "session" {
+		t.Errorf("claims = %+v, want user and session", claims)
+	}
+
+	// After a rotation, tokens of the old signing key stay valid
+	if err := ks.chooseSigningKey("key1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateToken(token); err != nil {
+		t.Errorf("token of the previous signing key: %v", err)
+	}
+}
+
+func TestKeySetRejectsUnknownKeys(t *testing.T) {
+	ks := testKeySet(t, "EdDSA")
+	other := testKeySet(t, "EdDSA")
+	claims := &TokenClaims{UserID: "user", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()}}
+
+	tests := []struct {
+		name string
+		kid  interface{}
+	}{
+		{"without kid", nil},
+		{"unknown kid", "key9"},
+		{"kid of another type", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+			if tt.kid != nil {
+				token.Header["kid"] = tt.kid
+			}
+			signed, err := token.SignedString(ks.signing.private)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ks.ValidateToken(signed); err == nil {
+				t.Error("ValidateToken accepted the token")
+			}
+		})
+	}
+
+	// A known kid does not help a token signed with a key outside the set
+	signed, err := other.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateToken(signed); err == nil {
+		t.Error("ValidateToken accepted a token signed with another key of the same id")
+	}
+}
+
+func TestKeySetRejectsOtherSigningMethods(t *testing.T) {
+	ks := testKeySet(t, "RS256", "EdDSA")
+	claims := &TokenClaims{UserID: "user", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()}}
+
+	// An HMAC token keyed with the public key, which anyone can get from
+	// the JWKS, must not pass as an RSA token
+	public, err := x509.MarshalPKIXPublicKey(ks.keys["key0"].public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmac.Header["kid"] = "key0"
+	signed, err := hmac.SignedString(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateToken(signed); err == nil {
+		t.Error("ValidateToken accepted an HS256 token for an RSA key")
+	}
+
+	// Nor may a token name one key and be signed with the method of another
+	eddsa := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	eddsa.Header["kid"] = "key0"
+	signed, err = eddsa.SignedString(ks.keys["key1"].private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateToken(signed); err == nil {
+		t.Error("ValidateToken accepted an EdDSA token naming an RSA key")
+	}
+
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = "key0"
+	signed, err = none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateToken(signed); err == nil {
+		t.Error("ValidateToken accepted an unsigned token")
+	}
+}
+
+func TestLoadKeySetNeedsOneSigningKey(t *testing.T) {
+	private, err := GenerateKey("EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey("EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    []config.KeyConfig
+		signing string
+	}{
+		{"no keys", nil, ""},
+		{"several private keys", []config.KeyConfig{{ID: "a", PEM: string(private)}, {ID: "b", PEM: string(other)}}, ""},
+		{"unknown signing key", []config.KeyConfig{{ID: "a", PEM: string(private)}}, "b"},
+		{"key twice", []config.KeyConfig{{ID: "a", PEM: string(private)}, {ID: "a", PEM: string(other)}}, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Auth.KeysDir = ""
+			cfg.Auth.Keys = tt.keys
+			cfg.Auth.SigningKey = tt.signing
+			if _, err := LoadKeySet(cfg); err == nil {
+				t.Error("LoadKeySet succeeded")
+			}
+		})
+	}
+}
+
+func TestJWKSPublishesEveryPublicKey(t *testing.T) {
+	ks := testKeySet(t, "RS256", "EdDSA")
+
+	set := ks.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].KeyID != "key0" || set.Keys[1].KeyID != "key1" {
+		t.Fatalf("JWKS = %+v, want key0 and key1 in order", set.Keys)
+	}
+
+	rsaKey := set.Keys[0]
+	if rsaKey.KeyType != "RSA" || rsaKey.Algorithm != "RS256" || rsaKey.Use != "sig" {
+		t.Errorf("RSA key = %+v", rsaKey)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(rsaKey.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(rsaKey.E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if !pub.Equal(ks.keys["key0"].public) {
+		t.Error("RSA key does not decode to the public key of key0")
+	}
+
+	edKey := set.Keys[1]
+	if edKey.KeyType != "OKP" || edKey.Curve != "Ed25519" || edKey.Algorithm != "EdDSA" {
+		t.Errorf("Ed25519 key = %+v", edKey)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(edKey.X)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.PublicKey(x).Equal(ks.keys["key1"].public) {
+		t.Error("Ed25519 key does not decode to the public key of key1")
+	}
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+// LockoutDuration returns how long password logins to an account are refused
+// after its given number of wrong passwords in a row, or 0 while there are
+// too few to lock it. Each wrong password past the threshold doubles the
+// lockout, so guessing gets slower and slower.
+func LockoutDuration(cfg *config.Config, failedLogins int) time.Duration {
+	excess := failedLogins - cfg.Auth.LockoutThreshold
+	if excess < 0 {
+		return 0
+	}
+
+	lockout := time.Duration(cfg.Auth.LockoutDuration) * time.Minute
+	max := time.Duration(cfg.Auth.MaxLockoutDuration) * time.Minute
+	for i := 0; i < excess && lockout < max; i++ {
+		lockout *= 2
+	}
+	if lockout > max {
+		lockout = max
+	}
+	return lockout
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	cfg := &config.Config{Auth: config.AuthConfig{LockoutThreshold: 5, LockoutDuration: 1, MaxLockoutDuration: 60}}
+	tests := []struct {
+		failedLogins int
+		want         time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{11, 60 * time.Minute},
+		{1000, 60 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := LockoutDuration(cfg, tt.failedLogins); got != tt.want {
+			t.Errorf("LockoutDuration after %d wrong passwords = %v, want %v", tt.failedLogins, got, tt.want)
+		}
+	}
+}
+
+func TestDummyHashMatchesNoPassword(t *testing.T) {
+	hash := DummyHash()
+	if hash != DummyHash() {
+		t.Error("DummyHash changed between calls")
+	}
+	for _, password := range []string{"", "password123", hash} {
+		if CheckPasswordHash(password, hash) {
+			t.Errorf("%q matches the dummy hash", password)
+		}
+	}
+}
@@ -1,9 +1,17 @@
 package auth
 
 import (
+	"crypto/rand"
+	"sync"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
 // HashPassword creates a bcrypt hash of the password
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -14,4 +22,22 @@ func HashPassword(password string) (string, error) {
 func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
-} 
\ No newline at end of file
+} 
+
+// DummyHash returns a hash made like HashPassword's of a random password that
+// is never used. Checking a password against it when there is no account to
+// check makes a refused login take as long as one with a wrong password.
+func DummyHash() string {
+	dummyHashOnce.Do(func() {
+		password := make([]byte, 32)
+		if _, err := rand.Read(password); err != nil {
+			panic("auth: cannot read random bytes: " + err.Error())
+		}
+		hash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+		if err != nil {
+			panic("auth: cannot hash the dummy password: " + err.Error())
+		}
+		dummyHash = string(hash)
+	})
+	return dummyHash
+}
\ No newline at end of file
@@ -0,0 +1,220 @@
+// Package battle runs the server-side auto-battle simulation.
+//
+// A simulation is a pure function of the team, the stage and the seed: the
+// same inputs always produce a byte-identical battle log, so the client can
+// replay a battle and disputed battles can be re-run from the stored seed.
+// Damage is computed with integer arithmetic only, so the result does not
+// depend on the platform's floating point behaviour.
+package battle
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// Battle outcomes
+const (
+	Victory = "victory"
+	Defeat  = "defeat"
+)
+
+const (
+	// MaxTurns ends a battle as a defeat if it runs this long
+	MaxTurns = 30
+
+	// BasicAttackSkillID is logged for actions that do not use a skill
+	BasicAttackSkillID = "basic_attack"
+
+	// Damage varies randomly between these percentages of the base damage
+	minDamagePercent = 90
+	maxDamagePercent = 110
+)
+
+var (
+	// ErrNoHeroes is returned when the team has no heroes to fight with
+	ErrNoHeroes = errors.New("battle: team has no heroes")
+
+	// ErrNoEnemies is returned when the stage has no enemies loaded
+	ErrNoEnemies = errors.New("battle: stage has no enemies")
+)
+
+// Result is the outcome of a simulated battle
+type Result struct {
+	Outcome string
+	Seed    int64
+	Log     []model.BattleTurn
+}
+
+// unit is a hero or enemy taking part in a battle
+type unit struct {
+	id        string
+	atk       int
+	hp        int
+	skills    []skill
+	cooldowns []int
+}
+
+// skill is a model.Skill with its multiplier converted to per mille
+type skill struct {
+	id         string
+	permille   int
+	cooldown   int
+	targetsAll bool
+}
+
+// Simulate fights the stage's enemies with the team and returns the battle log.
+//
+// Heroes act in position order, then enemies in stage order. Each unit uses its
+// first skill that is off cooldown, or a basic attack. Single-target actions hit
+// a random living opponent; AoE skills hit every living opponent. The team's
+// heroes must have their stats calculated, including equipment bonuses. The
+// inputs are not modified.
+func Simulate(team *model.Team, stage *model.Stage, seed int64) (*Result, error) {
+	positions := make([]int, 0, len(team.Heroes))
+	for pos, hero := range team.Heroes {
+		if hero != nil {
+			positions = append(positions, pos)
+		}
+	}
+	sort.Ints(positions)
+
+	var heroes []*unit
+	for _, pos := range positions {
+		hero := team.Heroes[pos]
+		heroes = append(heroes, newUnit(hero.ID, hero.ATK, hero.HP, hero.Skills))
+	}
+	if len(heroes) == 0 {
+		return nil, ErrNoHeroes
+	}
+
+	var enemies []*unit
+	for _, enemy := range stage.Enemies {
+		enemies = append(enemies, newUnit(enemy.ID, enemy.ATK, enemy.HP, enemy.Skills))
+	}
+	if len(enemies) == 0 {
+		return nil, ErrNoEnemies
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	res := &Result{Outcome: Defeat, Seed: seed, Log: []model.BattleTurn{}}
+
+	for turn := 1; turn <= MaxTurns; turn++ {
+		bt := model.BattleTurn{Turn: turn, Actions: []model.BattleAction{}}
+
+		for _, actor := range heroes {
+			if actor.hp > 0 && anyAlive(enemies) {
+				bt.Actions = append(bt.Actions, actor.act(enemies, rng)...)
+			}
+		}
+		for _, actor := range enemies {
+			if actor.hp > 0 && anyAlive(heroes) {
+				bt.Actions = append(bt.Actions, actor.act(heroes, rng)...)
+			}
+		}
+		res.Log = append(res.Log, bt)
+
+		if !anyAlive(enemies) {
+			res.Outcome = Victory
+			break
+		}
+		if !anyAlive(heroes) {
+			break
+		}
+	}
+	return res, nil
+}
+
+// newUnit creates a unit at full health with every skill ready
+func newUnit(id string, atk, hp int, skills []model.Skill) *unit {
+	u := &unit{id: id, atk: atk, hp: hp, cooldowns: make([]int, len(skills))}
+	for _, s := range skills {
+		u.skills = append(u.skills, skill{
+			id:         s.ID,
+			permille:   int(math.Round(s.DamageMultiplier * 1000)),
+			cooldown:   s.Cooldown,
+			targetsAll: s.TargetsAll,
+		})
+	}
+	return u
+}
+
+// act uses the first ready skill, or a basic attack, and returns the resulting actions
+func (u *unit) act(opponents []*unit, rng *rand.Rand) []model.BattleAction {
+	defer u.tickCooldowns()
+
+	for i, s := range u.skills {
+		if u.cooldowns[i] > 0 {
+			continue
+		}
+		u.cooldowns[i] = s.cooldown + 1 // ticked once at the end of this action
+
+		if !s.targetsAll {
+			return []model.BattleAction{u.hit(randomAlive(opponents, rng), s.id, s.permille, rng)}
+		}
+		var actions []model.BattleAction
+		for _, target := range opponents {
+			if target.hp > 0 {
+				actions = append(actions, u.hit(target, s.id, s.permille, rng))
+			}
+		}
+		return actions
+	}
+
+	return []model.BattleAction{u.hit(randomAlive(opponents, rng), BasicAttackSkillID, 1000, rng)}
+}
+
+// hit deals damage to the target and records the action
+func (u *unit) hit(target *unit, skillID string, permille int, rng *rand.Rand) model.BattleAction {
+	percent := minDamagePercent + rng.Intn(maxDamagePercent-minDamagePercent+1)
+	damage := u.atk * permille * percent / 100000
+	if damage < 1 {
+		damage = 1
+	}
+
+	target.hp -= damage
+	if target.hp < 0 {
+		target.hp = 0
+	}
+	return model.BattleAction{
+		Actor:             u.id,
+		Target:            target.id,
+		SkillUsed:         skillID,
+		DamageDealt:       damage,
+		TargetHPRemaining: target.hp,
+	}
+}
+
+// tickCooldowns advances every skill cooldown by one turn
+func (u *unit) tickCooldowns() {
+	for i := range u.cooldowns {
+		if u.cooldowns[i] > 0 {
+			u.cooldowns[i]--
+		}
+	}
+}
+
+// randomAlive picks a random opponent with HP left. The caller makes sure
+// there is at least one.
+func randomAlive(group []*unit, rng *rand.Rand) *unit {
+	var alive []*unit
+	for _, u := range group {
+		if u.hp > 0 {
+			alive = append(alive, u)
+		}
+	}
+	return alive[rng.Intn(len(alive))]
+}
+
+// anyAlive reports whether any unit in the group has HP left
+func anyAlive(group []*unit) bool {
+	for _, u := range group {
+		if u.hp > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package battle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// testTeam returns a team of two heroes, one with a single-target skill and
+// one with an AoE skill
+func testTeam() *model.Team {
+	team := model.NewTeam("team", "user")
+	team.Heroes[1] = &model.Hero{ID: "hero_1", ATK: 60, HP: 400, Skills: []model.Skill{
+		{ID: "slash", DamageMultiplier: 1.5, Cooldown: 2},
+	}}
+	team.Heroes[3] = &model.Hero{ID: "hero_3", ATK: 45, HP: 300, Skills: []model.Skill{
+		{ID: "fireball", DamageMultiplier: 1.2, Cooldown: 3, TargetsAll: true},
+	}}
+	return team
+}
+
+// testStage returns a stage of three enemies the test team can beat in a
+// few turns
+func testStage() *model.Stage {
+	return &model.Stage{ID: "stage", Enemies: []*model.Enemy{
+		{ID: "enemy_1", ATK: 30, HP: 250},
+		{ID: "enemy_2", ATK: 35, HP: 200, Skills: []model.Skill{{ID: "bite", DamageMultiplier: 1.3, Cooldown: 2}}},
+		{ID: "enemy_3", ATK: 25, HP: 300},
+	}}
+}
+
+// encodeLog returns the battle log as the client receives it
+func encodeLog(t *testing.T, res *Result) []byte {
+	t.Helper()
+	data, err := json.Marshal(res.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSimulateIsDeterministic(t *testing.T) {
+	const seed = 42
+	first, err := Simulate(testTeam(), testStage(), seed)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	second, err := Simulate(testTeam(), testStage(), seed)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+
+	if first.Outcome != second.Outcome || first.Seed != seed || second.Seed != seed {
+		t.Errorf("outcomes %s and %s with seeds %d and %d, want the same outcome with seed %d",
+			first.Outcome, second.Outcome, first.Seed, second.Seed, seed)
+	}
+	if !bytes.Equal(encodeLog(t, first), encodeLog(t, second)) {
+		t.Error("the same team, stage and seed gave different battle logs")
+	}
+}
+
+func TestSimulateDependsOnSeed(t *testing.T) {
+	first, err := Simulate(testTeam(), testStage(), 1)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	second, err := Simulate(testTeam(), testStage(), 2)
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if bytes.Equal(encodeLog(t, first), encodeLog(t, second)) {
+		t.Error("different seeds gave the same battle log")
+	}
+}
+
+func TestSimulateDoesNotModifyInputs(t *testing.T) {
+	team, stage := testTeam(), testStage()
+	if _, err := Simulate(team, stage, 7); err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if team.Heroes[1].HP != 400 || stage.Enemies[0].HP != 250 {
+		t.Errorf("hero HP %d and enemy HP %d after the battle, want 400 and 250", team.Heroes[1].HP, stage.Enemies[0].HP)
+	}
+}
+
+func TestSimulateNeedsBothSides(t *testing.T) {
+	if _, err := Simulate(model.NewTeam("team", "user"), testStage(), 1); !errors.Is(err, ErrNoHeroes) {
+		t.Errorf("empty team: got %v, want ErrNoHeroes", err)
+	}
+	if _, err := Simulate(testTeam(), &model.Stage{ID: "stage"}, 1); !errors.Is(err, ErrNoEnemies) {
+		t.Errorf("stage without enemies: got %v, want ErrNoEnemies", err)
+	}
+}
@@ -1,28 +1,67 @@
 package config
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-)
+import "sync/atomic"
 
 // Config holds the application configuration
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Auth     AuthConfig     `json:"auth"`
-	Storage  StorageConfig  `json:"storage"`
-	Game     GameConfig     `json:"game"`
+	Server    ServerConfig    `json:"server"`
+	Database  DatabaseConfig  `json:"database"`
+	Auth      AuthConfig      `json:"auth"`
+	Storage   StorageConfig   `json:"storage"`
+	Mail      MailConfig      `json:"mail"`
+	Log       LogConfig       `json:"log"`
+	Metrics   MetricsConfig   `json:"metrics"`
+	Tracing   TracingConfig   `json:"tracing"`
+	RateLimit RateLimitConfig `json:"rate_limit"`
+	// Game is the game config as loaded at startup. Read it with CurrentGame,
+	// which follows changes to the config file.
+	Game GameConfig `json:"game"`
+
+	game atomic.Pointer[GameConfig] // set when the config file is reloaded
+}
+
+// CurrentGame returns the game config in effect. A reload replaces it as a
+// whole, so callers see a consistent set of values; they must not modify it.
+func (c *Config) CurrentGame() *GameConfig {
+	if game := c.game.Load(); game != nil {
+		return game
+	}
+	return &c.Game
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Port int    `json:"port"`
 	Host string `json:"host"`
+	// Timeouts in seconds for reading a request's headers, reading the whole
+	// request, writing the response and keeping idle connections open
+	ReadHeaderTimeout int `json:"read_header_timeout"` // default 5
+	ReadTimeout       int `json:"read_timeout"`        // default 15
+	WriteTimeout      int `json:"write_timeout"`       // default 30
+	IdleTimeout       int `json:"idle_timeout"`        // default 120
+	// ShutdownTimeout is how many seconds in-flight requests get to finish
+	// when the server is stopped, before they are cut off
+	ShutdownTimeout int `json:"shutdown_timeout"` // default 30
+	// HealthCheckTimeout is how many seconds each readiness check may take
+	HealthCheckTimeout int `json:"health_check_timeout"` // default 2
+	// TrustedProxies are the addresses or CIDR ranges of the proxies and load
+	// balancers in front of the server. The client IP is read from the
+	// X-Forwarded-For header they set, and taken from the connection
+	// otherwise. Default: loopback and private networks.
+	TrustedProxies []string `json:"trusted_proxies"`
+	// IdempotencyKeyExpiry is how many hours the response to a request sent
+	// with an Idempotency-Key header is kept for retries
+	IdempotencyKeyExpiry int `json:"idempotency_key_expiry"` // default 24
+	// IdempotencyKeyLease is how many seconds a request holds its
+	// Idempotency-Key while it runs. A retry after that runs the request
+	// again, so a server that stopped in the middle of one does not block
+	// retries until the key expires. It must be longer than any request takes.
+	IdempotencyKeyLease int `json:"idempotency_key_lease"` // default 300
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
+	Driver   string `json:"driver"` // mysql (default) or memory
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
 	User     string `json:"user"`
@@ -32,8 +71,38 @@ type DatabaseConfig struct {
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	JWTSecret   string `json:"jwt_secret"`
-	TokenExpiry int    `json:"token_expiry"` // in hours
+	// SigningKey is the ID of the private key new access tokens are signed
+	// with. It may be left empty if there is only one private key.
+	SigningKey string `json:"signing_key"`
+	// Keys and the PEM files in KeysDir, named <key id>.pem, are the keys
+	// access tokens are verified with. Keeping the previous key after a
+	// rotation lets the tokens it signed stay valid until they expire.
+	Keys               []KeyConfig `json:"keys"`
+	KeysDir            string      `json:"keys_dir"`
+	AccessTokenExpiry  int         `json:"access_token_expiry"`  // in minutes, default 15
+	RefreshTokenExpiry int         `json:"refresh_token_expiry"` // in hours, default 720 (30 days)
+	// Lifetimes of the links sent by email
+	VerifyEmailExpiry   int `json:"verify_email_expiry"`   // in hours, default 48
+	PasswordResetExpiry int `json:"password_reset_expiry"` // in minutes, default 60
+	// After LockoutThreshold wrong passwords in a row, password logins to the
+	// account are refused for LockoutDuration, doubling with every further
+	// wrong password up to MaxLockoutDuration
+	LockoutThreshold   int `json:"lockout_threshold"`    // default 5
+	LockoutDuration    int `json:"lockout_duration"`     // in minutes, default 1
+	MaxLockoutDuration int `json:"max_lockout_duration"` // in minutes, default 60
+	// CountryHeader is the request header a proxy or CDN in front of the
+	// server sets to the client's country code, such as CF-IPCountry. It is
+	// used to flag logins from another country. Only set it if the proxy
+	// always overwrites the header, as clients can send it too.
+	CountryHeader string `json:"country_header"`
+}
+
+// KeyConfig is an RSA or Ed25519 key for access tokens, given as a PEM file
+// or inline PEM. Private keys can sign and verify, public keys only verify.
+type KeyConfig struct {
+	ID   string `json:"id"` // sent as the kid header of tokens
+	Path string `json:"path"`
+	PEM  string `json:"pem"`
 }
 
 // StorageConfig holds storage configuration
@@ -45,81 +114,96 @@ type StorageConfig struct {
 	UseSSL    bool   `json:"use_ssl"`
 }
 
-// GameConfig holds game-specific configuration
-type GameConfig struct {
-	MaxIdleHours      int `json:"max_idle_hours"`
-	IdleGoldPerMinute int `json:"idle_gold_per_minute"`
-	IdleExpPerMinute  int `json:"idle_exp_per_minute"`
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	Driver string `json:"driver"` // smtp, or log (default) for local development
+	From   string `json:"from"`
+	// SMTP server. Connections are upgraded with STARTTLS when the server
+	// supports it; credentials are only sent over TLS or to localhost.
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Dir makes the log driver write each email to a file in it instead of the log
+	Dir string `json:"dir"`
+	// LinkBaseURL is the start of the links in emails; the token is appended
+	// to pages under it, e.g. <base>/verify-email?token=...
+	LinkBaseURL string `json:"link_base_url"`
 }
 
-// LoadConfig loads configuration from a file
-func LoadConfig(path string) (*Config, error) {
-	// Read configuration file
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+// LogConfig holds logging configuration
+type LogConfig struct {
+	Level  string `json:"level"`  // debug, info (default), warn or error
+	Format string `json:"format"` // json (default), or text for local development
+}
 
-	// Parse configuration
-	var config Config
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&config); err != nil {
-		return nil, err
-	}
+// MetricsConfig holds the Prometheus metrics endpoint configuration
+type MetricsConfig struct {
+	Enabled bool `json:"enabled"` // serve /metrics, default true
+	// BearerToken, if set, must be sent by scrapers as
+	// "Authorization: Bearer <token>", so the metrics are not public
+	BearerToken string `json:"bearer_token"`
+}
 
-	// Override with environment variables if they exist
-	overrideWithEnv(&config)
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	// Exporter spans are sent with: none (default), otlp to send them to a
+	// collector over gRPC, or stdout to print them
+	Exporter string `json:"exporter"`
+	// Endpoint is the host:port of the OTLP collector. Empty uses the
+	// OTEL_EXPORTER_OTLP_ENDPOINT environment variable, or localhost:4317.
+	Endpoint    string  `json:"endpoint"`
+	Insecure    bool    `json:"insecure"`     // connect to the collector without TLS
+	ServiceName string  `json:"service_name"` // default oden-api
+	SampleRatio float64 `json:"sample_ratio"` // share of new traces recorded, default 1
+}
 
-	return &config, nil
+// RateLimitConfig holds the request rate limits
+type RateLimitConfig struct {
+	Enabled bool `json:"enabled"` // default true
+	// Store keeps the request counts: memory (default) for a single
+	// instance, or redis to share them between instances
+	Store string      `json:"store"`
+	Redis RedisConfig `json:"redis"`
+	// Auth limits the /v1/auth routes per client IP, Protected the routes
+	// that need a login per user
+	Auth      RateLimit `json:"auth"`      // default 20 per 60 seconds
+	Protected RateLimit `json:"protected"` // default 120 per 60 seconds
 }
 
-// overrideWithEnv overrides configuration with environment variables
-func overrideWithEnv(config *Config) {
-	// Database
-	if host := os.Getenv("ODEN_DB_HOST"); host != "" {
-		config.Database.Host = host
-	}
-	if port := os.Getenv("ODEN_DB_PORT"); port != "" {
-		var portInt int
-		if _, err := fmt.Sscanf(port, "%d", &portInt); err == nil {
-			config.Database.Port = portInt
-		}
-	}
-	if user := os.Getenv("ODEN_DB_USER"); user != "" {
-		config.Database.User = user
-	}
-	if password := os.Getenv("ODEN_DB_PASSWORD"); password != "" {
-		config.Database.Password = password
-	}
-	if dbName := os.Getenv("ODEN_DB_NAME"); dbName != "" {
-		config.Database.DBName = dbName
-	}
+// RateLimit allows a number of requests in every window of Window seconds
+type RateLimit struct {
+	Requests int `json:"requests"`
+	Window   int `json:"window"`
+}
 
-	// Auth
-	if jwtSecret := os.Getenv("ODEN_JWT_SECRET"); jwtSecret != "" {
-		config.Auth.JWTSecret = jwtSecret
-	}
+// RedisConfig holds Redis connection configuration
+type RedisConfig struct {
+	Addr     string `json:"addr"` // host:port
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+}
 
-	// Server
-	if port := os.Getenv("ODEN_PORT"); port != "" {
-		var portInt int
-		if _, err := fmt.Sscanf(port, "%d", &portInt); err == nil {
-			config.Server.Port = portInt
-		}
-	}
+// GameConfig holds game-specific configuration
+type GameConfig struct {
+	MaxIdleHours      int `json:"max_idle_hours"`
+	IdleGoldPerMinute int `json:"idle_gold_per_minute"`
+	IdleExpPerMinute  int `json:"idle_exp_per_minute"`
+	// Idle rewards grow by this percentage for every stage up to the furthest cleared one
+	IdleStageBonusPercent int           `json:"idle_stage_bonus_percent"`
+	Missions              MissionConfig `json:"missions"`
+}
 
-	// Storage
-	if endpoint := os.Getenv("ODEN_STORAGE_ENDPOINT"); endpoint != "" {
-		config.Storage.Endpoint = endpoint
-	}
-	if bucket := os.Getenv("ODEN_STORAGE_BUCKET"); bucket != "" {
-		config.Storage.Bucket = bucket
-	}
-	if accessKey := os.Getenv("ODEN_STORAGE_ACCESS_KEY"); accessKey != "" {
-		config.Storage.AccessKey = accessKey
-	}
-	if secretKey := os.Getenv("ODEN_STORAGE_SECRET_KEY"); secretKey != "" {
-		config.Storage.SecretKey = secretKey
-	}
-} 
\ No newline at end of file
+// MissionConfig holds the daily and weekly mission schedule
+type MissionConfig struct {
+	ResetTime      string `json:"reset_time"`       // HH:MM at which daily missions reset, default 00:00
+	Timezone       string `json:"timezone"`         // IANA time zone of ResetTime, default UTC
+	WeeklyResetDay string `json:"weekly_reset_day"` // Day weekly missions reset on, default monday
+	// Templates daily and weekly missions are picked from. Empty means every
+	// template of that type.
+	DailyTemplates  []string `json:"daily_templates"`
+	WeeklyTemplates []string `json:"weekly_templates"`
+	// Missions given per period, rotating through the templates. 0 means all of them.
+	DailyCount  int `json:"daily_count"`
+	WeeklyCount int `json:"weekly_count"`
+}
\ No newline at end of file
@@ -0,0 +1,299 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// envPrefix starts the name of the environment variable of every key
+const envPrefix = "ODEN"
+
+// legacyEnv are the environment variables keys were set with before every
+// key had one. They are still read, after the conventional name.
+var legacyEnv = map[string][]string{
+	"server.port":       {"ODEN_PORT"},
+	"database.driver":   {"ODEN_DB_DRIVER"},
+	"database.host":     {"ODEN_DB_HOST"},
+	"database.port":     {"ODEN_DB_PORT"},
+	"database.user":     {"ODEN_DB_USER"},
+	"database.password": {"ODEN_DB_PASSWORD"},
+	"database.dbname":   {"ODEN_DB_NAME"},
+	"auth.signing_key":  {"ODEN_JWT_SIGNING_KEY"},
+	"auth.keys_dir":     {"ODEN_JWT_KEYS_DIR"},
+	"mail.host":         {"ODEN_SMTP_HOST"},
+	"mail.username":     {"ODEN_SMTP_USERNAME"},
+	"mail.password":     {"ODEN_SMTP_PASSWORD"},
+}
+
+// Default returns the configuration settings have when nothing else sets them
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:                 8080,
+			ReadHeaderTimeout:    5,
+			ReadTimeout:          15,
+			WriteTimeout:         30,
+			IdleTimeout:          120,
+			ShutdownTimeout:      30,
+			HealthCheckTimeout:   2,
+			TrustedProxies:       []string{"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"},
+			IdempotencyKeyExpiry: 24,
+			IdempotencyKeyLease:  300,
+		},
+		Database: DatabaseConfig{
+			Driver: "mysql",
+			Host:   "localhost",
+			Port:   3306,
+			DBName: "oden",
+		},
+		Auth: AuthConfig{
+			KeysDir:             "keys",
+			AccessTokenExpiry:   15,
+			RefreshTokenExpiry:  720,
+			VerifyEmailExpiry:   48,
+			PasswordResetExpiry: 60,
+			LockoutThreshold:    5,
+			LockoutDuration:     1,
+			MaxLockoutDuration:  60,
+		},
+		Storage: StorageConfig{
+			Bucket: "oden-assets",
+		},
+		Mail: MailConfig{
+			Driver:      "log",
+			From:        "Oden <no-reply@oden-game.com>",
+			Port:        587,
+			LinkBaseURL: "https://oden-game.com/account",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "oden-api",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   true,
+			Store:     "memory",
+			Auth:      RateLimit{Requests: 20, Window: 60},
+			Protected: RateLimit{Requests: 120, Window: 60},
+		},
+		Game: GameConfig{
+			MaxIdleHours:          24,
+			IdleGoldPerMinute:     2,
+			IdleExpPerMinute:      1,
+			IdleStageBonusPercent: 10,
+			Missions: MissionConfig{
+				ResetTime:      "00:00",
+				Timezone:       "UTC",
+				WeeklyResetDay: "monday",
+			},
+		},
+	}
+}
+
+// Loader loads the configuration in layers. From lowest to highest
+// precedence they are the defaults, a JSON or YAML file, environment
+// variables and command line flags. Every key, such as
+// game.missions.reset_time, can be set by an environment variable named
+// after it, ODEN_GAME_MISSIONS_RESET_TIME, and by a flag of the same name,
+// -game.missions.reset_time. Lists are given comma separated, and lists of
+// objects as JSON.
+type Loader struct {
+	v    *viper.Viper
+	path string
+}
+
+// NewLoader creates a loader for the file at path, which may be empty to
+// only use defaults, environment variables and flags. flags must have been
+// parsed; the flags RegisterFlags added to it that were set override the
+// other layers.
+func NewLoader(path string, flags *flag.FlagSet) *Loader {
+	v := viper.New()
+	walk(reflect.ValueOf(Default()).Elem(), "", func(key string, value reflect.Value) {
+		v.SetDefault(key, value.Interface())
+		v.BindEnv(append([]string{key, envName(key)}, legacyEnv[key]...)...)
+	})
+
+	if flags != nil {
+		flags.Visit(func(f *flag.Flag) {
+			if kf, ok := f.Value.(*keyFlag); ok {
+				v.Set(f.Name, kf.value)
+			}
+		})
+	}
+
+	if path != "" {
+		v.SetConfigFile(path)
+	}
+	return &Loader{v: v, path: path}
+}
+
+// Load reads the configuration and validates it
+func (l *Loader) Load() (*Config, error) {
+	if l.path != "" {
+		if err := l.v.ReadInConfig(); err != nil {
+			return nil, err
+		}
+	}
+	return l.decode()
+}
+
+// decode builds the configuration from the layers read so far
+func (l *Loader) decode() (*Config, error) {
+	var cfg Config
+	err := l.v.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "json"
+		dc.ErrorUnused = true
+		dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+			jsonListHook,
+			mapstructure.StringToSliceHookFunc(","),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Watch applies changes to the game section of the config file to cfg while
+// the server runs, so balance changes need no restart. A change that does
+// not validate is ignored. Other sections are only read at startup.
+func (l *Loader) Watch(cfg *Config) {
+	if l.path == "" {
+		return
+	}
+
+	l.v.OnConfigChange(func(fsnotify.Event) {
+		// Editors may truncate the file before writing it; an empty file
+		// would otherwise reset the game config to the defaults
+		if info, err := os.Stat(l.path); err == nil && info.Size() == 0 {
+			return
+		}
+
+		next, err := l.decode()
+		if err != nil {
+			logrus.WithError(err).WithField("path", l.path).Error("Ignoring invalid changes to config file")
+			return
+		}
+
+		if !reflect.DeepEqual(cfg.CurrentGame(), &next.Game) {
+			cfg.game.Store(&next.Game)
+			logrus.WithField("path", l.path).Info("Reloaded game config")
+		}
+		if restartNeeded(cfg, next) {
+			logrus.WithField("path", l.path).Warn("Config file changed settings outside the game section; restart the server to apply them")
+		}
+	})
+	l.v.WatchConfig()
+}
+
+// restartNeeded reports whether next changes settings that are only read at
+// startup
+func restartNeeded(cfg, next *Config) bool {
+	return !reflect.DeepEqual(cfg.Server, next.Server) ||
+		!reflect.DeepEqual(cfg.Database, next.Database) ||
+		!reflect.DeepEqual(cfg.Auth, next.Auth) ||
+		!reflect.DeepEqual(cfg.Storage, next.Storage) ||
+		!reflect.DeepEqual(cfg.Mail, next.Mail) ||
+		!reflect.DeepEqual(cfg.Metrics, next.Metrics) ||
+		!reflect.DeepEqual(cfg.Tracing, next.Tracing) ||
+		!reflect.DeepEqual(cfg.RateLimit, next.RateLimit)
+}
+
+// RegisterFlags adds a flag for every key to flags, named like the key,
+// e.g. -server.port
+func RegisterFlags(flags *flag.FlagSet) {
+	walk(reflect.ValueOf(Default()).Elem(), "", func(key string, value reflect.Value) {
+		kf := &keyFlag{}
+		kind := value.Kind().String()
+		switch {
+		case value.Kind() == reflect.Bool:
+			kf.bool = true
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct:
+			kind = "json"
+		case value.Kind() == reflect.Slice:
+			kind = "list"
+		}
+		flags.Var(kf, key, fmt.Sprintf("also set by %s (`%s`)", envName(key), kind))
+	})
+}
+
+// keyFlag is the command line flag of a key. Only flags that were set are
+// applied, so unset flags never hide the file or the environment.
+type keyFlag struct {
+	value string
+	bool  bool
+}
+
+func (f *keyFlag) String() string {
+	return f.value
+}
+
+func (f *keyFlag) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+// IsBoolFlag lets boolean keys be set without a value, e.g. -storage.use_ssl
+func (f *keyFlag) IsBoolFlag() bool {
+	return f.bool
+}
+
+// envName returns the name of the environment variable of a key
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// walk calls fn with the key and value of every setting in v, a config
+// struct, e.g. "game.missions.reset_time". Lists are single settings.
+func walk(v reflect.Value, prefix string, fn func(key string, value reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), key+".", fn)
+			continue
+		}
+		fn(key, v.Field(i))
+	}
+}
+
+// jsonListHook decodes lists of objects given as JSON, as they are in
+// environment variables and flags, e.g.
+// ODEN_AUTH_KEYS='[{"id":"2024-01","path":"keys/2024-01.pem"}]'
+func jsonListHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Slice || to.Elem().Kind() != reflect.Struct {
+		return data, nil
+	}
+
+	list := reflect.New(to)
+	if err := json.Unmarshal([]byte(data.(string)), list.Interface()); err != nil {
+		return nil, fmt.Errorf("expected a JSON list: %w", err)
+	}
+	return list.Elem().Interface(), nil
+}
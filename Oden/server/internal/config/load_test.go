@@ -0,0 +1,149 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFile writes a config file named name in a new directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// load loads the config file at path with the given command line arguments
+func load(t *testing.T, path string, args ...string) (*Config, error) {
+	t.Helper()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return NewLoader(path, flags).Load()
+}
+
+func TestLoaderLayers(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+		"server": {"port": 9000, "host": "file"},
+		"database": {"user": "oden", "host": "file"},
+		"game": {"max_idle_hours": 12}
+	}`)
+	t.Setenv("ODEN_SERVER_HOST", "env")
+	t.Setenv("ODEN_DATABASE_HOST", "env")
+	t.Setenv("ODEN_GAME_MISSIONS_TIMEZONE", "Europe/Paris")
+
+	cfg, err := load(t, path, "-database.host", "flag", "-storage.use_ssl")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != 9000 || cfg.Game.MaxIdleHours != 12 {
+		t.Errorf("file settings: port %d, max idle hours %d", cfg.Server.Port, cfg.Game.MaxIdleHours)
+	}
+	if cfg.Server.Host != "env" || cfg.Game.Missions.Timezone != "Europe/Paris" {
+		t.Errorf("env settings: host %q, timezone %q", cfg.Server.Host, cfg.Game.Missions.Timezone)
+	}
+	if cfg.Database.Host != "flag" || !cfg.Storage.UseSSL {
+		t.Errorf("flag settings: database host %q, use SSL %v", cfg.Database.Host, cfg.Storage.UseSSL)
+	}
+	if cfg.Auth.AccessTokenExpiry != Default().Auth.AccessTokenExpiry {
+		t.Errorf("access token expiry = %d, want the default", cfg.Auth.AccessTokenExpiry)
+	}
+}
+
+func TestLoaderReadsYAMLAndEnvLists(t *testing.T) {
+	path := writeFile(t, "config.yaml", "database:\n  user: oden\ngame:\n  idle_gold_per_minute: 5\n")
+	t.Setenv("ODEN_DB_PORT", "3307")
+	t.Setenv("ODEN_SERVER_TRUSTED_PROXIES", "10.0.0.0/8,192.168.0.0/16")
+	t.Setenv("ODEN_AUTH_KEYS", `[{"id":"2024-01","path":"keys/2024-01.pem"}]`)
+
+	cfg, err := load(t, path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Game.IdleGoldPerMinute != 5 {
+		t.Errorf("idle gold per minute = %d, want 5 from the file", cfg.Game.IdleGoldPerMinute)
+	}
+	if cfg.Database.Port != 3307 {
+		t.Errorf("database port = %d, want 3307 from the legacy variable", cfg.Database.Port)
+	}
+	if len(cfg.Server.TrustedProxies) != 2 || cfg.Server.TrustedProxies[1] != "192.168.0.0/16" {
+		t.Errorf("trusted proxies = %v", cfg.Server.TrustedProxies)
+	}
+	if len(cfg.Auth.Keys) != 1 || cfg.Auth.Keys[0].ID != "2024-01" || cfg.Auth.Keys[0].Path != "keys/2024-01.pem" {
+		t.Errorf("auth keys = %+v", cfg.Auth.Keys)
+	}
+}
+
+func TestLoaderRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+	}{
+		{"unknown key", `{"database": {"user": "oden"}, "server": {"prot": 80}}`, nil},
+		{"invalid value", `{"database": {"user": "oden"}, "game": {"max_idle_hours": -1}}`, nil},
+		{"invalid env value", `{"database": {"user": "oden"}}`, map[string]string{"ODEN_SERVER_PORT": "http"}},
+		{"invalid JSON list", `{"database": {"user": "oden"}}`, map[string]string{"ODEN_AUTH_KEYS": "2024-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if _, err := load(t, writeFile(t, "config.json", tt.content)); err == nil {
+				t.Error("Load succeeded")
+			}
+		})
+	}
+}
+
+func TestWatchReloadsGameConfig(t *testing.T) {
+	path := writeFile(t, "config.json", `{"database": {"user": "oden"}, "game": {"max_idle_hours": 12}}`)
+	l := NewLoader(path, nil)
+	cfg, err := l.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	l.Watch(cfg)
+
+	// waitFor waits for the game config to reload to max idle hours
+	waitFor := func(hours int) bool {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if cfg.CurrentGame().MaxIdleHours == hours {
+				return true
+			}
+		}
+		return false
+	}
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"database": {"user": "oden"}, "game": {"max_idle_hours": 6}}`)
+	if !waitFor(6) {
+		t.Fatalf("max idle hours = %d after the change, want 6", cfg.CurrentGame().MaxIdleHours)
+	}
+	if cfg.Game.MaxIdleHours != 12 {
+		t.Errorf("Game.MaxIdleHours = %d, want the value loaded at startup", cfg.Game.MaxIdleHours)
+	}
+
+	// An invalid change is ignored, and a later valid one applied
+	write(`{"database": {"user": "oden"}, "game": {"max_idle_hours": -1}}`)
+	time.Sleep(100 * time.Millisecond)
+	if hours := cfg.CurrentGame().MaxIdleHours; hours != 6 {
+		t.Errorf("max idle hours = %d after an invalid change, want 6 still", hours)
+	}
+	write(`{"database": {"user": "oden"}, "game": {"max_idle_hours": 3}}`)
+	if !waitFor(3) {
+		t.Fatalf("max idle hours = %d after the change, want 3", cfg.CurrentGame().MaxIdleHours)
+	}
+}
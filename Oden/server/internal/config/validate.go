@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Validate checks that every setting has a usable value, and reports all
+// problems at once
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(validPort(c.Server.Port), "server.port must be between 1 and 65535, not %d", c.Server.Port)
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be a positive number of seconds")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be a positive number of seconds")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be a positive number of seconds")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be a positive number of seconds")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be a positive number of seconds")
+	check(c.Server.HealthCheckTimeout > 0, "server.health_check_timeout must be a positive number of seconds")
+	check(c.Server.IdempotencyKeyExpiry > 0, "server.idempotency_key_expiry must be a positive number of hours")
+	check(c.Server.IdempotencyKeyLease > c.Server.WriteTimeout, "server.idempotency_key_lease must be longer than server.write_timeout")
+	check(c.Server.IdempotencyKeyLease < c.Server.IdempotencyKeyExpiry*3600, "server.idempotency_key_lease must be shorter than server.idempotency_key_expiry")
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problems = append(problems, fmt.Sprintf("server.trusted_proxies must hold IP addresses or CIDR ranges, not %q", proxy))
+			}
+		}
+	}
+
+	switch c.Database.Driver {
+	case "mysql":
+		check(c.Database.Host != "", "database.host is required")
+		check(validPort(c.Database.Port), "database.port must be between 1 and 65535, not %d", c.Database.Port)
+		check(c.Database.User != "", "database.user is required")
+		check(c.Database.DBName != "", "database.dbname is required")
+	case "memory":
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver must be mysql or memory, not %q", c.Database.Driver))
+	}
+
+	check(c.Auth.AccessTokenExpiry > 0, "auth.access_token_expiry must be a positive number of minutes")
+	check(c.Auth.RefreshTokenExpiry > 0, "auth.refresh_token_expiry must be a positive number of hours")
+	check(c.Auth.RefreshTokenExpiry*60 > c.Auth.AccessTokenExpiry, "auth.refresh_token_expiry must be longer than auth.access_token_expiry")
+	check(c.Auth.VerifyEmailExpiry > 0, "auth.verify_email_expiry must be a positive number of hours")
+	check(c.Auth.PasswordResetExpiry > 0, "auth.password_reset_expiry must be a positive number of minutes")
+	check(c.Auth.LockoutThreshold > 0, "auth.lockout_threshold must be a positive number of failed logins")
+	check(c.Auth.LockoutDuration > 0, "auth.lockout_duration must be a positive number of minutes")
+	check(c.Auth.MaxLockoutDuration >= c.Auth.LockoutDuration, "auth.max_lockout_duration must be at least auth.lockout_duration")
+	for i, k := range c.Auth.Keys {
+		check(k.ID != "", "auth.keys[%d].id is required", i)
+		check((k.Path == "") != (k.PEM == ""), "auth.keys[%d] needs either a path or a pem", i)
+	}
+
+	// Storage is optional, so the server can run without MinIO or S3
+	if c.Storage.Endpoint != "" {
+		check(c.Storage.Bucket != "", "storage.bucket is required with storage.endpoint")
+	}
+
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		check(c.Mail.Host != "", "mail.host is required by the smtp driver")
+		check(validPort(c.Mail.Port), "mail.port must be between 1 and 65535, not %d", c.Mail.Port)
+	default:
+		problems = append(problems, fmt.Sprintf("mail.driver must be smtp or log, not %q", c.Mail.Driver))
+	}
+	if _, err := netmail.ParseAddress(c.Mail.From); err != nil {
+		problems = append(problems, fmt.Sprintf("mail.from must be an email address: %v", err))
+	}
+	if u, err := url.Parse(c.Mail.LinkBaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("mail.link_base_url must be an http(s) URL, not %q", c.Mail.LinkBaseURL))
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("log.level must be debug, info, warn or error, not %q", c.Log.Level))
+	}
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format must be json or text, not %q", c.Log.Format)
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter must be none, otlp or stdout, not %q", c.Tracing.Exporter))
+	}
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1, not %g", c.Tracing.SampleRatio)
+
+	switch c.RateLimit.Store {
+	case "memory":
+	case "redis":
+		check(c.RateLimit.Redis.Addr != "", "rate_limit.redis.addr is required by the redis store")
+	default:
+		problems = append(problems, fmt.Sprintf("rate_limit.store must be memory or redis, not %q", c.RateLimit.Store))
+	}
+	for name, l := range map[string]RateLimit{"auth": c.RateLimit.Auth, "protected": c.RateLimit.Protected} {
+		check(l.Requests > 0, "rate_limit.%s.requests must be positive", name)
+		check(l.Window > 0, "rate_limit.%s.window must be a positive number of seconds", name)
+	}
+
+	problems = append(problems, c.Game.problems()...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// problems returns what is wrong with the game config
+func (g *GameConfig) problems() []string {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(g.MaxIdleHours > 0, "game.max_idle_hours must be positive")
+	check(g.IdleGoldPerMinute >= 0, "game.idle_gold_per_minute must not be negative")
+	check(g.IdleExpPerMinute >= 0, "game.idle_exp_per_minute must not be negative")
+	check(g.IdleStageBonusPercent >= 0, "game.idle_stage_bonus_percent must not be negative")
+
+	// Empty mission reset settings fall back to the schedule's defaults
+	m := g.Missions
+	if _, err := time.Parse("15:04", m.ResetTime); err != nil && m.ResetTime != "" {
+		problems = append(problems, fmt.Sprintf("game.missions.reset_time must be HH:MM, not %q", m.ResetTime))
+	}
+	if _, err := time.LoadLocation(m.Timezone); err != nil {
+		problems = append(problems, fmt.Sprintf("game.missions.timezone must be an IANA time zone, not %q", m.Timezone))
+	}
+	check(m.WeeklyResetDay == "" || isWeekday(m.WeeklyResetDay), "game.missions.weekly_reset_day must be a day of the week, not %q", m.WeeklyResetDay)
+	check(m.DailyCount >= 0, "game.missions.daily_count must not be negative")
+	check(m.WeeklyCount >= 0, "game.missions.weekly_count must not be negative")
+	return problems
+}
+
+// validPort reports whether port is a TCP port number
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// isWeekday reports whether name is a day of the week, in any case
+func isWeekday(name string) bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) {
+			return true
+		}
+	}
+	return false
+}
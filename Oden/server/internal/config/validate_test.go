@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig returns the defaults plus the settings they leave empty
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.User = "oden"
+	return cfg
+}
+
+func TestValidateAcceptsValidConfig(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	cfg := validConfig()
+	cfg.Database = DatabaseConfig{Driver: "memory"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate of the memory driver without database settings: %v", err)
+	}
+}
+
+func TestValidateRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   string
+	}{
+		{"port", func(c *Config) { c.Server.Port = 70000 }, "server.port"},
+		{"trusted proxy", func(c *Config) { c.Server.TrustedProxies = []string{"proxy"} }, "server.trusted_proxies"},
+		{"database driver", func(c *Config) { c.Database.Driver = "sqlite" }, "database.driver"},
+		{"database user", func(c *Config) { c.Database.User = "" }, "database.user"},
+		{"refresh shorter than access", func(c *Config) { c.Auth.RefreshTokenExpiry = 1; c.Auth.AccessTokenExpiry = 90 }, "auth.refresh_token_expiry"},
+		{"max lockout", func(c *Config) { c.Auth.MaxLockoutDuration = 0 }, "auth.max_lockout_duration"},
+		{"key source", func(c *Config) { c.Auth.Keys = []KeyConfig{{ID: "a", Path: "a.pem", PEM: "pem"}} }, "auth.keys[0]"},
+		{"idempotency key lease", func(c *Config) { c.Server.IdempotencyKeyLease = c.Server.WriteTimeout }, "server.idempotency_key_lease"},
+		{"storage bucket", func(c *Config) { c.Storage.Endpoint = "localhost:9000"; c.Storage.Bucket = "" }, "storage.bucket"},
+		{"smtp host", func(c *Config) { c.Mail.Driver = "smtp" }, "mail.host"},
+		{"mail from", func(c *Config) { c.Mail.From = "nobody" }, "mail.from"},
+		{"link base url", func(c *Config) { c.Mail.LinkBaseURL = "oden-game.com" }, "mail.link_base_url"},
+		{"log level", func(c *Config) { c.Log.Level = "trace" }, "log.level"},
+		{"sample ratio", func(c *Config) { c.Tracing.SampleRatio = 2 }, "tracing.sample_ratio"},
+		{"redis addr", func(c *Config) { c.RateLimit.Store = "redis" }, "rate_limit.redis.addr"},
+		{"rate limit", func(c *Config) { c.RateLimit.Auth.Requests = 0 }, "rate_limit.auth.requests"},
+		{"reset time", func(c *Config) { c.Game.Missions.ResetTime = "25:00" }, "game.missions.reset_time"},
+		{"timezone", func(c *Config) { c.Game.Missions.Timezone = "Mars/Olympus" }, "game.missions.timezone"},
+		{"weekly reset day", func(c *Config) { c.Game.Missions.WeeklyResetDay = "someday" }, "game.missions.weekly_reset_day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want a problem with %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = 0
+	cfg.Log.Format = "xml"
+	cfg.Game.MaxIdleHours = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted an invalid config")
+	}
+	for _, want := range []string{"server.port", "log.format", "game.max_idle_hours"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate = %v, want it to mention %s", err, want)
+		}
+	}
+}
@@ -15,7 +15,9 @@ type DB struct {
 
 // NewDB creates a new database connection
 func NewDB(cfg *config.Config) (*DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+	// clientFoundRows makes UPDATE report matched rather than changed rows,
+	// so rewriting a row with identical values is not mistaken for a miss
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&clientFoundRows=true",
 		cfg.Database.User, cfg.Database.Password,
 		cfg.Database.Host, cfg.Database.Port,
 		cfg.Database.DBName)
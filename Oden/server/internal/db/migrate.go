@@ -0,0 +1,443 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the SQL migrations. NNN_name.sql applies version NNN;
+// the optional NNN_name.down.sql reverts it.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the MySQL named lock that keeps two servers from
+// migrating the same database at the same time
+const migrationLock = "oden_schema_migrations"
+
+// ErrChecksumMismatch is returned when an applied migration no longer matches
+// the embedded file it was applied from
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// ErrUnrecordedSchema is returned by Up when the database already has tables
+// but no recorded migrations, as when the MySQL image created it from the
+// migrations directory. Baseline records what was applied.
+var ErrUnrecordedSchema = errors.New("database has tables but no recorded migrations; record the applied ones with migrate baseline <version>")
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string // Empty if the migration cannot be reverted
+	Checksum string // SHA-256 of Up
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations and records them in the
+// schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations
+func NewMigrator(db *DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db.DB, migrations: migrations}, nil
+}
+
+// loadMigrations reads and orders the migrations in the migrations directory
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, p := range paths {
+		file := path.Base(p)
+		name := strings.TrimSuffix(file, ".sql")
+		down := strings.HasSuffix(name, ".down")
+		name = strings.TrimSuffix(name, ".down")
+
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: file name must start with a version number", file)
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", m.Name, name, version)
+		}
+		if down {
+			m.Down = string(content)
+		} else {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has a down script but no up script", m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// appliedMigration is a row of the schema_migrations table
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+// It fails without changing anything if an applied migration has drifted.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]appliedMigration) error {
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			// Running the first migrations again would fail half way, on
+			// the sample data they insert
+			var tables int
+			if err := conn.QueryRowContext(ctx,
+				"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'users'").
+				Scan(&tables); err != nil {
+				return err
+			}
+			if tables > 0 {
+				return ErrUnrecordedSchema
+			}
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := execScript(ctx, conn, mig.Up); err != nil {
+				return fmt.Errorf("applying migration %s: %w", mig.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+				mig.Version, mig.Name, mig.Checksum, time.Now()); err != nil {
+				return fmt.Errorf("recording migration %s: %w", mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline records the migrations up to and including version as applied
+// without running them, and returns them. It is for databases whose schema was
+// created without the migrator, such as Docker volumes the MySQL image
+// initialized from the migrations directory, and fails if any migration has
+// been recorded already.
+func (m *Migrator) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	var recorded []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]appliedMigration) error {
+		if len(applied) > 0 {
+			return errors.New("migrations have been recorded already; baseline only applies to a database without any")
+		}
+		known := false
+		for _, mig := range m.migrations {
+			if mig.Version == version {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("no migration has version %d", version)
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			if _, err := conn.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+				mig.Version, mig.Name, mig.Checksum, time.Now()); err != nil {
+				return fmt.Errorf("recording migration %s: %w", mig.Name, err)
+			}
+			recorded = append(recorded, mig)
+		}
+		return nil
+	})
+	return recorded, err
+}
+
+// Down reverts the most recently applied migration and returns it, or nil if
+// nothing has been applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]appliedMigration) error {
+		if err := m.verify(applied); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %s has no down script", mig.Name)
+			}
+			if err := execScript(ctx, conn, mig.Down); err != nil {
+				return fmt.Errorf("reverting migration %s: %w", mig.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", mig.Version); err != nil {
+				return fmt.Errorf("unrecording migration %s: %w", mig.Name, err)
+			}
+			reverted = &mig
+			return nil
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every migration with the time it was applied, if it was.
+// Drift is reported as an error alongside the statuses.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]appliedMigration) error {
+		for _, mig := range m.migrations {
+			st := MigrationStatus{Migration: mig}
+			if a, ok := applied[mig.Version]; ok {
+				at := a.appliedAt
+				st.AppliedAt = &at
+			}
+			statuses = append(statuses, st)
+		}
+		return m.verify(applied)
+	})
+	return statuses, err
+}
+
+// Pending returns the migrations that have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, st := range statuses {
+		if st.AppliedAt == nil {
+			pending = append(pending, st.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Missing returns the migrations of this build the database has not applied.
+// Unlike Pending it neither waits for the migration lock nor creates the
+// schema_migrations table, so it is cheap enough for health checks.
+func (m *Migrator) Missing(ctx context.Context) ([]Migration, error) {
+	applied, err := loadApplied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			missing = append(missing, mig)
+		}
+	}
+	return missing, nil
+}
+
+// verify checks that every applied migration still matches its embedded file
+func (m *Migrator) verify(applied map[int]appliedMigration) error {
+	known := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+
+	versions := make([]int, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+
+	for _, v := range versions {
+		a := applied[v]
+		mig, ok := known[v]
+		if !ok {
+			return fmt.Errorf("%w: %s was applied but is not part of this build", ErrChecksumMismatch, a.name)
+		}
+		if mig.Checksum != a.checksum {
+			return fmt.Errorf("%w: %s was changed after it was applied", ErrChecksumMismatch, mig.Name)
+		}
+	}
+	return nil
+}
+
+// locked runs fn on a single connection that holds the migration lock, after
+// making sure the schema_migrations table exists and loading its rows
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int]appliedMigration) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 60)", migrationLock).Scan(&got); err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	if got.Int64 != 1 {
+		return errors.New("timed out waiting for the migration lock")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLock)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	applied, err := loadApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+// loadApplied reads the rows of the schema_migrations table
+func loadApplied(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}) (map[int]appliedMigration, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+// execScript runs each statement of a migration script in turn
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a SQL script on the semicolons that end statements.
+// Semicolons inside quotes and comments are left alone, and statements that
+// are empty once comments are removed are dropped.
+func splitStatements(script string) []string {
+	var stmts []string
+	var cur strings.Builder
+	hasCode := false
+
+	flush := func() {
+		if hasCode {
+			stmts = append(stmts, strings.TrimSpace(cur.String()))
+		}
+		cur.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case ch == '-' && strings.HasPrefix(script[i:], "--"), ch == '#':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+			cur.WriteByte('\n')
+		case ch == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			}
+			i += end + 3
+			cur.WriteByte(' ')
+		case ch == '\'' || ch == '"' || ch == '`':
+			j := i + 1
+			for j < len(script) {
+				if script[j] == '\\' && ch != '`' {
+					j += 2
+					continue
+				}
+				if script[j] == ch {
+					break
+				}
+				j++
+			}
+			if j >= len(script) {
+				j = len(script) - 1
+			}
+			cur.WriteString(script[i : j+1])
+			i = j
+			hasCode = true
+		case ch == ';':
+			flush()
+		default:
+			cur.WriteByte(ch)
+			if ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r' {
+				hasCode = true
+			}
+		}
+	}
+	flush()
+	return stmts
+}
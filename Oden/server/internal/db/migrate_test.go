@@ -0,0 +1,271 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlTestDSNEnv names the environment variable holding the DSN of a MySQL
+// server for the migration tests. They create and drop a database of their
+// own, so the user needs the privilege to. The tests are skipped without it.
+const mysqlTestDSNEnv = "ODEN_TEST_MYSQL_DSN"
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"statements", "CREATE TABLE a (id INT);\nDROP TABLE b;", []string{"CREATE TABLE a (id INT)", "DROP TABLE b"}},
+		{"no final semicolon", "SELECT 1", []string{"SELECT 1"}},
+		{"empty statements", ";\n  ;SELECT 1;;", []string{"SELECT 1"}},
+		{"line comments", "-- a; b\nSELECT 1; # c; d\nSELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{"comment only", "-- nothing to do;\n/* really; */", nil},
+		{"block comment", "SELECT /* ; */ 1;", []string{"SELECT   1"}},
+		{"quoted semicolons", `INSERT INTO a VALUES ('x;y', "p;q");`, []string{`INSERT INTO a VALUES ('x;y', "p;q")`}},
+		{"escaped quotes", `INSERT INTO a VALUES ('it\'s; fine');SELECT 1`, []string{`INSERT INTO a VALUES ('it\'s; fine')`, "SELECT 1"}},
+		{"backticks", "CREATE TABLE `a;b` (id INT);", []string{"CREATE TABLE `a;b` (id INT)"}},
+		{"unterminated quote", "SELECT 'a;", []string{"SELECT 'a;"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/002_second.sql":      {Data: []byte("SELECT 2;")},
+		"migrations/001_first.sql":       {Data: []byte("SELECT 1;")},
+		"migrations/001_first.down.sql":  {Data: []byte("SELECT -1;")},
+		"migrations/010_tenth.sql":       {Data: []byte("SELECT 10;")},
+		"migrations/010_tenth.down.sql":  {Data: []byte("SELECT -10;")},
+		"migrations/not_a_migration.txt": {Data: []byte("ignored")},
+	}
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+
+	var versions []int
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	if !reflect.DeepEqual(versions, []int{1, 2, 10}) {
+		t.Fatalf("versions = %v, want [1 2 10]", versions)
+	}
+	if m := migrations[0]; m.Name != "001_first" || m.Up != "SELECT 1;" || m.Down != "SELECT -1;" {
+		t.Errorf("first migration = %+v", m)
+	}
+	if migrations[1].Down != "" {
+		t.Errorf("migration without a down script has Down %q", migrations[1].Down)
+	}
+	if migrations[0].Checksum == migrations[1].Checksum || len(migrations[0].Checksum) != 64 {
+		t.Errorf("checksums %q and %q, want distinct SHA-256 sums", migrations[0].Checksum, migrations[1].Checksum)
+	}
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"no version", fstest.MapFS{"migrations/first.sql": {}}},
+		{"version zero", fstest.MapFS{"migrations/000_first.sql": {}}},
+		{"shared version", fstest.MapFS{"migrations/001_a.sql": {}, "migrations/001_b.sql": {}}},
+		{"down without up", fstest.MapFS{"migrations/001_a.down.sql": {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrations(tt.fsys); err == nil {
+				t.Error("loadMigrations succeeded")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %s has version %d, want %d: versions must not have gaps", m.Name, m.Version, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %s has no down script", m.Name)
+		}
+	}
+}
+
+func TestVerifyDetectsDrift(t *testing.T) {
+	m := &Migrator{migrations: []Migration{
+		{Version: 1, Name: "001_first", Checksum: "aaa"},
+		{Version: 2, Name: "002_second", Checksum: "bbb"},
+	}}
+
+	tests := []struct {
+		name    string
+		applied map[int]appliedMigration
+		wantErr bool
+	}{
+		{"nothing applied", map[int]appliedMigration{}, false},
+		{"matching", map[int]appliedMigration{1: {name: "001_first", checksum: "aaa"}}, false},
+		{"edited", map[int]appliedMigration{1: {name: "001_first", checksum: "aaa"}, 2: {name: "002_second", checksum: "ccc"}}, true},
+		{"unknown", map[int]appliedMigration{3: {name: "003_third", checksum: "ddd"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.verify(tt.applied)
+			if tt.wantErr != errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("verify = %v, want a checksum mismatch: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testDatabase creates an empty database for the test and returns it
+func testDatabase(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv(mysqlTestDSNEnv)
+	if dsn == "" {
+		t.Skipf("set %s to run the MySQL migration tests", mysqlTestDSNEnv)
+	}
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	cfg.DBName = fmt.Sprintf("oden_migrate_test_%d", time.Now().UnixNano())
+	if _, err := server.Exec("CREATE DATABASE " + cfg.DBName); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Exec("DROP DATABASE " + cfg.DBName) })
+
+	sqlDB, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return &DB{DB: sqlDB}
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewMigrator(testDatabase(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Fatalf("Up applied %d migrations, want %d", len(applied), len(m.migrations))
+	}
+	if again, err := m.Up(ctx); err != nil || len(again) != 0 {
+		t.Fatalf("second Up applied %d migrations with error %v, want none", len(again), err)
+	}
+
+	// Every down script reverts its migration so that it applies again
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		reverted, err := m.Down(ctx)
+		if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+		if reverted == nil || reverted.Version != m.migrations[i].Version {
+			t.Fatalf("Down reverted %v, want %s", reverted, m.migrations[i].Name)
+		}
+	}
+	if reverted, err := m.Down(ctx); err != nil || reverted != nil {
+		t.Fatalf("Down with nothing applied reverted %v with error %v", reverted, err)
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != len(m.migrations) {
+		t.Fatalf("Up after reverting everything applied %d migrations with error %v", len(applied), err)
+	}
+}
+
+func TestMigratorRefusesDrift(t *testing.T) {
+	ctx := context.Background()
+	database := testDatabase(t)
+	m, err := NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if _, err := database.ExecContext(ctx, "UPDATE schema_migrations SET checksum = 'edited' WHERE version = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Up after an applied migration changed: got %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := m.Down(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Down after an applied migration changed: got %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestMigratorBaseline(t *testing.T) {
+	ctx := context.Background()
+	database := testDatabase(t)
+	m, err := NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the schema the way the MySQL image ran the first two
+	// migrations on a new volume, without recording them
+	conn, err := database.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mig := range m.migrations[:2] {
+		if err := execScript(ctx, conn, mig.Up); err != nil {
+			t.Fatalf("running %s: %v", mig.Name, err)
+		}
+	}
+	conn.Close()
+
+	if _, err := m.Up(ctx); !errors.Is(err, ErrUnrecordedSchema) {
+		t.Fatalf("Up on an unrecorded schema: got %v, want ErrUnrecordedSchema", err)
+	}
+	recorded, err := m.Baseline(ctx, 2)
+	if err != nil {
+		t.Fatalf("Baseline: %v", err)
+	}
+	if len(recorded) != 2 {
+		t.Errorf("Baseline recorded %d migrations, want 2", len(recorded))
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up after Baseline: %v", err)
+	}
+	if len(applied) != len(m.migrations)-2 || applied[0].Version != 3 {
+		t.Errorf("Up after Baseline applied %d migrations, want all from version 3", len(applied))
+	}
+
+	if _, err := m.Baseline(ctx, 2); err == nil {
+		t.Error("Baseline succeeded on a database with recorded migrations")
+	}
+}
@@ -0,0 +1,19 @@
+-- Drop every table created by 001_initial_schema.sql, children first
+DROP TABLE IF EXISTS summon_results;
+DROP TABLE IF EXISTS summon_sessions;
+DROP TABLE IF EXISTS banner_featured_items;
+DROP TABLE IF EXISTS banner_featured_heroes;
+DROP TABLE IF EXISTS banners;
+DROP TABLE IF EXISTS missions;
+DROP TABLE IF EXISTS mission_item_rewards;
+DROP TABLE IF EXISTS mission_templates;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS item_templates;
+DROP TABLE IF EXISTS battle_results;
+DROP TABLE IF EXISTS stages;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS heroes;
+DROP TABLE IF EXISTS skills;
+DROP TABLE IF EXISTS hero_types;
+DROP TABLE IF EXISTS player_resources;
+DROP TABLE IF EXISTS users;
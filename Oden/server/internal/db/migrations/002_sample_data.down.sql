@@ -0,0 +1,8 @@
+-- Remove the sample rows added by 002_sample_data.sql
+DELETE FROM banner_featured_heroes WHERE banner_id IN ('banner_001', 'banner_002');
+DELETE FROM banners WHERE id IN ('banner_001', 'banner_002');
+DELETE FROM mission_templates WHERE id IN ('mission_template_001', 'mission_template_002', 'mission_template_003');
+DELETE FROM item_templates WHERE id IN ('item_template_001', 'item_template_002', 'item_template_003', 'item_template_004', 'item_template_005');
+DELETE FROM stages WHERE id IN ('stage_001', 'stage_002', 'stage_003');
+DELETE FROM skills WHERE id IN ('skill_001', 'skill_002', 'skill_003', 'skill_004', 'skill_005');
+DELETE FROM hero_types WHERE id IN ('hero_type_001', 'hero_type_002', 'hero_type_003', 'hero_type_004', 'hero_type_005');
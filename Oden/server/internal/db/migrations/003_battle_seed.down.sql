@@ -0,0 +1 @@
+ALTER TABLE battle_results DROP COLUMN seed;
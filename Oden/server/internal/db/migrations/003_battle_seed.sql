@@ -0,0 +1,2 @@
+-- Store the simulation seed so battles can be replayed
+ALTER TABLE battle_results ADD COLUMN seed BIGINT NOT NULL DEFAULT 0 AFTER rewards_json;
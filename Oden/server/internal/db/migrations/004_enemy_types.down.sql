@@ -0,0 +1,3 @@
+ALTER TABLE stages DROP COLUMN enemy_level;
+DROP TABLE IF EXISTS enemy_skills;
+DROP TABLE IF EXISTS enemy_types;
@@ -0,0 +1,26 @@
+-- Create EnemyTypes table
+CREATE TABLE IF NOT EXISTS enemy_types (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    base_hp INT NOT NULL,
+    base_atk INT NOT NULL,
+    hp_per_level INT NOT NULL DEFAULT 0,
+    atk_per_level INT NOT NULL DEFAULT 0,
+    image_url VARCHAR(255)
+);
+
+-- Create EnemySkills table
+CREATE TABLE IF NOT EXISTS enemy_skills (
+    id VARCHAR(36) PRIMARY KEY,
+    enemy_type_id VARCHAR(36) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    damage_multiplier FLOAT NOT NULL,
+    cooldown INT NOT NULL,
+    targets_all BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (enemy_type_id) REFERENCES enemy_types(id) ON DELETE CASCADE
+);
+
+-- Level of the enemies in each stage
+ALTER TABLE stages ADD COLUMN enemy_level INT NOT NULL DEFAULT 1 AFTER enemy_5;
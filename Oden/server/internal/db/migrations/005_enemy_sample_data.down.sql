@@ -0,0 +1,4 @@
+-- Remove the sample enemies added by 005_enemy_sample_data.sql
+UPDATE stages SET enemy_level = 1 WHERE id IN ('stage_001', 'stage_002', 'stage_003');
+DELETE FROM enemy_skills WHERE id IN ('enemy_skill_001', 'enemy_skill_002', 'enemy_skill_003', 'enemy_skill_004', 'enemy_skill_005', 'enemy_skill_006', 'enemy_skill_007');
+DELETE FROM enemy_types WHERE id IN ('enemy_001', 'enemy_002', 'enemy_003', 'enemy_004', 'enemy_005', 'enemy_006', 'enemy_007', 'enemy_008');
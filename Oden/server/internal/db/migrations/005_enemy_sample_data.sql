@@ -0,0 +1,27 @@
+-- Insert sample enemy types
+INSERT INTO enemy_types (id, name, description, base_hp, base_atk, hp_per_level, atk_per_level, image_url)
+VALUES 
+('enemy_001', 'Forest Slime', 'A sticky slime that lurks on forest paths', 180, 18, 20, 2, 'enemies/forest_slime.png'),
+('enemy_002', 'Wild Wolf', 'A hungry wolf that hunts in packs', 150, 25, 15, 3, 'enemies/wild_wolf.png'),
+('enemy_003', 'Cave Bat', 'A swarm of bats that dives from the ceiling', 200, 30, 20, 3, 'enemies/cave_bat.png'),
+('enemy_004', 'Goblin Scout', 'A sneaky goblin that strikes from the shadows', 260, 32, 25, 3, 'enemies/goblin_scout.png'),
+('enemy_005', 'Cave Troll', 'A slow but brutal troll', 450, 38, 40, 4, 'enemies/cave_troll.png'),
+('enemy_006', 'Mountain Harpy', 'A shrieking harpy that rides the mountain winds', 320, 42, 30, 4, 'enemies/mountain_harpy.png'),
+('enemy_007', 'Stone Golem', 'A golem of living rock', 600, 40, 50, 4, 'enemies/stone_golem.png'),
+('enemy_008', 'Frost Giant', 'A towering giant that guards the pass', 800, 50, 60, 5, 'enemies/frost_giant.png');
+
+-- Insert sample enemy skills
+INSERT INTO enemy_skills (id, enemy_type_id, name, description, damage_multiplier, cooldown, targets_all)
+VALUES 
+('enemy_skill_001', 'enemy_002', 'Bite', 'Deal 130% ATK to a single hero', 1.3, 2, false),
+('enemy_skill_002', 'enemy_003', 'Screech', 'Deal 80% ATK to all heroes', 0.8, 3, true),
+('enemy_skill_003', 'enemy_004', 'Ambush', 'Deal 150% ATK to a single hero', 1.5, 3, false),
+('enemy_skill_004', 'enemy_005', 'Club Smash', 'Deal 180% ATK to a single hero', 1.8, 4, false),
+('enemy_skill_005', 'enemy_006', 'Gale', 'Deal 100% ATK to all heroes', 1.0, 3, true),
+('enemy_skill_006', 'enemy_007', 'Rock Throw', 'Deal 150% ATK to a single hero', 1.5, 3, false),
+('enemy_skill_007', 'enemy_008', 'Avalanche', 'Deal 130% ATK to all heroes', 1.3, 4, true);
+
+-- Set the enemy level of the sample stages
+UPDATE stages SET enemy_level = 1 WHERE id = 'stage_001';
+UPDATE stages SET enemy_level = 3 WHERE id = 'stage_002';
+UPDATE stages SET enemy_level = 5 WHERE id = 'stage_003';
@@ -0,0 +1,3 @@
+ALTER TABLE player_resources
+    DROP COLUMN special_tickets,
+    DROP COLUMN summon_tickets;
@@ -0,0 +1,4 @@
+-- Ticket balances for banners that are not paid with gems
+ALTER TABLE player_resources
+    ADD COLUMN summon_tickets INT NOT NULL DEFAULT 0 AFTER premium_currency,
+    ADD COLUMN special_tickets INT NOT NULL DEFAULT 0 AFTER summon_tickets;
@@ -0,0 +1 @@
+DROP INDEX idx_missions_expires_at ON missions;
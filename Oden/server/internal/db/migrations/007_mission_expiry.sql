@@ -0,0 +1,2 @@
+-- Speed up the mission scheduler's expiry sweep
+CREATE INDEX idx_missions_expires_at ON missions (expires_at);
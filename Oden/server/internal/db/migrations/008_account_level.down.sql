@@ -0,0 +1,3 @@
+ALTER TABLE player_resources
+    DROP COLUMN account_experience,
+    DROP COLUMN account_level;
@@ -0,0 +1,4 @@
+-- Account level, raised by mission experience that is not given to a hero
+ALTER TABLE player_resources
+    ADD COLUMN account_level INT NOT NULL DEFAULT 1 AFTER special_tickets,
+    ADD COLUMN account_experience INT NOT NULL DEFAULT 0 AFTER account_level;
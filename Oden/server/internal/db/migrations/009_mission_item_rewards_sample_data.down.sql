@@ -0,0 +1,2 @@
+-- Remove the sample item rewards added by 009_mission_item_rewards_sample_data.sql
+DELETE FROM mission_item_rewards WHERE mission_template_id = 'mission_template_003';
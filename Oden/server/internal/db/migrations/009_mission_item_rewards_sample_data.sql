@@ -0,0 +1,4 @@
+-- Item rewards for the sample weekly mission
+INSERT INTO mission_item_rewards (mission_template_id, item_template_id, quantity) VALUES
+('mission_template_003', 'item_template_002', 1),
+('mission_template_003', 'item_template_005', 5);
@@ -0,0 +1,10 @@
+-- Guests cannot exist without credentials, so they are deleted with
+-- everything they own
+DELETE FROM users WHERE password_hash IS NULL;
+
+ALTER TABLE users
+    DROP INDEX idx_users_device_id,
+    DROP COLUMN device_id,
+    MODIFY username VARCHAR(50) NOT NULL,
+    MODIFY email VARCHAR(100) NOT NULL,
+    MODIFY password_hash VARCHAR(255) NOT NULL;
@@ -0,0 +1,8 @@
+-- Guest accounts have no credentials until they are upgraded; they log in
+-- with the ID of their device instead. NULLs do not collide in unique keys.
+ALTER TABLE users
+    MODIFY username VARCHAR(50) NULL,
+    MODIFY email VARCHAR(100) NULL,
+    MODIFY password_hash VARCHAR(255) NULL,
+    ADD COLUMN device_id VARCHAR(128) NULL AFTER password_hash,
+    ADD UNIQUE INDEX idx_users_device_id (device_id);
@@ -0,0 +1,3 @@
+-- When the user proved they own their email address
+ALTER TABLE users
+    ADD COLUMN email_verified_at TIMESTAMP NULL AFTER email;
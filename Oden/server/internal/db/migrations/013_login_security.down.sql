@@ -0,0 +1,11 @@
+ALTER TABLE sessions
+    DROP INDEX idx_sessions_user_id_created_at,
+    DROP COLUMN new_country,
+    DROP COLUMN new_device,
+    DROP COLUMN country,
+    DROP COLUMN user_agent,
+    DROP COLUMN ip;
+
+ALTER TABLE users
+    DROP COLUMN locked_until,
+    DROP COLUMN failed_logins;
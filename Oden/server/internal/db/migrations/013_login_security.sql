@@ -0,0 +1,15 @@
+-- Failed password logins since the last successful one, and until when
+-- password logins are refused after too many of them
+ALTER TABLE users
+    ADD COLUMN failed_logins INT NOT NULL DEFAULT 0 AFTER last_login,
+    ADD COLUMN locked_until TIMESTAMP NULL AFTER failed_logins;
+
+-- Where each session was started from, and whether that was unfamiliar for
+-- the user. The country is only known behind a proxy that reports it.
+ALTER TABLE sessions
+    ADD COLUMN ip VARCHAR(45) NOT NULL DEFAULT '' AFTER user_id,
+    ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '' AFTER ip,
+    ADD COLUMN country CHAR(2) NOT NULL DEFAULT '' AFTER user_agent,
+    ADD COLUMN new_device BOOLEAN NOT NULL DEFAULT FALSE AFTER country,
+    ADD COLUMN new_country BOOLEAN NOT NULL DEFAULT FALSE AFTER new_device,
+    ADD INDEX idx_sessions_user_id_created_at (user_id, created_at);
@@ -0,0 +1,16 @@
+-- Requests sent with an Idempotency-Key header and the responses to them,
+-- which are replayed when the client retries with the same key. status_code
+-- stays 0 while the first request is still running.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id VARCHAR(36) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    body MEDIUMBLOB NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
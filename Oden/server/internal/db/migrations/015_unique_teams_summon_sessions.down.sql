@@ -0,0 +1,8 @@
+-- The foreign keys on user_id need an index once the unique ones are gone
+ALTER TABLE summon_sessions
+    ADD INDEX idx_summon_sessions_user_id (user_id),
+    DROP INDEX idx_summon_sessions_user_id_banner_id;
+
+ALTER TABLE teams
+    ADD INDEX idx_teams_user_id_fk (user_id),
+    DROP INDEX idx_teams_user_id;
@@ -0,0 +1,16 @@
+-- A user has one team and one summon session per banner. Concurrent first
+-- saves could insert more than one, so keep the oldest, which is the one that
+-- was read until now, and let the unique keys prevent new duplicates.
+DELETE t FROM teams t
+    JOIN teams older ON older.user_id = t.user_id
+        AND (older.created_at < t.created_at OR (older.created_at = t.created_at AND older.id < t.id));
+
+ALTER TABLE teams
+    ADD UNIQUE INDEX idx_teams_user_id (user_id);
+
+DELETE ss FROM summon_sessions ss
+    JOIN summon_sessions older ON older.user_id = ss.user_id AND older.banner_id = ss.banner_id
+        AND (older.created_at < ss.created_at OR (older.created_at = ss.created_at AND older.id < ss.id));
+
+ALTER TABLE summon_sessions
+    ADD UNIQUE INDEX idx_summon_sessions_user_id_banner_id (user_id, banner_id);
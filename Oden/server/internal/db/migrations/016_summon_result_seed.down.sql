@@ -0,0 +1 @@
+ALTER TABLE summon_results DROP COLUMN seed;
@@ -0,0 +1,2 @@
+-- Store the seed each summon's pulls were rolled with, so they can be audited
+ALTER TABLE summon_results ADD COLUMN seed BIGINT NOT NULL DEFAULT 0 AFTER pull_number;
@@ -0,0 +1,155 @@
+// Package events is an in-process bus for gameplay events.
+//
+// Events are published synchronously from inside the transaction of the action
+// that caused them, and every handler runs in that transaction. A handler error
+// rolls the action back, so side effects such as mission progress are recorded
+// exactly when the action itself is. Handlers with effects outside the store,
+// such as metrics, defer them with tx.AfterCommit.
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// Event is something a user did in the game
+type Event interface {
+	// User returns the ID of the user who caused the event
+	User() string
+}
+
+// BattleCompleted is published when a user finishes a battle, won or lost
+type BattleCompleted struct {
+	UserID  string
+	StageID string
+	Victory bool
+	// KilledEnemyTypes holds the enemy type ID of every enemy defeated
+	KilledEnemyTypes []string
+}
+
+// HeroLeveledUp is published when a hero gains one or more levels
+type HeroLeveledUp struct {
+	UserID     string
+	HeroID     string
+	HeroTypeID string
+	Levels     int // levels gained
+	Level      int // the level reached
+}
+
+// HeroesAcquired is published when a user gains new heroes
+type HeroesAcquired struct {
+	UserID      string
+	HeroTypeIDs []string
+}
+
+// HeroesSummoned is published when a user summons heroes, with the rarity of
+// every hero summoned. BannerID is empty for summons outside banners.
+type HeroesSummoned struct {
+	UserID   string
+	BannerID string
+	Rarities []string
+}
+
+// ItemsCollected is published when a user gains items
+type ItemsCollected struct {
+	UserID         string
+	ItemTemplateID string
+	Quantity       int
+}
+
+// ItemEquipped is published when a user equips an item to a hero
+type ItemEquipped struct {
+	UserID         string
+	ItemTemplateID string
+	HeroID         string
+}
+
+// GoldSpent is published when a user pays with gold
+type GoldSpent struct {
+	UserID string
+	Amount int
+}
+
+// GemsSpent is published when a user pays with premium currency
+type GemsSpent struct {
+	UserID string
+	Amount int
+}
+
+// IdleRewardsClaimed is published when a user claims their idle rewards
+type IdleRewardsClaimed struct {
+	UserID string
+	Gold   int
+}
+
+func (e BattleCompleted) User() string    { return e.UserID }
+func (e HeroLeveledUp) User() string      { return e.UserID }
+func (e HeroesAcquired) User() string     { return e.UserID }
+func (e HeroesSummoned) User() string     { return e.UserID }
+func (e ItemsCollected) User() string     { return e.UserID }
+func (e ItemEquipped) User() string       { return e.UserID }
+func (e GoldSpent) User() string          { return e.UserID }
+func (e GemsSpent) User() string          { return e.UserID }
+func (e IdleRewardsClaimed) User() string { return e.UserID }
+
+// LevelUp returns the HeroLeveledUp event for a hero that was at fromLevel
+// before gaining experience, or nil if it did not level up
+func LevelUp(hero *model.Hero, fromLevel int) []Event {
+	if hero.Level <= fromLevel {
+		return nil
+	}
+	return []Event{HeroLeveledUp{
+		UserID:     hero.UserID,
+		HeroID:     hero.ID,
+		HeroTypeID: hero.HeroTypeID,
+		Levels:     hero.Level - fromLevel,
+		Level:      hero.Level,
+	}}
+}
+
+// Handler reacts to an event inside the transaction that published it
+type Handler func(ctx context.Context, tx store.Store, e Event) error
+
+// Bus delivers published events to its subscribers. It is safe for concurrent
+// use, and a nil *Bus discards every event.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates a bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for every event published on the bus
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish passes the events, in order, to every subscriber in the order they
+// subscribed. tx must be the transaction of the action that caused the events.
+// The first handler error is returned and no further handlers are called.
+func (b *Bus) Publish(ctx context.Context, tx store.Store, events ...Event) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, e := range events {
+		for _, h := range handlers {
+			if err := h(ctx, tx, e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+func TestPublishDeliversInOrder(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+	var got []string
+	for _, name := range []string{"first", "second"} {
+		name := name
+		bus.Subscribe(func(ctx context.Context, tx store.Store, e Event) error {
+			got = append(got, fmt.Sprintf("%s:%T", name, e))
+			return nil
+		})
+	}
+
+	err := bus.Publish(ctx, nil, GoldSpent{UserID: "user", Amount: 1}, GemsSpent{UserID: "user", Amount: 1})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	want := []string{"first:events.GoldSpent", "second:events.GoldSpent", "first:events.GemsSpent", "second:events.GemsSpent"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("handlers saw %v, want %v", got, want)
+	}
+}
+
+func TestPublishStopsAtFirstError(t *testing.T) {
+	ctx := context.Background()
+	bus := NewBus()
+	failure := errors.New("failure")
+	calls := 0
+	bus.Subscribe(func(ctx context.Context, tx store.Store, e Event) error {
+		calls++
+		return failure
+	})
+	bus.Subscribe(func(ctx context.Context, tx store.Store, e Event) error {
+		calls++
+		return nil
+	})
+
+	err := bus.Publish(ctx, nil, GoldSpent{UserID: "user"}, GoldSpent{UserID: "user"})
+	if !errors.Is(err, failure) {
+		t.Errorf("Publish returned %v, want the handler error", err)
+	}
+	if calls != 1 {
+		t.Errorf("handlers called %d times, want 1", calls)
+	}
+}
+
+func TestPublishOnNilBus(t *testing.T) {
+	var bus *Bus
+	if err := bus.Publish(context.Background(), nil, GoldSpent{UserID: "user"}); err != nil {
+		t.Errorf("Publish on a nil bus: %v", err)
+	}
+}
+
+func TestLevelUp(t *testing.T) {
+	hero := model.NewHero("hero", "user", "hero_type_001")
+	if events := LevelUp(hero, hero.Level); events != nil {
+		t.Errorf("LevelUp without a new level = %v, want nil", events)
+	}
+
+	hero.AddExperience(250)
+	want := []Event{HeroLeveledUp{UserID: "user", HeroID: "hero", HeroTypeID: "hero_type_001", Levels: 2, Level: 3}}
+	if got := LevelUp(hero, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("LevelUp = %+v, want %+v", got, want)
+	}
+
+	// Experience past the max level does not raise the level further
+	hero.AddExperience(1000000)
+	got := LevelUp(hero, 3)
+	if len(got) != 1 || got[0].(HeroLeveledUp).Level != model.MaxHeroLevel {
+		t.Errorf("LevelUp past the max level = %+v, want level %d", got, model.MaxHeroLevel)
+	}
+}
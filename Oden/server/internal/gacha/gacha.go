@@ -0,0 +1,397 @@
+// Package gacha implements banner summons: rarity rolls, hard pity, the
+// featured-hero guarantee and the daily free summon.
+package gacha
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// Rarity of the heroes that pity and the featured guarantee apply to
+const legendary = "legendary"
+
+// Errors returned to clients. They are model.CustomError so the API layer can
+// report them as they are.
+var (
+	ErrBannerNotFound        = model.CustomError{Message: "Banner not found", Code: "resource_not_found"}
+	ErrInsufficientResources = model.CustomError{Message: "Not enough resources", Code: "insufficient_resources"}
+	ErrFreeSummonUnavailable = model.CustomError{Message: "The free summon is not available", Code: "free_summon_unavailable"}
+	ErrInvalidCount          = model.CustomError{Message: "Summon count must be 1 or 10", Code: "invalid_request"}
+	ErrEmptyPool             = model.CustomError{Message: "No heroes available to summon", Code: "resource_not_found"}
+	ErrItemBanner            = model.CustomError{Message: "Banners with item rewards are not supported", Code: "invalid_request"}
+)
+
+// RarityWeight is the relative chance of rolling a rarity
+type RarityWeight struct {
+	Rarity string
+	Weight int
+}
+
+// nonLegendaryWeights are the odds of the other rarities once a pull has not
+// rolled a legendary. The legendary rate comes from the banner itself.
+var nonLegendaryWeights = []RarityWeight{{"common", 50}, {"rare", 38}, {"epic", 12}}
+
+// rarities lists hero rarities from lowest to highest
+var rarities = []string{"common", "rare", "epic", legendary}
+
+// Rand is the source of randomness for rolls. It is implemented by
+// *rand.Rand, and by any generator that is safe for concurrent use.
+type Rand interface {
+	Intn(n int) int
+}
+
+// Days tells when each day starts for the daily free summon. It is
+// implemented by *mission.Service, so the free summon resets along with the
+// daily missions.
+type Days interface {
+	Daily(t time.Time) (start, end time.Time)
+}
+
+// Service performs summons. It is safe for concurrent use.
+type Service struct {
+	store  store.Store
+	events *events.Bus
+	days   Days
+
+	mu  sync.Mutex
+	rng *rand.Rand // draws the seed of each summon
+}
+
+// NewService creates a gacha service. Each summon rolls its pulls from a
+// generator of its own, whose seed is drawn from a generator seeded with seed
+// and stored with the summon's results, so the pulls can be audited. The same
+// seed and the same sequence of summons give the same results, which lets
+// tests check the rates statistically. Summons publish their gem spending and
+// new heroes on bus, and the free summon is available once in each day of
+// days.
+func NewService(st store.Store, bus *events.Bus, days Days, seed int64) *Service {
+	return &Service{
+		store:  st,
+		events: bus,
+		days:   days,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Summon performs count pulls on a banner for the user. A free summon is a
+// single pull that uses the banner's daily free summon instead of the cost.
+// The charge, the heroes, the results and the updated pity state are all
+// written in one transaction.
+func (s *Service) Summon(ctx context.Context, userID, bannerID string, count int, free bool) (*model.SummonMultiResult, error) {
+	if count != 1 && count != 10 {
+		return nil, ErrInvalidCount
+	}
+	if free && count != 1 {
+		return nil, ErrFreeSummonUnavailable
+	}
+
+	var result *model.SummonMultiResult
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		banner, err := tx.Banners().Get(ctx, bannerID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && !banner.IsActive()) {
+			return ErrBannerNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := CheckBanner(banner); err != nil {
+			return err
+		}
+
+		types, err := tx.HeroTypes().List(ctx)
+		if err != nil {
+			return err
+		}
+		pool := heroPool(banner, types)
+		if len(pool.standard) == 0 && len(pool.featured) == 0 {
+			return ErrEmptyPool
+		}
+
+		// The resources row is read first so concurrent summons by the same
+		// user are serialized before the session is read or created
+		resources, err := tx.Resources().Get(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		session, err := tx.Summons().GetSession(ctx, userID, banner.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			session = model.NewSummonSession(model.NewID("summon"), userID, banner.ID)
+		} else if err != nil {
+			return err
+		}
+
+		var published []events.Event
+		if free {
+			today, _ := s.days.Daily(time.Now())
+			if !banner.HasDailyFreeSummon || !session.CanClaimFreeSummon(today) {
+				return ErrFreeSummonUnavailable
+			}
+			session.UpdateFreeSummon()
+		} else {
+			gems := resources.PremiumCurrency
+			if err := charge(resources, banner, count); err != nil {
+				return err
+			}
+			if err := tx.Resources().Update(ctx, resources); err != nil {
+				return err
+			}
+			if spent := gems - resources.PremiumCurrency; spent > 0 {
+				published = append(published, events.GemsSpent{UserID: userID, Amount: spent})
+			}
+		}
+
+		result = &model.SummonMultiResult{
+			BannerID:   banner.ID,
+			BannerName: banner.Name,
+			Results:    make([]*model.SummonResult, 0, count),
+			NewHeroes:  make([]*model.HeroWithDetails, 0, count),
+		}
+		acquired := events.HeroesAcquired{UserID: userID}
+		summoned := events.HeroesSummoned{UserID: userID, BannerID: banner.ID}
+		pulls, seed := s.rollPulls(banner, session, pool, count)
+		for _, p := range pulls {
+			hero := model.NewHero(model.NewID("hero"), userID, p.heroType.ID)
+			if err := tx.Heroes().Create(ctx, hero); err != nil {
+				return err
+			}
+
+			sr := model.NewSummonResult(model.NewID("pull"), userID, banner.ID, "hero", p.heroType.ID,
+				p.heroType.Rarity, p.featured, p.pityBreak, p.pullNumber)
+			sr.Seed = seed
+			if err := tx.Summons().CreateResult(ctx, sr); err != nil {
+				return err
+			}
+
+			hero.HeroType = p.heroType
+			hero.Skills = p.heroType.Skills
+			hero.CalculateStats()
+			result.Results = append(result.Results, sr)
+			result.NewHeroes = append(result.NewHeroes, hero.ToHeroWithDetails())
+			acquired.HeroTypeIDs = append(acquired.HeroTypeIDs, p.heroType.ID)
+			summoned.Rarities = append(summoned.Rarities, p.heroType.Rarity)
+		}
+
+		if err := tx.Summons().SaveSession(ctx, session); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, tx, append(published, acquired, summoned)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Logged so a summon a player reports missing can be traced
+	summonIDs := make([]string, 0, len(result.Results))
+	for _, r := range result.Results {
+		summonIDs = append(summonIDs, r.ID)
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"banner_id":  bannerID,
+		"count":      count,
+		"free":       free,
+		"summon_ids": summonIDs,
+	}).Info("Summoned on banner")
+	return result, nil
+}
+
+// CheckBanner returns ErrItemBanner if the banner has item rewards. Summons
+// only roll heroes, so such a banner is rejected rather than summoned on as if
+// its items did not exist.
+func CheckBanner(banner *model.Banner) error {
+	if len(banner.FeaturedItems) > 0 || len(banner.ItemPool) > 0 {
+		return ErrItemBanner
+	}
+	return nil
+}
+
+// charge takes the cost of count pulls from the balance the banner is paid with
+func charge(resources *model.PlayerResources, banner *model.Banner, count int) error {
+	cost := banner.SingleSummonCost
+	if count == 10 {
+		cost = banner.TenSummonCost
+	}
+
+	var balance *int
+	switch banner.CostType {
+	case model.SummonCostGem, "":
+		balance = &resources.PremiumCurrency
+	case model.SummonCostSummonTicket:
+		balance = &resources.SummonTickets
+	case model.SummonCostSpecialTicket:
+		balance = &resources.SpecialTickets
+	default:
+		return model.CustomError{Message: "Unsupported summon cost type", Code: "invalid_request"}
+	}
+
+	if *balance < cost {
+		return ErrInsufficientResources
+	}
+	*balance -= cost
+	return nil
+}
+
+// pool is the set of hero types a banner can give
+type pool struct {
+	featured []*model.HeroType
+	standard []*model.HeroType
+}
+
+// heroPool splits the banner's hero types into featured and standard heroes.
+// A banner with a HeroPool only gives the heroes listed in it.
+func heroPool(banner *model.Banner, types []*model.HeroType) pool {
+	featured := make(map[string]bool, len(banner.FeaturedHeroes))
+	for _, id := range banner.FeaturedHeroes {
+		featured[id] = true
+	}
+	allowed := make(map[string]bool, len(banner.HeroPool))
+	for _, id := range banner.HeroPool {
+		allowed[id] = true
+	}
+
+	var p pool
+	for _, ht := range types {
+		switch {
+		case featured[ht.ID]:
+			p.featured = append(p.featured, ht)
+		case len(allowed) == 0 || allowed[ht.ID]:
+			p.standard = append(p.standard, ht)
+		}
+	}
+	return p
+}
+
+// pull is the outcome of one roll
+type pull struct {
+	heroType   *model.HeroType
+	featured   bool
+	pityBreak  bool
+	pullNumber int
+}
+
+// rollPulls rolls count pulls from a generator seeded for the summon and
+// advances the session's pity state. It returns the pulls and the seed, which
+// with the session as it was before reproduces them.
+func (s *Service) rollPulls(banner *model.Banner, session *model.SummonSession, p pool, count int) ([]pull, int64) {
+	s.mu.Lock()
+	seed := s.rng.Int63()
+	s.mu.Unlock()
+
+	rng := rand.New(rand.NewSource(seed))
+	pulls := make([]pull, 0, count)
+	for i := 0; i < count; i++ {
+		pulls = append(pulls, roll(banner, session, p, rng))
+	}
+	return pulls, seed
+}
+
+// roll performs a single pull.
+//
+// A pull is legendary with probability StandardHeroRate + FeaturedHeroRate, or
+// always once GuaranteeThreshold pulls have passed without one (hard pity). A
+// legendary is featured with probability FeaturedHeroRate over the legendary
+// rate, which is 50/50 when both rates are equal. Losing that roll makes the
+// next legendary featured for certain.
+//
+// Pity and the guarantee only change when a legendary is actually awarded, so
+// a pool without legendaries, or without featured ones, does not use them up.
+func roll(banner *model.Banner, session *model.SummonSession, p pool, rng *rand.Rand) pull {
+	session.IncrementPullCount()
+	res := pull{pullNumber: session.PullCount}
+
+	legendaryRate := banner.StandardHeroRate + banner.FeaturedHeroRate
+	sinceLegendary := session.PullCount - session.LastLegendaryAt
+	pity := banner.GuaranteeThreshold > 0 && sinceLegendary >= banner.GuaranteeThreshold
+	featuredLegendary := hasRarity(p.featured, legendary)
+
+	switch {
+	case !pity && rng.Float64() >= legendaryRate:
+		res.heroType = PickHeroType(p.standard, RollRarity(nonLegendaryWeights, rng), rng)
+	case featuredLegendary && (session.HasGuarantee || !hasRarity(p.standard, legendary) ||
+		(legendaryRate > 0 && rng.Float64()*legendaryRate < banner.FeaturedHeroRate)):
+		res.heroType = PickHeroType(p.featured, legendary, rng)
+		res.featured = true
+	default:
+		res.heroType = PickHeroType(p.standard, legendary, rng)
+	}
+	if res.heroType == nil {
+		// The banner only has featured heroes
+		res.heroType = p.featured[rng.Intn(len(p.featured))]
+		res.featured = true
+	}
+
+	if res.heroType.Rarity == legendary {
+		res.pityBreak = pity
+		session.LastLegendaryAt = session.PullCount
+		// A standard legendary when a featured one could have been awarded
+		// loses the 50/50
+		session.HasGuarantee = !res.featured && featuredLegendary
+	}
+	return res
+}
+
+// RollRarity picks a rarity according to the given weights
+func RollRarity(weights []RarityWeight, rng Rand) string {
+	total := 0
+	for _, w := range weights {
+		total += w.Weight
+	}
+
+	n := rng.Intn(total)
+	for _, w := range weights {
+		if n < w.Weight {
+			return w.Rarity
+		}
+		n -= w.Weight
+	}
+	return weights[len(weights)-1].Rarity
+}
+
+// hasRarity reports whether any of types has the rarity
+func hasRarity(types []*model.HeroType, rarity string) bool {
+	for _, ht := range types {
+		if ht.Rarity == rarity {
+			return true
+		}
+	}
+	return false
+}
+
+// PickHeroType picks a random hero type of the given rarity. If there is none,
+// the next lower rarity is tried, and finally any hero type. It returns nil
+// only if types is empty.
+func PickHeroType(types []*model.HeroType, rarity string, rng Rand) *model.HeroType {
+	start := len(rarities) - 1
+	for i, r := range rarities {
+		if r == rarity {
+			start = i
+			break
+		}
+	}
+
+	for i := start; i >= 0; i-- {
+		var candidates []*model.HeroType
+		for _, ht := range types {
+			if ht.Rarity == rarities[i] {
+				candidates = append(candidates, ht)
+			}
+		}
+		if len(candidates) > 0 {
+			return candidates[rng.Intn(len(candidates))]
+		}
+	}
+
+	if len(types) == 0 {
+		return nil
+	}
+	return types[rng.Intn(len(types))]
+}
@@ -0,0 +1,288 @@
+package gacha
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// testPool has a standard hero of every rarity and a featured legendary
+func testPool() pool {
+	return pool{
+		standard: []*model.HeroType{
+			{ID: "common", Rarity: "common"},
+			{ID: "rare", Rarity: "rare"},
+			{ID: "epic", Rarity: "epic"},
+			{ID: "legendary", Rarity: legendary},
+		},
+		featured: []*model.HeroType{{ID: "featured", Rarity: legendary}},
+	}
+}
+
+// pullsFrom rolls n pulls on the banner for a new session
+func pullsFrom(banner *model.Banner, p pool, n int, seed int64) ([]pull, *model.SummonSession) {
+	session := model.NewSummonSession("session", "user", banner.ID)
+	rng := rand.New(rand.NewSource(seed))
+	pulls := make([]pull, 0, n)
+	for i := 0; i < n; i++ {
+		pulls = append(pulls, roll(banner, session, p, rng))
+	}
+	return pulls, session
+}
+
+func TestRollRates(t *testing.T) {
+	const n = 200000
+	banner := &model.Banner{ID: "banner", StandardHeroRate: 0.03, FeaturedHeroRate: 0.03}
+	pulls, _ := pullsFrom(banner, testPool(), n, 1)
+
+	counts := make(map[string]int)
+	featured := 0
+	for _, p := range pulls {
+		counts[p.heroType.Rarity]++
+		if p.featured {
+			featured++
+		}
+	}
+
+	// Within 5% of the expected count, which is over ten standard
+	// deviations for the rarest outcome
+	expect := func(what string, got int, rate float64) {
+		t.Helper()
+		want := rate * n
+		if math.Abs(float64(got)-want) > want*0.05 {
+			t.Errorf("%s: %d of %d pulls, want about %.0f", what, got, n, want)
+		}
+	}
+	expect("legendary", counts[legendary], 0.06)
+	expect("common", counts["common"], 0.94*0.50)
+	expect("rare", counts["rare"], 0.94*0.38)
+	expect("epic", counts["epic"], 0.94*0.12)
+
+	// Half of the legendaries win the 50/50 and the other half make the
+	// next one featured, so two thirds are featured
+	share := float64(featured) / float64(counts[legendary])
+	if math.Abs(share-2.0/3) > 0.03 {
+		t.Errorf("%.3f of legendaries featured, want about 0.667", share)
+	}
+}
+
+func TestRollPityAtThreshold(t *testing.T) {
+	// No legendary can be rolled, so only pity awards them
+	banner := &model.Banner{ID: "banner", GuaranteeThreshold: 10}
+	pulls, session := pullsFrom(banner, testPool(), 25, 1)
+
+	for _, p := range pulls {
+		wantPity := p.pullNumber == 10 || p.pullNumber == 20
+		if p.pityBreak != wantPity || (p.heroType.Rarity == legendary) != wantPity {
+			t.Errorf("pull %d: %s with pity %v, want a legendary by pity %v", p.pullNumber, p.heroType.Rarity, p.pityBreak, wantPity)
+		}
+	}
+	if session.LastLegendaryAt != 20 {
+		t.Errorf("last legendary at pull %d, want 20", session.LastLegendaryAt)
+	}
+}
+
+func TestRollLostFiftyFiftyGuaranteesFeatured(t *testing.T) {
+	// Every pull is legendary and none wins the 50/50
+	banner := &model.Banner{ID: "banner", StandardHeroRate: 1}
+	pulls, session := pullsFrom(banner, testPool(), 4, 1)
+
+	for _, p := range pulls {
+		wantFeatured := p.pullNumber%2 == 0
+		if p.heroType.Rarity != legendary || p.featured != wantFeatured {
+			t.Errorf("pull %d: %s, featured %v; want a legendary, featured %v", p.pullNumber, p.heroType.ID, p.featured, wantFeatured)
+		}
+	}
+	if session.HasGuarantee {
+		t.Error("guarantee still set after it was used")
+	}
+}
+
+func TestRollKeepsPityWithoutLegendaries(t *testing.T) {
+	banner := &model.Banner{ID: "banner", StandardHeroRate: 0.5, FeaturedHeroRate: 0.5, GuaranteeThreshold: 5}
+	p := pool{
+		standard: []*model.HeroType{{ID: "rare", Rarity: "rare"}},
+		featured: []*model.HeroType{{ID: "epic", Rarity: "epic"}},
+	}
+	pulls, session := pullsFrom(banner, p, 20, 1)
+
+	for _, pl := range pulls {
+		if pl.pityBreak {
+			t.Errorf("pull %d counted as a pity break without a legendary", pl.pullNumber)
+		}
+	}
+	if session.LastLegendaryAt != 0 || session.HasGuarantee {
+		t.Errorf("last legendary at %d with guarantee %v, want pity and guarantee untouched", session.LastLegendaryAt, session.HasGuarantee)
+	}
+}
+
+// fixedDays is a day schedule whose current day started at start
+type fixedDays struct{ start time.Time }
+
+func (d fixedDays) Daily(t time.Time) (start, end time.Time) {
+	return d.start, d.start.AddDate(0, 0, 1)
+}
+
+// newTestService returns a service over the sample data with a player who
+// has gems to summon with
+func newTestService(t *testing.T, days Days) (*Service, store.Store, string) {
+	t.Helper()
+	ctx := context.Background()
+	st := store.NewMemory()
+	st.LoadSampleData()
+	user := model.NewUser("user", "player", "player@example.com", "hash")
+	if err := st.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Resources().Create(ctx, model.NewPlayerResources(user.ID, 0, 10000)); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(st, events.NewBus(), days, 1), st, user.ID
+}
+
+func TestSummonStoresSeed(t *testing.T) {
+	ctx := context.Background()
+	s, st, userID := newTestService(t, fixedDays{time.Now().Add(-time.Hour)})
+
+	res, err := s.Summon(ctx, userID, "banner_001", 10, false)
+	if err != nil {
+		t.Fatalf("Summon: %v", err)
+	}
+	seed := res.Results[0].Seed
+	stored, err := st.Summons().ListResults(ctx, userID, "banner_001", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range stored {
+		if r.Seed != seed {
+			t.Errorf("pull %d stored with seed %d, want %d", r.PullNumber, r.Seed, seed)
+		}
+	}
+
+	// The seed and the session before the summon reproduce the pulls
+	banner, err := st.Banners().Get(ctx, "banner_001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := st.HeroTypes().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := model.NewSummonSession("session", userID, banner.ID)
+	rng := rand.New(rand.NewSource(seed))
+	for _, r := range res.Results {
+		p := roll(banner, session, heroPool(banner, types), rng)
+		if p.heroType.ID != r.ResultID || p.pullNumber != r.PullNumber {
+			t.Errorf("replayed pull %d gave %s, want %s", p.pullNumber, p.heroType.ID, r.ResultID)
+		}
+	}
+}
+
+func TestFreeSummonOncePerDay(t *testing.T) {
+	ctx := context.Background()
+	days := &fixedDays{time.Now().Add(-time.Hour)}
+	s, _, userID := newTestService(t, days)
+
+	if _, err := s.Summon(ctx, userID, "banner_001", 1, true); err != nil {
+		t.Fatalf("first free summon: %v", err)
+	}
+	if _, err := s.Summon(ctx, userID, "banner_001", 1, true); !errors.Is(err, ErrFreeSummonUnavailable) {
+		t.Errorf("second free summon the same day: got %v, want ErrFreeSummonUnavailable", err)
+	}
+
+	// The next day starts at the configured reset, not at midnight UTC
+	days.start = time.Now()
+	if _, err := s.Summon(ctx, userID, "banner_001", 1, true); err != nil {
+		t.Errorf("free summon after the reset: %v", err)
+	}
+}
+
+func TestRollRarityFollowsWeights(t *testing.T) {
+	const n = 100000
+	weights := []RarityWeight{{"common", 60}, {"rare", 30}, {"epic", 10}, {legendary, 0}}
+	rng := rand.New(rand.NewSource(1))
+
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[RollRarity(weights, rng)]++
+	}
+	for _, w := range weights {
+		want := float64(w.Weight) / 100 * n
+		if math.Abs(float64(counts[w.Rarity])-want) > n*0.01 {
+			t.Errorf("%s: %d of %d rolls, want about %.0f", w.Rarity, counts[w.Rarity], n, want)
+		}
+	}
+}
+
+func TestPickHeroTypeFallsBackToLowerRarities(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	common := &model.HeroType{ID: "common", Rarity: "common"}
+	epic := &model.HeroType{ID: "epic", Rarity: "epic"}
+	unranked := &model.HeroType{ID: "unranked", Rarity: "mythic"}
+
+	tests := []struct {
+		name   string
+		types  []*model.HeroType
+		rarity string
+		want   *model.HeroType
+	}{
+		{"rarity in the pool", []*model.HeroType{common, epic}, "epic", epic},
+		{"next lower rarity", []*model.HeroType{common, epic}, legendary, epic},
+		{"lowest rarity", []*model.HeroType{common, epic}, "rare", common},
+		{"no lower rarity", []*model.HeroType{epic}, "common", epic},
+		{"unknown rarity", []*model.HeroType{unranked}, legendary, unranked},
+		{"empty pool", nil, "common", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PickHeroType(tt.types, tt.rarity, rng); got != tt.want {
+				t.Errorf("PickHeroType(%s) = %v, want %v", tt.rarity, got, tt.want)
+			}
+		})
+	}
+}
+
+// itemBannerStore gives every banner featured items
+type itemBannerStore struct{ store.Store }
+
+func (s itemBannerStore) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return s.Store.WithTx(ctx, func(tx store.Store) error { return fn(itemBannerStore{tx}) })
+}
+
+func (s itemBannerStore) Banners() store.BannerRepository {
+	return itemBanners{s.Store.Banners()}
+}
+
+type itemBanners struct{ store.BannerRepository }
+
+func (r itemBanners) Get(ctx context.Context, id string) (*model.Banner, error) {
+	b, err := r.BannerRepository.Get(ctx, id)
+	if err == nil {
+		b.FeaturedItems = []string{"item_template_001"}
+	}
+	return b, err
+}
+
+func TestSummonRejectsItemBanners(t *testing.T) {
+	ctx := context.Background()
+	_, st, userID := newTestService(t, fixedDays{time.Now().Add(-time.Hour)})
+	s := NewService(itemBannerStore{st}, events.NewBus(), fixedDays{time.Now().Add(-time.Hour)}, 1)
+
+	if _, err := s.Summon(ctx, userID, "banner_001", 1, false); !errors.Is(err, ErrItemBanner) {
+		t.Fatalf("Summon on a banner with items: got %v, want ErrItemBanner", err)
+	}
+	resources, err := st.Resources().Get(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resources.PremiumCurrency != 10000 {
+		t.Errorf("gems after the rejected summon = %d, want 10000", resources.PremiumCurrency)
+	}
+}
@@ -0,0 +1,104 @@
+// Package health checks whether the dependencies of the server work, for
+// readiness probes.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Statuses of a check and of a report
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Check tests one dependency, such as the database
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the outcome of every check. Its status is ok only if every
+// check passed.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs a set of checks
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker creates a checker that gives each check up to timeout
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Run runs every check at once and reports their results. A check that does
+// not finish within the timeout fails, so a hung dependency cannot hang the
+// probe.
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+// run runs one check with the timeout
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		// The check ignored its context; stop waiting for it
+		err = ctx.Err()
+	}
+
+	result := Result{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunReportsEveryCheck(t *testing.T) {
+	c := NewChecker(time.Second,
+		Check{Name: "database", Run: func(ctx context.Context) error { return nil }},
+		Check{Name: "storage", Run: func(ctx context.Context) error { return errors.New("bucket not found") }},
+	)
+
+	report := c.Run(context.Background())
+	if report.OK() || report.Status != StatusUnavailable {
+		t.Errorf("status = %q, want unavailable", report.Status)
+	}
+	if r := report.Checks["database"]; r.Status != StatusOK || r.Error != "" {
+		t.Errorf("database = %+v, want ok", r)
+	}
+	if r := report.Checks["storage"]; r.Status != StatusUnavailable || r.Error != "bucket not found" {
+		t.Errorf("storage = %+v, want its error", r)
+	}
+}
+
+func TestRunWithoutChecksIsOK(t *testing.T) {
+	report := NewChecker(time.Second).Run(context.Background())
+	if !report.OK() || len(report.Checks) != 0 {
+		t.Errorf("report = %+v, want ok with no checks", report)
+	}
+}
+
+func TestRunFailsChecksThatTimeOut(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := NewChecker(50*time.Millisecond,
+		// A check that waits for its context and one that ignores it
+		Check{Name: "database", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		Check{Name: "storage", Run: func(ctx context.Context) error {
+			<-release
+			return nil
+		}},
+	)
+
+	start := time.Now()
+	report := c.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run took %v, want it to stop at the timeout", elapsed)
+	}
+	if report.OK() {
+		t.Error("report is ok, want checks that timed out to fail")
+	}
+	for _, name := range []string{"database", "storage"} {
+		if r := report.Checks[name]; r.Status != StatusUnavailable || r.Error != context.DeadlineExceeded.Error() {
+			t.Errorf("%s = %+v, want it to time out", name, r)
+		}
+	}
+}
+
+func TestRunChecksAtOnce(t *testing.T) {
+	slow := func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}
+	c := NewChecker(time.Second, Check{Name: "a", Run: slow}, Check{Name: "b", Run: slow}, Check{Name: "c", Run: slow})
+
+	start := time.Now()
+	if report := c.Run(context.Background()); !report.OK() {
+		t.Fatalf("report = %+v, want ok", report)
+	}
+	if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+		t.Errorf("Run took %v, want the checks to run at once", elapsed)
+	}
+}
@@ -0,0 +1,165 @@
+// Package idempotency lets clients retry requests that change game state
+// without the change happening twice. A request sent with an idempotency key
+// reserves the key for its user; once it has been answered, the response is
+// stored with the key and retries get it back instead of running again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/logging"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// sweepInterval is how often expired keys are deleted
+const sweepInterval = time.Hour
+
+// beginAttempts is how many times Begin tries to reserve a key while
+// concurrent requests with it reserve and release it
+const beginAttempts = 3
+
+// Service reserves idempotency keys and stores the responses to their
+// requests. Keys are kept in the store, so retries are recognized by every
+// server and across restarts.
+type Service struct {
+	store  store.Store
+	expiry time.Duration
+	lease  time.Duration
+}
+
+// NewService creates a service that keeps keys for cfg.Server.IdempotencyKeyExpiry
+// and lets requests hold them for cfg.Server.IdempotencyKeyLease
+func NewService(st store.Store, cfg *config.Config) *Service {
+	return &Service{
+		store:  st,
+		expiry: time.Duration(cfg.Server.IdempotencyKeyExpiry) * time.Hour,
+		lease:  time.Duration(cfg.Server.IdempotencyKeyLease) * time.Second,
+	}
+}
+
+// ErrLeaseLost is returned by Complete when the request held its key past
+// the lease and another request has reserved it since
+var ErrLeaseLost = errors.New("idempotency key was reserved again after its lease ran out")
+
+// Begin reserves the user's key for the request with the given hash and
+// returns the reservation, which Complete or Release must be called with. If
+// the key was already used and has not expired, nothing is reserved and the
+// earlier key is returned instead: it may be for a different request or
+// still lack a response, which callers must check. A key whose request has
+// held it past the lease without a response is reserved again.
+func (s *Service) Begin(ctx context.Context, userID, key, requestHash string) (reserved, earlier *model.IdempotencyKey, err error) {
+	for attempt := 1; ; attempt++ {
+		reserved, earlier, err = s.begin(ctx, userID, key, requestHash)
+		if !errors.Is(err, store.ErrDuplicate) {
+			return reserved, earlier, err
+		}
+		// A concurrent request with the same key reserved, released or took
+		// it over between our reads and writes. Look it up again, unless it
+		// keeps changing, and then report it as in progress.
+		if attempt == beginAttempts {
+			return nil, &model.IdempotencyKey{UserID: userID, Key: key, RequestHash: requestHash}, nil
+		}
+	}
+}
+
+// begin is one attempt of Begin. It fails with store.ErrDuplicate if the key
+// changes concurrently. Each statement stands alone rather than in a
+// transaction: locking a key that does not exist yet before inserting it
+// deadlocks in MySQL when two requests do it at once, so the key is inserted
+// first and only taken over if it is still the one that was read.
+func (s *Service) begin(ctx context.Context, userID, key, requestHash string) (reserved, earlier *model.IdempotencyKey, err error) {
+	// The store keeps times to the second, and the reservation time tells a
+	// reservation apart from later ones of the same key
+	now := time.Now().Truncate(time.Second)
+	reserved = &model.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.expiry),
+	}
+
+	err = s.store.IdempotencyKeys().Create(ctx, reserved)
+	if err == nil {
+		return reserved, nil, nil
+	}
+	if !errors.Is(err, store.ErrDuplicate) {
+		return nil, nil, err
+	}
+
+	existing, err := s.store.IdempotencyKeys().Get(ctx, userID, key)
+	if errors.Is(err, store.ErrNotFound) {
+		// Released since the insert failed
+		return nil, nil, store.ErrDuplicate
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !existing.IsExpired(now) && !existing.IsAbandoned(now, s.lease) {
+		return nil, existing, nil
+	}
+
+	// The sweep has not caught up with it yet, or the server running its
+	// request stopped before answering it
+	err = s.store.IdempotencyKeys().Replace(ctx, existing, reserved)
+	if errors.Is(err, store.ErrNotFound) {
+		// Answered, released or taken over by another request since it was read
+		return nil, nil, store.ErrDuplicate
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !existing.IsExpired(now) {
+		logging.FromContext(ctx).WithField("reserved_at", existing.CreatedAt).
+			Warn("Took over an idempotency key whose request never finished")
+	}
+	return reserved, nil, nil
+}
+
+// Complete stores the response to the request of a reservation. It fails
+// with ErrLeaseLost, storing nothing, if the key has been taken over since.
+func (s *Service) Complete(ctx context.Context, reserved *model.IdempotencyKey, statusCode int, contentType string, body []byte) error {
+	err := s.store.IdempotencyKeys().Update(ctx, &model.IdempotencyKey{
+		UserID:      reserved.UserID,
+		Key:         reserved.Key,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+		CreatedAt:   reserved.CreatedAt,
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+// Release frees a reservation whose request failed without changing
+// anything, so a retry runs it again. A key taken over since is left alone.
+func (s *Service) Release(ctx context.Context, reserved *model.IdempotencyKey) error {
+	return s.store.IdempotencyKeys().Delete(ctx, reserved)
+}
+
+// Run deletes expired keys now and then until ctx is cancelled. It is safe to
+// run on several servers at once.
+func (s *Service) Run(ctx context.Context) {
+	for {
+		if n, err := s.store.IdempotencyKeys().DeleteExpired(ctx, time.Now()); err != nil {
+			logrus.WithError(err).Error("Error deleting expired idempotency keys")
+		} else if n > 0 {
+			logrus.WithField("count", n).Info("Deleted expired idempotency keys")
+		}
+
+		timer := time.NewTimer(sweepInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
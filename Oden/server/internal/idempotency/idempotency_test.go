@@ -0,0 +1,217 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// racyStore fails the first dups reservations of a key as if a concurrent
+// request had reserved it and released it again before it could be read
+type racyStore struct {
+	store.Store
+	dups *int
+}
+
+func (s racyStore) IdempotencyKeys() store.IdempotencyKeyRepository {
+	return racyKeys{IdempotencyKeyRepository: s.Store.IdempotencyKeys(), dups: s.dups}
+}
+
+type racyKeys struct {
+	store.IdempotencyKeyRepository
+	dups *int
+}
+
+func (r racyKeys) Create(ctx context.Context, key *model.IdempotencyKey) error {
+	if *r.dups > 0 {
+		*r.dups--
+		return store.ErrDuplicate
+	}
+	return r.IdempotencyKeyRepository.Create(ctx, key)
+}
+
+func newTestService(st store.Store) *Service {
+	return NewService(st, config.Default())
+}
+
+func TestBeginReservesOnce(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(store.NewMemory())
+
+	reserved, earlier, err := s.Begin(ctx, "user", "key", "hash")
+	if err != nil || reserved == nil || earlier != nil {
+		t.Fatalf("first Begin = %v, %v, %v; want the key reserved", reserved, earlier, err)
+	}
+	again, earlier, err := s.Begin(ctx, "user", "key", "hash")
+	if err != nil || again != nil || earlier == nil || earlier.IsComplete() {
+		t.Fatalf("Begin while the request runs = %v, %v, %v; want the incomplete key", again, earlier, err)
+	}
+	if other, earlier, err := s.Begin(ctx, "other user", "key", "hash"); err != nil || other == nil || earlier != nil {
+		t.Errorf("Begin of another user's key = %v, %v, %v; want the key reserved", other, earlier, err)
+	}
+
+	if err := s.Complete(ctx, reserved, 200, "application/json", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, earlier, err = s.Begin(ctx, "user", "key", "hash")
+	if err != nil || earlier == nil || earlier.StatusCode != 200 {
+		t.Errorf("Begin after Complete = %v, %v; want the stored response", earlier, err)
+	}
+}
+
+func TestReleaseFreesKey(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(store.NewMemory())
+
+	reserved, _, err := s.Begin(ctx, "user", "key", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Release(ctx, reserved); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if again, earlier, err := s.Begin(ctx, "user", "key", "hash"); err != nil || again == nil || earlier != nil {
+		t.Errorf("Begin after Release = %v, %v, %v; want the key reserved again", again, earlier, err)
+	}
+}
+
+// abandon reserves the user's key as if it had been reserved by a request
+// that started longer ago than the lease, with the status code
+func abandon(t *testing.T, st store.Store, s *Service, key string, statusCode int) *model.IdempotencyKey {
+	t.Helper()
+	reservedAt := time.Now().Add(-s.lease - time.Second).Truncate(time.Second)
+	k := &model.IdempotencyKey{UserID: "user", Key: key, RequestHash: "hash", StatusCode: statusCode, CreatedAt: reservedAt, ExpiresAt: reservedAt.Add(s.expiry)}
+	if err := st.IdempotencyKeys().Create(context.Background(), k); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestBeginTakesOverAbandonedKeys(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	s := newTestService(st)
+	abandoned := abandon(t, st, s, "abandoned", 0)
+	abandon(t, st, s, "answered", 201)
+
+	taken, earlier, err := s.Begin(ctx, "user", "abandoned", "hash")
+	if err != nil || taken == nil || earlier != nil {
+		t.Errorf("Begin of an abandoned key = %v, %v, %v; want the key reserved again", taken, earlier, err)
+	}
+	if _, earlier, err := s.Begin(ctx, "user", "abandoned", "hash"); err != nil || earlier == nil {
+		t.Errorf("Begin of a key taken over = %v, %v; want the new reservation", earlier, err)
+	}
+	if _, earlier, err := s.Begin(ctx, "user", "answered", "hash"); err != nil || earlier == nil || earlier.StatusCode != 201 {
+		t.Errorf("Begin of an answered key past the lease = %v, %v; want the stored response", earlier, err)
+	}
+
+	// The request that lost the key can neither answer nor free it
+	if err := s.Complete(ctx, abandoned, 200, "application/json", []byte(`{}`)); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Complete after the key was taken over = %v, want ErrLeaseLost", err)
+	}
+	if err := s.Release(ctx, abandoned); err != nil {
+		t.Fatal(err)
+	}
+	k, err := st.IdempotencyKeys().Get(ctx, "user", "abandoned")
+	if err != nil || k.IsComplete() || !k.CreatedAt.Equal(taken.CreatedAt) {
+		t.Errorf("key after the old request finished = %+v, %v; want the new reservation untouched", k, err)
+	}
+	if err := s.Complete(ctx, taken, 200, "application/json", []byte(`{}`)); err != nil {
+		t.Errorf("Complete by the new request: %v", err)
+	}
+}
+
+// answeringStore stores a response for a key right after it is read, as if
+// the request holding it answered just before it was taken over
+type answeringStore struct {
+	store.Store
+}
+
+func (s answeringStore) IdempotencyKeys() store.IdempotencyKeyRepository {
+	return answeringKeys{s.Store.IdempotencyKeys()}
+}
+
+type answeringKeys struct {
+	store.IdempotencyKeyRepository
+}
+
+func (r answeringKeys) Get(ctx context.Context, userID, key string) (*model.IdempotencyKey, error) {
+	k, err := r.IdempotencyKeyRepository.Get(ctx, userID, key)
+	if err == nil && !k.IsComplete() {
+		answered := *k
+		answered.StatusCode = 201
+		if err := r.IdempotencyKeyRepository.Update(ctx, &answered); err != nil {
+			return nil, err
+		}
+	}
+	return k, err
+}
+
+func TestBeginKeepsResponsesStoredConcurrently(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	s := newTestService(answeringStore{st})
+	abandon(t, st, s, "key", 0)
+
+	reserved, earlier, err := s.Begin(ctx, "user", "key", "hash")
+	if err != nil || reserved != nil || earlier == nil || earlier.StatusCode != 201 {
+		t.Errorf("Begin of a key answered while it was taken over = %v, %v, %v; want the stored response", reserved, earlier, err)
+	}
+}
+
+func TestBeginRetriesConcurrentReservations(t *testing.T) {
+	ctx := context.Background()
+
+	// The concurrent reservation is gone by the time it is looked up
+	dups := 1
+	s := newTestService(racyStore{Store: store.NewMemory(), dups: &dups})
+	if reserved, earlier, err := s.Begin(ctx, "user", "key", "hash"); err != nil || reserved == nil || earlier != nil {
+		t.Errorf("Begin after a released concurrent reservation = %v, %v, %v; want the key reserved", reserved, earlier, err)
+	}
+
+	// Concurrent requests keep reserving and releasing it
+	dups = beginAttempts
+	s = newTestService(racyStore{Store: store.NewMemory(), dups: &dups})
+	reserved, earlier, err := s.Begin(ctx, "user", "key", "hash")
+	if err != nil || reserved != nil || earlier == nil || earlier.IsComplete() || earlier.RequestHash != "hash" {
+		t.Errorf("Begin of a contended key = %v, %v, %v; want it reported in progress", reserved, earlier, err)
+	}
+}
+
+func TestConcurrentBeginsReserveOnce(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(store.NewMemory())
+
+	const requests = 8
+	var wg sync.WaitGroup
+	results := make(chan *model.IdempotencyKey, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reserved, _, err := s.Begin(ctx, "user", "key", "hash")
+			if err != nil {
+				t.Errorf("Begin: %v", err)
+			}
+			results <- reserved
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	n := 0
+	for reserved := range results {
+		if reserved != nil {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%d concurrent requests reserved the key, want 1", n)
+	}
+}
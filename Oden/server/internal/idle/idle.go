@@ -0,0 +1,191 @@
+// Package idle computes and grants the rewards players accrue while away.
+package idle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// Rewards represents the rewards accrued while the player was away
+type Rewards struct {
+	Gold       int            `json:"gold"`
+	Experience map[string]int `json:"experience"` // Hero ID -> XP
+}
+
+// Service computes and claims idle rewards. The server clock and the stored
+// LastIdleClaim are the only inputs, so clients cannot inflate their rewards.
+type Service struct {
+	store  store.Store
+	cfg    *config.Config
+	events *events.Bus
+	now    func() time.Time // the server clock
+}
+
+// NewService creates an idle reward service. Claims, and heroes that level up
+// from them, are published on bus.
+func NewService(st store.Store, cfg *config.Config, bus *events.Bus) *Service {
+	return &Service{store: st, cfg: cfg, events: bus, now: time.Now}
+}
+
+// accrual is the result of computing idle rewards at a point in time
+type accrual struct {
+	timeAway time.Duration
+	rewards  *Rewards
+	heroIDs  []string // the team, in position order
+	// nextClaim is the new LastIdleClaim. Time that did not add up to a whole
+	// minute is carried over to the next claim, unless the cap was reached.
+	nextClaim time.Time
+}
+
+// Pending returns how long the user has been away, capped at MaxIdleHours, and
+// the rewards they could claim now
+func (s *Service) Pending(ctx context.Context, userID string) (time.Duration, *Rewards, error) {
+	resources, err := s.store.Resources().Get(ctx, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	a, err := s.accrue(ctx, s.store, resources, s.now())
+	if err != nil {
+		return 0, nil, err
+	}
+	return a.timeAway, a.rewards, nil
+}
+
+// Claim grants the pending rewards and resets the idle timer. The resources
+// row is locked for the whole transaction, so a concurrent claim waits and
+// then finds nothing left to pay out.
+func (s *Service) Claim(ctx context.Context, userID string) (time.Time, *Rewards, error) {
+	now := s.now()
+	var rewards *Rewards
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		resources, err := tx.Resources().Get(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		a, err := s.accrue(ctx, tx, resources, now)
+		if err != nil {
+			return err
+		}
+		rewards = a.rewards
+
+		for _, heroID := range a.heroIDs {
+			hero, err := tx.Heroes().Get(ctx, heroID)
+			if err != nil {
+				return err
+			}
+			level := hero.Level
+			hero.AddExperience(rewards.Experience[heroID])
+			if err := tx.Heroes().Update(ctx, hero); err != nil {
+				return err
+			}
+			if err := s.events.Publish(ctx, tx, events.LevelUp(hero, level)...); err != nil {
+				return err
+			}
+		}
+
+		resources.Gold += rewards.Gold
+		resources.LastIdleClaim = a.nextClaim
+		if err := tx.Resources().Update(ctx, resources); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, tx, events.IdleRewardsClaimed{UserID: userID, Gold: rewards.Gold})
+	})
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return now, rewards, nil
+}
+
+// accrue computes the rewards for the time since the last claim. Gold and XP
+// accrue per whole minute, grow by IdleStageBonusPercent for every stage up to
+// the furthest one cleared, and the XP is split evenly across the team.
+func (s *Service) accrue(ctx context.Context, st store.Store, resources *model.PlayerResources, now time.Time) (*accrual, error) {
+	game := s.cfg.CurrentGame()
+
+	timeAway := now.Sub(resources.LastIdleClaim)
+	if timeAway < 0 {
+		timeAway = 0
+	}
+	minutes := int(timeAway / time.Minute)
+	nextClaim := resources.LastIdleClaim.Add(time.Duration(minutes) * time.Minute)
+	if maxIdle := time.Duration(game.MaxIdleHours) * time.Hour; timeAway >= maxIdle {
+		timeAway = maxIdle
+		minutes = int(maxIdle / time.Minute)
+		nextClaim = now
+	}
+
+	stage, err := furthestClearedStage(ctx, st, resources.UserID)
+	if err != nil {
+		return nil, err
+	}
+	percent := 100 + stage*game.IdleStageBonusPercent
+
+	heroIDs, err := teamHeroIDs(ctx, st, resources.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	rewards := &Rewards{
+		Gold:       minutes * game.IdleGoldPerMinute * percent / 100,
+		Experience: make(map[string]int, len(heroIDs)),
+	}
+	if len(heroIDs) > 0 {
+		totalXP := minutes * game.IdleExpPerMinute * percent / 100
+		share, rest := totalXP/len(heroIDs), totalXP%len(heroIDs)
+		for i, heroID := range heroIDs {
+			rewards.Experience[heroID] = share
+			if i < rest {
+				rewards.Experience[heroID]++
+			}
+		}
+	}
+
+	return &accrual{timeAway: timeAway, rewards: rewards, heroIDs: heroIDs, nextClaim: nextClaim}, nil
+}
+
+// furthestClearedStage returns the 1-based number of the furthest stage the
+// user has won, in stage order, or 0 if they have not won any
+func furthestClearedStage(ctx context.Context, st store.Store, userID string) (int, error) {
+	cleared, err := st.BattleResults().ListClearedStageIDs(ctx, userID)
+	if err != nil || len(cleared) == 0 {
+		return 0, err
+	}
+
+	stages, err := st.Stages().List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	isCleared := make(map[string]bool, len(cleared))
+	for _, id := range cleared {
+		isCleared[id] = true
+	}
+	furthest := 0
+	for i, stage := range stages {
+		if isCleared[stage.ID] {
+			furthest = i + 1
+		}
+	}
+	return furthest, nil
+}
+
+// teamHeroIDs returns the IDs of the heroes in the user's team in position
+// order, or nil if the user has no team
+func teamHeroIDs(ctx context.Context, st store.Store, userID string) ([]string, error) {
+	team, err := st.Teams().GetByUser(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return team.GetHeroIDs(), nil
+}
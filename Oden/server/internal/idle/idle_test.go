@@ -0,0 +1,175 @@
+package idle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// lastClaim is when the test player last claimed idle rewards
+var lastClaim = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestService returns a service over the sample data whose clock reads
+// away after the last claim of a new player, who has no gold
+func newTestService(t *testing.T, away time.Duration) (*Service, store.Store, string) {
+	t.Helper()
+	ctx := context.Background()
+	st := store.NewMemory()
+	st.LoadSampleData()
+	user := model.NewUser("user", "player", "player@example.com", "hash")
+	if err := st.Users().Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	resources := model.NewPlayerResources(user.ID, 0, 0)
+	resources.LastIdleClaim = lastClaim
+	if err := st.Resources().Create(ctx, resources); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewService(st, config.Default(), events.NewBus())
+	s.now = func() time.Time { return lastClaim.Add(away) }
+	return s, st, user.ID
+}
+
+func TestClaim(t *testing.T) {
+	// The default game settings pay 2 gold a minute for up to 24 hours, and
+	// 10% more for every stage up to the furthest cleared one
+	tests := []struct {
+		name      string
+		away      time.Duration
+		cleared   string // stage won before claiming
+		wantAway  time.Duration
+		wantGold  int
+		nextClaim time.Duration // after lastClaim
+	}{
+		{"under a minute", 59 * time.Second, "", 59 * time.Second, 0, 0},
+		{"whole minutes", 10 * time.Minute, "", 10 * time.Minute, 20, 10 * time.Minute},
+		{"part minute carried over", 10*time.Minute + 30*time.Second, "", 10*time.Minute + 30*time.Second, 20, 10 * time.Minute},
+		{"at the cap", 24 * time.Hour, "", 24 * time.Hour, 2880, 24 * time.Hour},
+		{"past the cap", 30*time.Hour + 30*time.Second, "", 24 * time.Hour, 2880, 30*time.Hour + 30*time.Second},
+		{"clock behind the last claim", -time.Hour, "", 0, 0, 0},
+		{"first stage cleared", 10 * time.Minute, "stage_001", 10 * time.Minute, 22, 10 * time.Minute},
+		{"second stage cleared", 10 * time.Minute, "stage_002", 10 * time.Minute, 24, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, st, userID := newTestService(t, tt.away)
+			if tt.cleared != "" {
+				won := model.NewBattleResult(model.NewID("battle"), userID, "team", tt.cleared, "victory", &model.Rewards{})
+				if err := st.BattleResults().Create(ctx, won); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			away, pending, err := s.Pending(ctx, userID)
+			if err != nil {
+				t.Fatalf("Pending: %v", err)
+			}
+			if away != tt.wantAway || pending.Gold != tt.wantGold {
+				t.Errorf("Pending = %v and %d gold, want %v and %d", away, pending.Gold, tt.wantAway, tt.wantGold)
+			}
+
+			claimedAt, rewards, err := s.Claim(ctx, userID)
+			if err != nil {
+				t.Fatalf("Claim: %v", err)
+			}
+			if !claimedAt.Equal(lastClaim.Add(tt.away)) || rewards.Gold != tt.wantGold {
+				t.Errorf("Claim = %v and %d gold, want %v and %d", claimedAt, rewards.Gold, lastClaim.Add(tt.away), tt.wantGold)
+			}
+			resources, err := st.Resources().Get(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resources.Gold != tt.wantGold {
+				t.Errorf("gold after the claim = %d, want %d", resources.Gold, tt.wantGold)
+			}
+			if want := lastClaim.Add(tt.nextClaim); !resources.LastIdleClaim.Equal(want) {
+				t.Errorf("last claim moved to %v, want %v", resources.LastIdleClaim, want)
+			}
+		})
+	}
+}
+
+func TestClaimSplitsExperienceAcrossTeam(t *testing.T) {
+	ctx := context.Background()
+	// 1 XP a minute by default
+	s, st, userID := newTestService(t, 11*time.Minute)
+
+	positions := make(map[string]string)
+	var heroIDs []string
+	for _, pos := range []string{"1", "2"} {
+		hero := model.NewHero(model.NewID("hero"), userID, "hero_type_001")
+		if err := st.Heroes().Create(ctx, hero); err != nil {
+			t.Fatal(err)
+		}
+		positions[pos] = hero.ID
+		heroIDs = append(heroIDs, hero.ID)
+	}
+	team := model.NewTeam(model.NewID("team"), userID)
+	team.SetAllPositions(positions)
+	if err := st.Teams().Save(ctx, team); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rewards, err := s.Claim(ctx, userID)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	// The first position gets the XP that does not split evenly
+	for i, want := range []int{6, 5} {
+		if got := rewards.Experience[heroIDs[i]]; got != want {
+			t.Errorf("hero in position %d got %d XP, want %d", i+1, got, want)
+		}
+		hero, err := st.Heroes().Get(ctx, heroIDs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hero.Experience != want {
+			t.Errorf("hero in position %d has %d XP, want %d", i+1, hero.Experience, want)
+		}
+	}
+}
+
+func TestConcurrentClaimsPayOnce(t *testing.T) {
+	ctx := context.Background()
+	s, st, userID := newTestService(t, 30*time.Hour)
+
+	const claims = 8
+	var wg sync.WaitGroup
+	paid := make([]int, claims)
+	errs := make([]error, claims)
+	for i := 0; i < claims; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, rewards, err := s.Claim(ctx, userID)
+			errs[i] = err
+			if err == nil {
+				paid[i] = rewards.Gold
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range paid {
+		if errs[i] != nil {
+			t.Fatalf("Claim: %v", errs[i])
+		}
+		total += paid[i]
+	}
+	resources, err := st.Resources().Get(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2880 || resources.Gold != 2880 {
+		t.Errorf("concurrent claims paid %d gold and granted %d, want 2880 once", total, resources.Gold)
+	}
+}
@@ -0,0 +1,61 @@
+// Package logging configures the structured logger of the server and carries
+// request-scoped loggers in contexts, so every line logged while handling a
+// request can be found by its request ID.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/config"
+)
+
+// Configure sets the level and format of the standard logrus logger, and
+// sends the output of the standard library's log package through it
+func Configure(cfg config.LogConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.StandardLogger()
+	logger.SetOutput(os.Stderr)
+	logger.SetLevel(level)
+	switch cfg.Format {
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	case "text":
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	log.SetFlags(0)
+	log.SetOutput(logger.Writer())
+	return nil
+}
+
+// ctxKey is the context key of the request-scoped logger
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying logger
+func NewContext(ctx context.Context, logger *logrus.Entry) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the standard logger if
+// it has none
+func FromContext(ctx context.Context) *logrus.Entry {
+	if logger, ok := ctx.Value(ctxKey{}).(*logrus.Entry); ok {
+		return logger
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// WithFields returns a copy of ctx whose logger adds fields to every line
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return NewContext(ctx, FromContext(ctx).WithFields(fields))
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"log"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/config"
+)
+
+// restoreLogger puts back the settings of the standard logger after the test
+func restoreLogger(t *testing.T) {
+	logger := logrus.StandardLogger()
+	out, level, formatter := logger.Out, logger.GetLevel(), logger.Formatter
+	flags, logOut := log.Flags(), log.Writer()
+	t.Cleanup(func() {
+		logger.SetOutput(out)
+		logger.SetLevel(level)
+		logger.SetFormatter(formatter)
+		log.SetFlags(flags)
+		log.SetOutput(logOut)
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	restoreLogger(t)
+
+	if err := Configure(config.LogConfig{Level: "warn", Format: "json"}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	logger := logrus.StandardLogger()
+	if logger.GetLevel() != logrus.WarnLevel {
+		t.Errorf("level = %v, want warn", logger.GetLevel())
+	}
+
+	if _, ok := logger.Formatter.(*logrus.JSONFormatter); !ok {
+		t.Errorf("formatter = %T, want JSON", logger.Formatter)
+	}
+	if log.Flags() != 0 {
+		t.Errorf("log flags = %d, want none, as logrus adds the time", log.Flags())
+	}
+
+	if err := Configure(config.LogConfig{Level: "debug", Format: "text"}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if _, ok := logger.Formatter.(*logrus.TextFormatter); !ok || logger.GetLevel() != logrus.DebugLevel {
+		t.Errorf("formatter %T at %v, want text at debug", logger.Formatter, logger.GetLevel())
+	}
+
+	for _, cfg := range []config.LogConfig{{Level: "loud", Format: "json"}, {Level: "info", Format: "xml"}} {
+		if err := Configure(cfg); err == nil {
+			t.Errorf("Configure(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	ctx := context.Background()
+	if entry := FromContext(ctx); entry.Logger != logrus.StandardLogger() || len(entry.Data) != 0 {
+		t.Errorf("logger of a context without one = %+v, want the standard logger", entry)
+	}
+
+	ctx = WithFields(ctx, logrus.Fields{"request_id": "abc"})
+	ctx = WithFields(ctx, logrus.Fields{"user_id": "user"})
+	data := FromContext(ctx).Data
+	if data["request_id"] != "abc" || data["user_id"] != "user" {
+		t.Errorf("fields = %v, want both request_id and user_id", data)
+	}
+
+	other := logrus.New().WithField("service", "test")
+	if FromContext(NewContext(ctx, other)) != other {
+		t.Error("FromContext did not return the logger given to NewContext")
+	}
+}
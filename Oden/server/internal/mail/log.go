@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/logging"
+)
+
+// unsafeFileChars matches characters kept out of email file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// Log writes emails to the log, or to files in a directory, instead of
+// sending them. It is meant for local development and tests.
+type Log struct {
+	from string
+	dir  string
+}
+
+var _ Mailer = (*Log)(nil)
+
+// NewLog creates a mailer that logs emails, or writes each one to a .eml
+// file in dir if dir is not empty
+func NewLog(from, dir string) *Log {
+	return &Log{from: from, dir: dir}
+}
+
+// Send logs msg or writes it to a file
+func (m *Log) Send(ctx context.Context, msg Message) error {
+	data, err := compose(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if m.dir == "" {
+		logging.FromContext(ctx).WithFields(logrus.Fields{
+			"to":      msg.To,
+			"subject": msg.Subject,
+			"body":    msg.Body,
+		}).Info("Email")
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o600)
+}
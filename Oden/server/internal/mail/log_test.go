@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogWritesEmailFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := NewLog("oden@example.com", dir)
+
+	msg := Message{To: "not an address", Subject: "Verify your email address", Body: "Hello"}
+	if err := m.Send(context.Background(), msg); err == nil {
+		t.Error("Send to an invalid address succeeded")
+	}
+	msg.To = "player@example.com"
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || !strings.HasSuffix(paths[0], "-player@example.com.eml") {
+		t.Fatalf("email files %v, want one named after the recipient", paths)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("reading email file: %v", err)
+	}
+	if got := parsed.Header.Get("To"); got != msg.To {
+		t.Errorf("To = %q, want %q", got, msg.To)
+	}
+}
+
+func TestLogWithoutDirectoryOnlyLogs(t *testing.T) {
+	m := NewLog("oden@example.com", "")
+	if err := m.Send(context.Background(), Message{To: "player@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Errorf("Send: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+func TestNewSelectsDriver(t *testing.T) {
+	if m, err := New(config.MailConfig{From: "oden@example.com"}); err != nil {
+		t.Errorf("New with no driver: %v", err)
+	} else if _, ok := m.(*Log); !ok {
+		t.Errorf("New with no driver = %T, want *Log", m)
+	}
+	if m, err := New(config.MailConfig{Driver: "smtp", Host: "mail.example.com", From: "Oden <oden@example.com>"}); err != nil {
+		t.Errorf("New smtp: %v", err)
+	} else if _, ok := m.(*SMTP); !ok {
+		t.Errorf("New smtp = %T, want *SMTP", m)
+	}
+	if _, err := New(config.MailConfig{Driver: "smtp", From: "oden@example.com"}); err == nil {
+		t.Error("New smtp without a host succeeded")
+	}
+	if _, err := New(config.MailConfig{Driver: "carrier pigeon"}); err == nil {
+		t.Error("New with an unknown driver succeeded")
+	}
+}
+
+func TestComposeFormatsMessage(t *testing.T) {
+	msg := Message{
+		To:      "player@example.com",
+		Subject: "Réinitialiser",
+		Body:    "Reset your password:\nhttps://example.com/reset?token=abc=def",
+	}
+	data, err := compose("Oden <oden@example.com>", msg)
+	if err != nil {
+		t.Fatalf("compose: %v", err)
+	}
+
+	parsed, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("reading composed message: %v", err)
+	}
+	if got := parsed.Header.Get("From"); got != "Oden <oden@example.com>" {
+		t.Errorf("From = %q", got)
+	}
+	if got := parsed.Header.Get("To"); got != msg.To {
+		t.Errorf("To = %q", got)
+	}
+	if _, err := parsed.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q, %v; want %q", subject, err, msg.Subject)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(msg.Body, "\n", "\r\n"); string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestComposeRejectsInvalidMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"invalid recipient", Message{To: "not an address", Subject: "Hi"}},
+		{"injected recipient", Message{To: "player@example.com\r\nBcc: other@example.com", Subject: "Hi"}},
+		{"multi-line subject", Message{To: "player@example.com", Subject: "Hi\r\nBcc: other@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compose("oden@example.com", tt.msg); err == nil {
+				t.Error("compose succeeded")
+			}
+		})
+	}
+}
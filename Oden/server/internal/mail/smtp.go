@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+// smtpTimeout bounds a delivery when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTP sends email through an SMTP server
+type SMTP struct {
+	host string
+	addr string
+	from string
+	// envelope is the bare address of from
+	envelope string
+	auth     smtp.Auth
+}
+
+var _ Mailer = (*SMTP)(nil)
+
+// NewSMTP creates a mailer for the SMTP server in cfg. Port defaults to 587.
+func NewSMTP(cfg config.MailConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("mail.host is required for the smtp driver")
+	}
+	from, err := netmail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail.from: %w", err)
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	m := &SMTP{
+		host:     cfg.Host,
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		from:     from.String(),
+		envelope: from.Address,
+	}
+	if cfg.Username != "" {
+		// PlainAuth refuses to send credentials without TLS, except to localhost
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m, nil
+}
+
+// Send delivers msg, giving up when ctx is done
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := compose(m.from, msg)
+	if err != nil {
+		return err
+	}
+	to, _ := netmail.ParseAddress(msg.To)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.envelope); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,150 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+// delivery is what a test SMTP server received
+type delivery struct {
+	from, to string
+	data     string
+}
+
+// serveSMTP accepts one connection on l and speaks just enough SMTP, without
+// STARTTLS or AUTH, to take a message, which it sends to the returned channel
+func serveSMTP(t *testing.T, l net.Listener) <-chan delivery {
+	t.Helper()
+	received := make(chan delivery, 1)
+	go func() {
+		defer close(received)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+
+		var d delivery
+		reply := func(format string, args ...interface{}) bool { return tp.PrintfLine(format, args...) == nil }
+		if !reply("220 localhost ESMTP test") {
+			return
+		}
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL":
+				d.from = strings.TrimPrefix(line, "MAIL FROM:")
+				reply("250 OK")
+			case "RCPT":
+				d.to = strings.TrimPrefix(line, "RCPT TO:")
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+				lines, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				d.data = strings.Join(lines, "\n")
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				received <- d
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+	return received
+}
+
+// listen starts listening on a local port and returns the mail config for it
+func listen(t *testing.T) (net.Listener, config.MailConfig) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	port := l.Addr().(*net.TCPAddr).Port
+	return l, config.MailConfig{Driver: "smtp", Host: "127.0.0.1", Port: port, From: "Oden <oden@example.com>"}
+}
+
+func TestSMTPDeliversMessage(t *testing.T) {
+	l, cfg := listen(t)
+	received := serveSMTP(t, l)
+	m, err := NewSMTP(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := Message{To: "Player <player@example.com>", Subject: "Verify your email address", Body: "Hello"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case d := <-received:
+		if d.from != "<oden@example.com>" || d.to != "<player@example.com>" {
+			t.Errorf("envelope from %s to %s, want the bare addresses", d.from, d.to)
+		}
+		if !strings.Contains(d.data, "Subject: Verify your email address") || !strings.HasSuffix(d.data, "Hello") {
+			t.Errorf("delivered %q, want the composed message", d.data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message delivered")
+	}
+}
+
+func TestSMTPGivesUpWhenContextIsDone(t *testing.T) {
+	l, cfg := listen(t)
+	// Accept the connection but never greet the client
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			bufio.NewReader(conn).ReadString('\n')
+		}
+	}()
+	m, err := NewSMTP(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := m.Send(ctx, Message{To: "player@example.com", Subject: "Hi", Body: "Hello"}); err == nil {
+		t.Error("Send to a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send took %v, want it to give up at the context deadline", elapsed)
+	}
+}
+
+func TestNewSMTPDefaultsPort(t *testing.T) {
+	m, err := NewSMTP(config.MailConfig{Host: "mail.example.com", From: "oden@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := net.JoinHostPort("mail.example.com", strconv.Itoa(587)); m.addr != want {
+		t.Errorf("addr = %q, want %q", m.addr, want)
+	}
+	if _, err := NewSMTP(config.MailConfig{Host: "mail.example.com", From: "not an address"}); err == nil {
+		t.Error("NewSMTP with an invalid sender succeeded")
+	}
+}
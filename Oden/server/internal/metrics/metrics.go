@@ -0,0 +1,145 @@
+// Package metrics collects the Prometheus metrics of the server: requests by
+// route, the database connection pool and the gameplay counters live-ops
+// watch to see whether a new banner or stage is working.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// namespace starts the name of every metric of the server
+const namespace = "oden"
+
+// noBanner labels summons that were not made on a banner
+const noBanner = "none"
+
+// Metrics holds the metrics of the server. It is safe for concurrent use,
+// and a nil *Metrics records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+
+	summons  *prometheus.CounterVec
+	battles  *prometheus.CounterVec
+	idleGold prometheus.Counter
+	spent    *prometheus.CounterVec
+}
+
+// New creates the metrics of the server, along with the Go runtime and
+// process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to handle HTTP requests, by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		summons: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "summons_total",
+			Help:      "Heroes summoned, by banner and rarity. Summons outside banners have banner_id none.",
+		}, []string{"banner_id", "rarity"}),
+		battles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "battles_total",
+			Help:      "Battles completed, by stage and result.",
+		}, []string{"stage_id", "result"}),
+		idleGold: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "idle_gold_claimed_total",
+			Help:      "Gold claimed from idle rewards.",
+		}),
+		spent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "currency_spent_total",
+			Help:      "Currency spent by players, by currency.",
+		}, []string{"currency"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.latency,
+		m.summons, m.battles, m.idleGold, m.spent,
+	)
+	return m
+}
+
+// WatchDB adds the connection pool stats of db, labelled with dbName
+func (m *Metrics) WatchDB(db *sql.DB, dbName string) error {
+	if m == nil {
+		return nil
+	}
+	return m.registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// Handler returns the handler that serves the metrics to Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a handled request. route is the route template,
+// such as /v1/heroes/:id, so requests for different IDs are counted together.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.latency.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// HandleEvent counts the gameplay events. It is an events.Handler, but the
+// counters only change once tx commits, so actions that are rolled back or
+// fail to commit are not counted.
+func (m *Metrics) HandleEvent(ctx context.Context, tx store.Store, e events.Event) error {
+	if m == nil {
+		return nil
+	}
+	tx.AfterCommit(func() { m.count(e) })
+	return nil
+}
+
+// count adds a committed event to the gameplay counters
+func (m *Metrics) count(e events.Event) {
+	switch e := e.(type) {
+	case events.HeroesSummoned:
+		banner := e.BannerID
+		if banner == "" {
+			banner = noBanner
+		}
+		for _, rarity := range e.Rarities {
+			m.summons.WithLabelValues(banner, rarity).Inc()
+		}
+	case events.BattleCompleted:
+		result := "defeat"
+		if e.Victory {
+			result = "victory"
+		}
+		m.battles.WithLabelValues(e.StageID, result).Inc()
+	case events.IdleRewardsClaimed:
+		m.idleGold.Add(float64(e.Gold))
+	case events.GoldSpent:
+		m.spent.WithLabelValues("gold").Add(float64(e.Amount))
+	case events.GemsSpent:
+		m.spent.WithLabelValues("gems").Add(float64(e.Amount))
+	}
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// value returns the value of the counter with the name and labels, or 0 if
+// it has not been counted yet
+func value(t *testing.T, m *Metrics, name string, labels ...string) float64 {
+	t.Helper()
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range f.GetMetric() {
+			for i, l := range metric.GetLabel() {
+				if i*2+1 >= len(labels) || l.GetName() != labels[i*2] || l.GetValue() != labels[i*2+1] {
+					continue metrics
+				}
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestHandleEventCountsCommittedEvents(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	st := store.NewMemory()
+	summoned := events.HeroesSummoned{UserID: "user", BannerID: "banner_001", Rarities: []string{"rare", "rare", "legendary"}}
+
+	err := st.WithTx(ctx, func(tx store.Store) error {
+		if err := m.HandleEvent(ctx, tx, summoned); err != nil {
+			return err
+		}
+		if got := value(t, m, "oden_summons_total", "banner_id", "banner_001", "rarity", "rare"); got != 0 {
+			t.Errorf("rare summons before the commit = %v, want 0", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if got := value(t, m, "oden_summons_total", "banner_id", "banner_001", "rarity", "rare"); got != 2 {
+		t.Errorf("rare summons = %v, want 2", got)
+	}
+	if got := value(t, m, "oden_summons_total", "banner_id", "banner_001", "rarity", "legendary"); got != 1 {
+		t.Errorf("legendary summons = %v, want 1", got)
+	}
+}
+
+func TestHandleEventSkipsRolledBackEvents(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	st := store.NewMemory()
+
+	err := st.WithTx(ctx, func(tx store.Store) error {
+		if err := m.HandleEvent(ctx, tx, events.GoldSpent{UserID: "user", Amount: 100}); err != nil {
+			return err
+		}
+		return errors.New("failure")
+	})
+	if err == nil {
+		t.Fatal("WithTx succeeded")
+	}
+	if got := value(t, m, "oden_currency_spent_total", "currency", "gold"); got != 0 {
+		t.Errorf("gold spent = %v after a rollback, want 0", got)
+	}
+}
+
+func TestHandleEventLabels(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	st := store.NewMemory()
+
+	published := []events.Event{
+		events.HeroesSummoned{UserID: "user", Rarities: []string{"common"}},
+		events.BattleCompleted{UserID: "user", StageID: "stage_001", Victory: true},
+		events.BattleCompleted{UserID: "user", StageID: "stage_001"},
+		events.IdleRewardsClaimed{UserID: "user", Gold: 250},
+		events.GemsSpent{UserID: "user", Amount: 30},
+	}
+	for _, e := range published {
+		if err := m.HandleEvent(ctx, st, e); err != nil {
+			t.Fatalf("HandleEvent: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"summons outside banners", value(t, m, "oden_summons_total", "banner_id", noBanner, "rarity", "common"), 1},
+		{"victories", value(t, m, "oden_battles_total", "result", "victory", "stage_id", "stage_001"), 1},
+		{"defeats", value(t, m, "oden_battles_total", "result", "defeat", "stage_id", "stage_001"), 1},
+		{"idle gold", value(t, m, "oden_idle_gold_claimed_total"), 250},
+		{"gems spent", value(t, m, "oden_currency_spent_total", "currency", "gems"), 30},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestNilMetricsRecordNothing(t *testing.T) {
+	var m *Metrics
+	m.ObserveRequest(http.MethodGet, "/v1/heroes", http.StatusOK, time.Millisecond)
+	if err := m.HandleEvent(context.Background(), store.NewMemory(), events.GoldSpent{Amount: 1}); err != nil {
+		t.Errorf("HandleEvent: %v", err)
+	}
+	if err := m.WatchDB(nil, "oden"); err != nil {
+		t.Errorf("WatchDB: %v", err)
+	}
+}
+
+func TestHandlerServesRequests(t *testing.T) {
+	m := New()
+	m.ObserveRequest(http.MethodGet, "/v1/heroes/:id", http.StatusOK, 20*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	want := `oden_http_requests_total{method="GET",route="/v1/heroes/:id",status="200"} 1`
+	if !strings.Contains(body, want) {
+		t.Errorf("metrics do not contain %s", want)
+	}
+}
@@ -0,0 +1,332 @@
+// Package mission assigns missions to players, advances them from gameplay
+// events and expires them when their period ends.
+package mission
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// retryInterval is how soon a failed expiry sweep is retried
+const retryInterval = time.Minute
+
+// templateTTL is how long loaded mission templates are used before they are
+// read from the store again
+const templateTTL = 5 * time.Minute
+
+// Service assigns, advances and expires missions.
+//
+// Daily and weekly missions are assigned the first time a user is seen in a
+// period: when they list their missions or do something that counts towards
+// one. Each assignment has an ID derived from the user, the template and the
+// period, so assigning twice, from a restarted server or from several servers
+// at once, creates the mission only once. Achievement and story missions are
+// assigned the same way, once, and never expire.
+//
+// The service caches the mission templates and remembers which users it has
+// assigned missions to in the current day, so gameplay events only read the
+// user's missions in progress.
+type Service struct {
+	store store.Store
+	cfg   *config.Config
+
+	mu       sync.Mutex
+	game     *config.GameConfig // the game config schedule was built from
+	schedule *Schedule
+
+	templates   []*model.MissionTemplate
+	index       map[string]*model.MissionTemplate // templates by ID
+	templatesAt time.Time                         // when templates were loaded
+	period      string                            // the day assigned is for
+	assigned    map[string]bool                   // users with their missions for period
+}
+
+// NewService creates a mission service using the schedule in cfg.Game.Missions.
+// Changes to it are picked up when the game config is reloaded.
+func NewService(st store.Store, cfg *config.Config) (*Service, error) {
+	game := cfg.CurrentGame()
+	schedule, err := NewSchedule(game.Missions)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{store: st, cfg: cfg, game: game, schedule: schedule}, nil
+}
+
+// current returns the mission config in effect and its schedule. If the new
+// schedule of a reloaded config does not parse, the previous one is kept.
+func (s *Service) current() (config.MissionConfig, *Schedule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if game := s.cfg.CurrentGame(); game != s.game {
+		schedule, err := NewSchedule(game.Missions)
+		if err != nil {
+			logrus.WithError(err).Error("Keeping the previous mission schedule")
+			return s.game.Missions, s.schedule
+		}
+		s.game, s.schedule = game, schedule
+		// The new config may assign other templates
+		s.assigned = nil
+	}
+	return s.game.Missions, s.schedule
+}
+
+// Daily returns the start and end of the daily period that contains t, so
+// other daily limits can reset along with the daily missions
+func (s *Service) Daily(t time.Time) (start, end time.Time) {
+	_, schedule := s.current()
+	return schedule.Daily(t)
+}
+
+// Assign gives the user the missions due for the current periods and returns
+// all of their missions with templates attached, including expired ones that
+// have not been swept yet
+func (s *Service) Assign(ctx context.Context, userID string) ([]*model.Mission, error) {
+	now := time.Now()
+	missions, _, err := s.assign(ctx, s.store, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	// Outside a transaction the new missions are already saved
+	s.markAssigned(userID, now)
+	return missions, nil
+}
+
+// assign is Assign using st, which may be a transaction. It also reports
+// whether any mission was created.
+func (s *Service) assign(ctx context.Context, st store.Store, userID string, now time.Time) ([]*model.Mission, bool, error) {
+	templates, index, err := s.loadTemplates(ctx, st)
+	if err != nil {
+		return nil, false, err
+	}
+	missions, err := st.Missions().ListByUser(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	have := make(map[string]bool, len(missions))
+	for _, m := range missions {
+		m.Template = index[m.MissionTemplateID]
+		have[m.ID] = true
+	}
+
+	created := false
+	for _, due := range s.due(templates, index, now) {
+		id := missionID(userID, due.template.ID, due.period)
+		if have[id] {
+			continue
+		}
+
+		m := model.NewMission(id, userID, due.template.ID, due.template, due.expiresAt)
+		err := st.Missions().Create(ctx, m)
+		if errors.Is(err, store.ErrDuplicate) {
+			// Assigned concurrently by another request or server
+			if m, err = st.Missions().Get(ctx, id); err != nil {
+				return nil, false, err
+			}
+			m.Template = due.template
+		} else if err != nil {
+			return nil, false, err
+		} else {
+			created = true
+		}
+		missions = append(missions, m)
+	}
+	return missions, created, nil
+}
+
+// loadTemplates returns the mission templates and an index of them by ID,
+// reading them with st when the cached ones are older than templateTTL
+func (s *Service) loadTemplates(ctx context.Context, st store.Store) ([]*model.MissionTemplate, map[string]*model.MissionTemplate, error) {
+	s.mu.Lock()
+	if s.index != nil && time.Since(s.templatesAt) < templateTTL {
+		defer s.mu.Unlock()
+		return s.templates, s.index, nil
+	}
+	s.mu.Unlock()
+
+	templates, err := st.Missions().ListTemplates(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	index := make(map[string]*model.MissionTemplate, len(templates))
+	for _, mt := range templates {
+		index[mt.ID] = mt
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !sameTemplates(s.index, index) {
+		// Added templates have to be assigned to everyone again
+		s.assigned = nil
+		for _, mt := range templates {
+			if !trackable(mt) {
+				logrus.WithFields(logrus.Fields{
+					"mission_template_id": mt.ID,
+					"requirement_type":    mt.RequirementType,
+				}).Warn("Not assigning a mission template no event counts towards")
+			}
+		}
+	}
+	s.templates, s.index, s.templatesAt = templates, index, time.Now()
+	return templates, index, nil
+}
+
+// sameTemplates reports whether two template indexes have the same IDs
+func sameTemplates(a, b map[string]*model.MissionTemplate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id := range b {
+		if a[id] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// periodKey identifies the day that contains now. Weekly periods start with
+// a day, so a user assigned their missions in it has the weekly ones too.
+func (s *Service) periodKey(now time.Time) string {
+	_, schedule := s.current()
+	start, _ := schedule.Daily(now)
+	return start.UTC().Format(time.RFC3339)
+}
+
+// isAssigned reports whether the user was given their missions for the day
+// that contains now
+func (s *Service) isAssigned(userID string, now time.Time) bool {
+	period := s.periodKey(now)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.period == period && s.assigned[userID]
+}
+
+// markAssigned records that the user has their missions for the day that
+// contains now
+func (s *Service) markAssigned(userID string, now time.Time) {
+	period := s.periodKey(now)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.period != period || s.assigned == nil {
+		s.period, s.assigned = period, make(map[string]bool)
+	}
+	s.assigned[userID] = true
+}
+
+// dueMission is a template that should be assigned for a period
+type dueMission struct {
+	template  *model.MissionTemplate
+	period    string     // identifies the period in mission IDs
+	expiresAt *time.Time // nil for permanent missions
+}
+
+// due returns the missions every user should have at the given time
+func (s *Service) due(templates []*model.MissionTemplate, index map[string]*model.MissionTemplate, now time.Time) []dueMission {
+	var due []dueMission
+	cfg, schedule := s.current()
+
+	dayStart, dayEnd := schedule.Daily(now)
+	for _, mt := range rotate(pool(templates, index, model.MissionTypeDaily, cfg.DailyTemplates), cfg.DailyCount, dayStart) {
+		due = append(due, dueMission{template: mt, period: "d" + dayStart.UTC().Format(time.RFC3339), expiresAt: &dayEnd})
+	}
+
+	weekStart, weekEnd := schedule.Weekly(now)
+	for _, mt := range rotate(pool(templates, index, model.MissionTypeWeekly, cfg.WeeklyTemplates), cfg.WeeklyCount, weekStart) {
+		due = append(due, dueMission{template: mt, period: "w" + weekStart.UTC().Format(time.RFC3339), expiresAt: &weekEnd})
+	}
+
+	for _, mt := range templates {
+		if (mt.Type == model.MissionTypeAchievement || mt.Type == model.MissionTypeStory) && trackable(mt) {
+			due = append(due, dueMission{template: mt, period: "permanent"})
+		}
+	}
+	return due
+}
+
+// pool returns the configured templates, or every template of the type if
+// none are configured. Unknown configured IDs and templates that are not
+// trackable are skipped.
+func pool(templates []*model.MissionTemplate, index map[string]*model.MissionTemplate, typ model.MissionType, configured []string) []*model.MissionTemplate {
+	var out []*model.MissionTemplate
+	if len(configured) > 0 {
+		for _, id := range configured {
+			if mt := index[id]; mt != nil && trackable(mt) {
+				out = append(out, mt)
+			}
+		}
+		return out
+	}
+
+	for _, mt := range templates {
+		if mt.Type == typ && trackable(mt) {
+			out = append(out, mt)
+		}
+	}
+	return out
+}
+
+// rotate picks count templates for the period starting at start. The choice
+// only depends on the period, so every user and every server gets the same
+// missions, and it changes from one period to the next.
+func rotate(templates []*model.MissionTemplate, count int, start time.Time) []*model.MissionTemplate {
+	if count <= 0 || count >= len(templates) {
+		return templates
+	}
+
+	rng := rand.New(rand.NewSource(start.Unix()))
+	picked := make([]*model.MissionTemplate, 0, count)
+	for _, i := range rng.Perm(len(templates))[:count] {
+		picked = append(picked, templates[i])
+	}
+	return picked
+}
+
+// missionID derives the ID of the mission assigned to the user from the
+// template for the period
+func missionID(userID, templateID, period string) string {
+	sum := sha256.Sum256([]byte(userID + "\x00" + templateID + "\x00" + period))
+	return "mission_" + hex.EncodeToString(sum[:12])
+}
+
+// Expire deletes the missions whose period has ended, claimed or not, and
+// returns how many were deleted. It is safe to run on several servers at once.
+func (s *Service) Expire(ctx context.Context) (int64, error) {
+	return s.store.Missions().DeleteExpired(ctx, time.Now())
+}
+
+// Run sweeps expired missions at startup and after every daily reset until
+// ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	for {
+		wait := retryInterval
+		if n, err := s.Expire(ctx); err != nil {
+			logrus.WithError(err).Error("Error expiring missions")
+		} else {
+			if n > 0 {
+				logrus.WithField("count", n).Info("Expired missions")
+			}
+			_, schedule := s.current()
+			_, next := schedule.Daily(time.Now())
+			wait = time.Until(next)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package mission
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// countingStore counts the reads assigning missions takes
+type countingStore struct {
+	store.Store
+	missions *countingMissions
+}
+
+func (s countingStore) Missions() store.MissionRepository { return s.missions }
+
+type countingMissions struct {
+	store.MissionRepository
+	listByUser, listTemplates int
+}
+
+func (r *countingMissions) ListByUser(ctx context.Context, userID string) ([]*model.Mission, error) {
+	r.listByUser++
+	return r.MissionRepository.ListByUser(ctx, userID)
+}
+
+func (r *countingMissions) ListTemplates(ctx context.Context) ([]*model.MissionTemplate, error) {
+	r.listTemplates++
+	return r.MissionRepository.ListTemplates(ctx)
+}
+
+// newTestService returns a service over the sample data and a new user
+func newTestService(t *testing.T) (*Service, store.Store, string) {
+	t.Helper()
+	st := store.NewMemory()
+	st.LoadSampleData()
+	user := model.NewUser("user", "player", "player@example.com", "hash")
+	if err := st.Users().Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewService(st, config.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, st, user.ID
+}
+
+// progressOf returns the progress of the user's mission from the template
+func progressOf(t *testing.T, st store.Store, userID, templateID string) int {
+	t.Helper()
+	missions, err := st.Missions().ListByUser(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range missions {
+		if m.MissionTemplateID == templateID {
+			return m.CurrentValue
+		}
+	}
+	t.Fatalf("user has no mission from %s", templateID)
+	return 0
+}
+
+func TestHandleEventAdvancesMissions(t *testing.T) {
+	ctx := context.Background()
+	s, st, userID := newTestService(t)
+
+	win := events.BattleCompleted{UserID: userID, StageID: "stage_001", Victory: true}
+	for i := 0; i < 2; i++ {
+		err := st.WithTx(ctx, func(tx store.Store) error { return s.HandleEvent(ctx, tx, win) })
+		if err != nil {
+			t.Fatalf("HandleEvent: %v", err)
+		}
+	}
+	if got := progressOf(t, st, userID, "mission_template_001"); got != 2 {
+		t.Errorf("win battles progress = %d, want 2", got)
+	}
+	if got := progressOf(t, st, userID, "mission_template_003"); got != 0 {
+		t.Errorf("collect items progress = %d, want 0", got)
+	}
+}
+
+func TestHandleEventAssignsAgainAfterRollback(t *testing.T) {
+	ctx := context.Background()
+	s, st, userID := newTestService(t)
+	failure := errors.New("failure")
+	win := events.BattleCompleted{UserID: userID, StageID: "stage_001", Victory: true}
+
+	err := st.WithTx(ctx, func(tx store.Store) error {
+		if err := s.HandleEvent(ctx, tx, win); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithTx returned %v, want the error of fn", err)
+	}
+
+	// The missions assigned in the rolled back transaction are assigned again
+	if err := st.WithTx(ctx, func(tx store.Store) error { return s.HandleEvent(ctx, tx, win) }); err != nil {
+		t.Fatalf("HandleEvent: %v", err)
+	}
+	if got := progressOf(t, st, userID, "mission_template_001"); got != 1 {
+		t.Errorf("win battles progress = %d, want 1", got)
+	}
+}
+
+func TestHandleEventAssignsOncePerDay(t *testing.T) {
+	ctx := context.Background()
+	s, st, userID := newTestService(t)
+	counting := countingStore{Store: st, missions: &countingMissions{MissionRepository: st.Missions()}}
+	win := events.BattleCompleted{UserID: userID, StageID: "stage_001", Victory: true}
+
+	for i := 0; i < 5; i++ {
+		if err := s.HandleEvent(ctx, counting, win); err != nil {
+			t.Fatalf("HandleEvent: %v", err)
+		}
+	}
+	// The first event creates the missions and the second finds them all
+	if n := counting.missions.listByUser; n != 2 {
+		t.Errorf("listed all of the user's missions %d times, want 2", n)
+	}
+	if n := counting.missions.listTemplates; n != 1 {
+		t.Errorf("listed the templates %d times, want 1", n)
+	}
+	if got := progressOf(t, st, userID, "mission_template_001"); got != 3 {
+		t.Errorf("win battles progress = %d, want 3", got)
+	}
+}
+
+func TestProgressFromLevelUps(t *testing.T) {
+	ctx := context.Background()
+	levelUp := &model.MissionTemplate{RequirementType: model.RequirementLevelUpHero}
+	maxLevel := &model.MissionTemplate{RequirementType: model.RequirementMaxLevelHero}
+	maxLevelOfType := &model.MissionTemplate{RequirementType: model.RequirementMaxLevelHero, TargetID: "hero_type_002"}
+	event := func(from, to int) events.HeroLeveledUp {
+		return events.HeroLeveledUp{UserID: "user", HeroID: "hero", HeroTypeID: "hero_type_001", Levels: to - from, Level: to}
+	}
+
+	tests := []struct {
+		name string
+		mt   *model.MissionTemplate
+		e    events.HeroLeveledUp
+		want int
+	}{
+		{"levels gained", levelUp, event(3, 5), 2},
+		{"below the max level", maxLevel, event(3, model.MaxHeroLevel-1), 0},
+		{"reaching the max level", maxLevel, event(model.MaxHeroLevel-2, model.MaxHeroLevel), 1},
+		{"another hero type reaching the max level", maxLevelOfType, event(model.MaxHeroLevel-1, model.MaxHeroLevel), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := progress(ctx, nil, tt.e, tt.mt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("progress = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// extraTemplatesStore adds mission templates to the stored ones
+type extraTemplatesStore struct {
+	store.Store
+	extra []*model.MissionTemplate
+}
+
+func (s extraTemplatesStore) Missions() store.MissionRepository {
+	return extraTemplates{MissionRepository: s.Store.Missions(), extra: s.extra}
+}
+
+type extraTemplates struct {
+	store.MissionRepository
+	extra []*model.MissionTemplate
+}
+
+func (r extraTemplates) ListTemplates(ctx context.Context) ([]*model.MissionTemplate, error) {
+	templates, err := r.MissionRepository.ListTemplates(ctx)
+	return append(templates, r.extra...), err
+}
+
+func TestAssignSkipsUntrackableTemplates(t *testing.T) {
+	ctx := context.Background()
+	_, st, userID := newTestService(t)
+	upgrade := &model.MissionTemplate{ID: "mission_template_upgrade", Type: model.MissionTypeDaily,
+		RequirementType: model.RequirementUpgradeItems, TargetValue: 1}
+	s, err := NewService(extraTemplatesStore{Store: st, extra: []*model.MissionTemplate{upgrade}}, config.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missions, err := s.Assign(ctx, userID)
+	if err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	if len(missions) == 0 {
+		t.Fatal("no missions assigned")
+	}
+	for _, m := range missions {
+		if m.MissionTemplateID == upgrade.ID {
+			t.Errorf("assigned a mission from the upgrade_items template, which nothing counts towards")
+		}
+	}
+}
+
+func TestRotatePicksPerPeriod(t *testing.T) {
+	var templates []*model.MissionTemplate
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		templates = append(templates, &model.MissionTemplate{ID: id})
+	}
+	ids := func(picked []*model.MissionTemplate) string {
+		var s string
+		for _, mt := range picked {
+			s += mt.ID
+		}
+		return s
+	}
+	day := time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+	if got := rotate(templates, 0, day); len(got) != len(templates) {
+		t.Errorf("rotate with no count picked %d templates, want all %d", len(got), len(templates))
+	}
+	first := ids(rotate(templates, 2, day))
+	if len(first) != 2 || ids(rotate(templates, 2, day)) != first {
+		t.Errorf("rotate picked %q and then %q for the same period, want the same 2 templates", first, ids(rotate(templates, 2, day)))
+	}
+	changed := false
+	for i := 1; i <= 7 && !changed; i++ {
+		changed = ids(rotate(templates, 2, day.AddDate(0, 0, i))) != first
+	}
+	if !changed {
+		t.Error("rotate picked the same templates for a week, want them to change")
+	}
+}
+
+func TestAssignOncePerPeriod(t *testing.T) {
+	ctx := context.Background()
+	s, st, userID := newTestService(t)
+	// Monday and Tuesday of the same week
+	monday := time.Date(2026, time.January, 5, 12, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	byTemplate := func(missions []*model.Mission) map[string][]*model.Mission {
+		index := make(map[string][]*model.Mission)
+		for _, m := range missions {
+			index[m.MissionTemplateID] = append(index[m.MissionTemplateID], m)
+		}
+		return index
+	}
+
+	_, created, err := s.assign(ctx, st, userID, monday)
+	if err != nil || !created {
+		t.Fatalf("assign on Monday = %v, %v; want missions created", created, err)
+	}
+	missions, created, err := s.assign(ctx, st, userID, monday.Add(time.Hour))
+	if err != nil || created {
+		t.Fatalf("assign again on Monday = %v, %v; want nothing created", created, err)
+	}
+	index := byTemplate(missions)
+	if n := len(index["mission_template_001"]); n != 1 {
+		t.Errorf("%d daily missions on Monday, want 1", n)
+	}
+	if m := index["mission_template_001"][0]; m.ExpiresAt == nil || !m.ExpiresAt.Equal(time.Date(2026, time.January, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Monday's daily mission expires at %v, want the next reset", m.ExpiresAt)
+	}
+
+	missions, _, err = s.assign(ctx, st, userID, tuesday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index = byTemplate(missions)
+	if n := len(index["mission_template_001"]); n != 2 {
+		t.Errorf("%d daily missions on Tuesday, want Monday's and Tuesday's", n)
+	}
+	if n := len(index["mission_template_003"]); n != 1 {
+		t.Errorf("%d weekly missions on Tuesday, want the one assigned on Monday", n)
+	}
+}
+
+func TestExpireDeletesEndedMissions(t *testing.T) {
+	ctx := context.Background()
+	s, st, userID := newTestService(t)
+	ended, later := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+	missions := []*model.Mission{
+		model.NewMission(model.NewID("mission"), userID, "mission_template_001", nil, &ended),
+		model.NewMission(model.NewID("mission"), userID, "mission_template_002", nil, &later),
+		model.NewMission(model.NewID("mission"), userID, "mission_template_003", nil, nil),
+	}
+	missions[0].Status = model.MissionStatusClaimed
+	for _, m := range missions {
+		if err := st.Missions().Create(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := s.Expire(ctx)
+	if err != nil {
+		t.Fatalf("Expire: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expire deleted %d missions, want 1", n)
+	}
+	if _, err := st.Missions().Get(ctx, missions[0].ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("ended mission: got %v, want ErrNotFound", err)
+	}
+	for _, m := range missions[1:] {
+		if _, err := st.Missions().Get(ctx, m.ID); err != nil {
+			t.Errorf("mission that has not ended: %v", err)
+		}
+	}
+}
+
+func TestRunStopsWhenContextIsDone(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
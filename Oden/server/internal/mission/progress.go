@@ -0,0 +1,183 @@
+package mission
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/oden/internal/events"
+	"github.com/yourusername/oden/internal/model"
+	"github.com/yourusername/oden/internal/store"
+)
+
+// HandleEvent advances every in-progress, unexpired mission of the user that
+// the event counts towards, assigning the current missions first if this
+// server has not yet in the current day. It is an events.Handler: it runs in
+// the transaction of the action, so progress is saved if and only if the
+// action is.
+func (s *Service) HandleEvent(ctx context.Context, tx store.Store, e events.Event) error {
+	now := time.Now()
+	userID := e.User()
+	if !s.isAssigned(userID, now) {
+		_, created, err := s.assign(ctx, tx, userID, now)
+		if err != nil {
+			return err
+		}
+		// Missions created in tx are lost if it rolls back, so the user is
+		// only remembered once they had them all before
+		if !created {
+			s.markAssigned(userID, now)
+		}
+	}
+
+	_, index, err := s.loadTemplates(ctx, tx)
+	if err != nil {
+		return err
+	}
+	missions, err := tx.Missions().ListInProgress(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range missions {
+		mt := index[m.MissionTemplateID]
+		if mt == nil {
+			continue
+		}
+
+		amount, err := progress(ctx, tx, e, mt)
+		if err != nil {
+			return err
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		// Read the mission again with its row locked, so concurrent actions
+		// by the same user do not overwrite each other's progress
+		locked, err := tx.Missions().Get(ctx, m.ID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != model.MissionStatusInProgress {
+			continue
+		}
+		if mt.RequirementType == model.RequirementOwnHeroes {
+			// amount is the number of heroes owned, not an increment
+			amount -= locked.CurrentValue
+			if amount <= 0 {
+				continue
+			}
+		}
+
+		locked.Template = mt
+		locked.UpdateProgress(amount)
+		if err := tx.Missions().Update(ctx, locked); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// progress returns how far the event advances a mission with the template.
+// A template with a TargetID only counts events for that stage, enemy type,
+// hero, hero type or item template. For own_heroes missions it returns the
+// number of matching heroes the user owns.
+func progress(ctx context.Context, tx store.Store, e events.Event, mt *model.MissionTemplate) (int, error) {
+	switch e := e.(type) {
+	case events.BattleCompleted:
+		switch mt.RequirementType {
+		case model.RequirementCompleteBattles:
+			return count(matches(mt.TargetID, e.StageID)), nil
+		case model.RequirementWinBattles:
+			return count(e.Victory && matches(mt.TargetID, e.StageID)), nil
+		case model.RequirementKillEnemies:
+			n := 0
+			for _, typeID := range e.KilledEnemyTypes {
+				n += count(matches(mt.TargetID, typeID))
+			}
+			return n, nil
+		}
+	case events.HeroLeveledUp:
+		if !matches(mt.TargetID, e.HeroID, e.HeroTypeID) {
+			break
+		}
+		switch mt.RequirementType {
+		case model.RequirementLevelUpHero:
+			return e.Levels, nil
+		case model.RequirementMaxLevelHero:
+			// Counted once, when the hero reaches the max level
+			return count(e.Level >= model.MaxHeroLevel && e.Level-e.Levels < model.MaxHeroLevel), nil
+		}
+	case events.HeroesAcquired:
+		if mt.RequirementType == model.RequirementOwnHeroes {
+			return ownedHeroes(ctx, tx, e.UserID, mt.TargetID)
+		}
+	case events.ItemsCollected:
+		if mt.RequirementType == model.RequirementCollectItems && matches(mt.TargetID, e.ItemTemplateID) {
+			return e.Quantity, nil
+		}
+	case events.ItemEquipped:
+		if mt.RequirementType == model.RequirementEquipItems && matches(mt.TargetID, e.ItemTemplateID) {
+			return 1, nil
+		}
+	case events.GoldSpent:
+		if mt.RequirementType == model.RequirementSpendGold {
+			return e.Amount, nil
+		}
+	case events.GemsSpent:
+		if mt.RequirementType == model.RequirementSpendGems {
+			return e.Amount, nil
+		}
+	}
+	return 0, nil
+}
+
+// trackable reports whether progress can advance missions with the template.
+// No event counts towards upgrade_items, as items cannot be upgraded yet, so
+// such templates are never assigned.
+func trackable(mt *model.MissionTemplate) bool {
+	switch mt.RequirementType {
+	case model.RequirementCompleteBattles, model.RequirementWinBattles, model.RequirementKillEnemies,
+		model.RequirementLevelUpHero, model.RequirementOwnHeroes, model.RequirementMaxLevelHero,
+		model.RequirementCollectItems, model.RequirementEquipItems,
+		model.RequirementSpendGold, model.RequirementSpendGems:
+		return true
+	}
+	return false
+}
+
+// ownedHeroes counts the user's heroes of the target hero type, or all of
+// their heroes if there is no target
+func ownedHeroes(ctx context.Context, tx store.Store, userID, targetID string) (int, error) {
+	heroes, err := tx.Heroes().ListByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, hero := range heroes {
+		n += count(matches(targetID, hero.HeroTypeID))
+	}
+	return n, nil
+}
+
+// matches reports whether a mission target is empty or equal to one of ids
+func matches(targetID string, ids ...string) bool {
+	if targetID == "" {
+		return true
+	}
+	for _, id := range ids {
+		if id == targetID {
+			return true
+		}
+	}
+	return false
+}
+
+// count converts a match into a progress amount
+func count(ok bool) int {
+	if ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package mission
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+// Schedule computes the daily and weekly mission periods. Resets happen at
+// the same wall-clock time in the configured time zone every day, so a reset
+// at 04:00 stays at 04:00 across daylight saving changes.
+type Schedule struct {
+	loc     *time.Location
+	hour    int
+	minute  int
+	weekday time.Weekday
+}
+
+// NewSchedule parses the reset settings of the mission config
+func NewSchedule(cfg config.MissionConfig) (*Schedule, error) {
+	s := &Schedule{loc: time.UTC, weekday: time.Monday}
+
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("missions.timezone: %w", err)
+		}
+		s.loc = loc
+	}
+
+	if cfg.ResetTime != "" {
+		t, err := time.Parse("15:04", cfg.ResetTime)
+		if err != nil {
+			return nil, fmt.Errorf("missions.reset_time must be HH:MM: %q", cfg.ResetTime)
+		}
+		s.hour, s.minute = t.Hour(), t.Minute()
+	}
+
+	if cfg.WeeklyResetDay != "" {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(cfg.WeeklyResetDay, d.String()) {
+				s.weekday, found = d, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("missions.weekly_reset_day must be a day of the week: %q", cfg.WeeklyResetDay)
+		}
+	}
+	return s, nil
+}
+
+// Daily returns the start and end of the daily period that contains t
+func (s *Schedule) Daily(t time.Time) (start, end time.Time) {
+	local := t.In(s.loc)
+	start = time.Date(local.Year(), local.Month(), local.Day(), s.hour, s.minute, 0, 0, s.loc)
+	if local.Before(start) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Weekly returns the start and end of the weekly period that contains t
+func (s *Schedule) Weekly(t time.Time) (start, end time.Time) {
+	day, _ := s.Daily(t)
+	back := (int(day.Weekday()) - int(s.weekday) + 7) % 7
+	start = day.AddDate(0, 0, -back)
+	return start, start.AddDate(0, 0, 7)
+}
@@ -0,0 +1,94 @@
+package mission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+func mustSchedule(t *testing.T, cfg config.MissionConfig) *Schedule {
+	t.Helper()
+	s, err := NewSchedule(cfg)
+	if err != nil {
+		t.Fatalf("NewSchedule: %v", err)
+	}
+	return s
+}
+
+func TestScheduleDaily(t *testing.T) {
+	s := mustSchedule(t, config.MissionConfig{ResetTime: "04:00", Timezone: "America/New_York"})
+	ny := s.loc
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, ny)
+	}
+
+	tests := []struct {
+		name       string
+		t          time.Time
+		start, end time.Time
+	}{
+		{"after the reset", at(time.January, 10, 9), at(time.January, 10, 4), at(time.January, 11, 4)},
+		{"before the reset", at(time.January, 10, 3), at(time.January, 9, 4), at(time.January, 10, 4)},
+		{"at the reset", at(time.January, 10, 4), at(time.January, 10, 4), at(time.January, 11, 4)},
+		// Clocks go forward on March 8, so that day is 23 hours long and
+		// still resets at 04:00
+		{"across daylight saving", at(time.March, 7, 12), at(time.March, 7, 4), at(time.March, 8, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := s.Daily(tt.t)
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("Daily(%v) = %v, %v; want %v, %v", tt.t, start, end, tt.start, tt.end)
+			}
+		})
+	}
+
+	start, end := s.Daily(at(time.March, 7, 12))
+	if got := end.Sub(start); got != 23*time.Hour {
+		t.Errorf("the day clocks go forward lasts %v, want 23h", got)
+	}
+}
+
+func TestScheduleWeekly(t *testing.T) {
+	s := mustSchedule(t, config.MissionConfig{ResetTime: "04:00", WeeklyResetDay: "Monday"})
+	// January 5, 2026 is a Monday
+	monday := time.Date(2026, time.January, 5, 4, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		t     time.Time
+		start time.Time
+	}{
+		{"midweek", time.Date(2026, time.January, 7, 12, 0, 0, 0, time.UTC), monday},
+		{"at the reset", monday, monday},
+		{"Monday before the reset", time.Date(2026, time.January, 12, 3, 0, 0, 0, time.UTC), monday},
+		{"Sunday", time.Date(2026, time.January, 11, 23, 0, 0, 0, time.UTC), monday},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := s.Weekly(tt.t)
+			if !start.Equal(tt.start) || !end.Equal(tt.start.AddDate(0, 0, 7)) {
+				t.Errorf("Weekly(%v) = %v, %v; want the week from %v", tt.t, start, end, tt.start)
+			}
+		})
+	}
+}
+
+func TestNewScheduleRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.MissionConfig
+	}{
+		{"time zone", config.MissionConfig{Timezone: "Nowhere/Special"}},
+		{"reset time", config.MissionConfig{ResetTime: "25:00"}},
+		{"reset day", config.MissionConfig{WeeklyResetDay: "Funday"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSchedule(tt.cfg); err == nil {
+				t.Errorf("NewSchedule(%+v) succeeded, want an error", tt.cfg)
+			}
+		})
+	}
+}
@@ -18,6 +18,9 @@ type BattleResult struct {
 	
 	// Battle log for client-side visualization
 	BattleLog []BattleTurn `json:"battle_log,omitempty"`
+	
+	// Seed of the simulation, so the battle can be replayed
+	Seed      int64     `json:"seed"`
 }
 
 // BattleTurn represents a turn in a battle
@@ -66,6 +69,7 @@ type Stage struct {
 	Enemy3      string `json:"enemy_3,omitempty"`
 	Enemy4      string `json:"enemy_4,omitempty"`
 	Enemy5      string `json:"enemy_5,omitempty"`
+	EnemyLevel  int    `json:"enemy_level"`
 	GoldReward  int    `json:"gold_reward"`
 	ExpReward   int    `json:"exp_reward"`
 	
@@ -73,19 +77,54 @@ type Stage struct {
 	Enemies    []*Enemy `json:"enemies,omitempty"`
 }
 
+// EnemyType represents a kind of enemy in the enemy catalogue
+type EnemyType struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	BaseHP      int     `json:"base_hp"`
+	BaseATK     int     `json:"base_atk"`
+	HPPerLevel  int     `json:"hp_per_level"`
+	ATKPerLevel int     `json:"atk_per_level"`
+	ImageURL    string  `json:"image_url,omitempty"`
+	Skills      []Skill `json:"skills,omitempty"`
+}
+
 // Enemy represents an enemy in a stage
 type Enemy struct {
 	ID          string `json:"id"`
 	TypeID      string `json:"type_id"`
 	Name        string `json:"name"`
+	Level       int    `json:"level"`
 	HP          int    `json:"hp"`
 	ATK         int    `json:"atk"`
 	Description string `json:"description,omitempty"`
+	Skills      []Skill `json:"skills,omitempty"`
 	
 	// Runtime battle state
 	CurrentHP   int    `json:"-"`
 }
 
+// NewEnemy creates an enemy of this type with its stats scaled to the level
+func (et *EnemyType) NewEnemy(id string, level int) *Enemy {
+	if level < 1 {
+		level = 1
+	}
+	
+	hp := et.BaseHP + et.HPPerLevel*(level-1)
+	return &Enemy{
+		ID:          id,
+		TypeID:      et.ID,
+		Name:        et.Name,
+		Level:       level,
+		HP:          hp,
+		ATK:         et.BaseATK + et.ATKPerLevel*(level-1),
+		Description: et.Description,
+		Skills:      et.Skills,
+		CurrentHP:   hp,
+	}
+}
+
 // GetEnemyIDs returns all enemy IDs in the stage
 func (s *Stage) GetEnemyIDs() []string {
 	var ids []string
@@ -155,6 +194,7 @@ func (br *BattleResult) ToBattleResponse() map[string]interface{} {
 		"battle_id":  br.ID,
 		"result":     br.Result,
 		"battle_log": br.BattleLog,
+		"seed":       br.Seed,
 		"rewards":    br.Rewards,
 	}
 } 
\ No newline at end of file
@@ -1,7 +1,6 @@
 package model
 
 import (
-	"encoding/json"
 	"time"
 )
 
@@ -85,6 +84,8 @@ type SummonResult struct {
 	
 	// For tracking pity system
 	PullNumber   int    `json:"pull_number"`
+	// Seed the summon's pulls were rolled with, so they can be audited
+	Seed         int64  `json:"seed"`
 }
 
 // NewSummonResult creates a new summon result
@@ -135,16 +136,14 @@ func NewSummonSession(id, userID, bannerID string) *SummonSession {
 	}
 }
 
-// CanClaimFreeSummon checks if the user can claim a free summon
-func (s *SummonSession) CanClaimFreeSummon() bool {
+// CanClaimFreeSummon checks if the user can claim a free summon in the day
+// that started at dayStart
+func (s *SummonSession) CanClaimFreeSummon(dayStart time.Time) bool {
 	if s.LastFreeSummon == nil {
 		return true
 	}
 	
-	lastFreeDay := s.LastFreeSummon.Truncate(24 * time.Hour)
-	today := time.Now().Truncate(24 * time.Hour)
-	
-	return today.After(lastFreeDay)
+	return s.LastFreeSummon.Before(dayStart)
 }
 
 // UpdateFreeSummon updates the last free summon time
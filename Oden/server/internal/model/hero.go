@@ -2,6 +2,9 @@ package model
 
 import "time"
 
+// MaxHeroLevel is the highest level a hero can reach
+const MaxHeroLevel = 100
+
 // HeroType represents a template for heroes
 type HeroType struct {
 	ID          string `json:"id"`
@@ -38,6 +41,7 @@ type Hero struct {
 	HP         int       `json:"hp,omitempty"`
 	ATK        int       `json:"atk,omitempty"`
 	Skills     []Skill   `json:"skills,omitempty"`
+	Equipment  []*Item   `json:"equipment,omitempty"` // Equipped items with their templates
 }
 
 // NewHero creates a new hero instance
@@ -52,7 +56,8 @@ func NewHero(id, userID, heroTypeID string) *Hero {
 	}
 }
 
-// CalculateStats calculates the hero's stats based on level and base stats
+// CalculateStats calculates the hero's stats based on level and base stats,
+// plus the flat bonuses of the equipment in h.Equipment
 func (h *Hero) CalculateStats() {
 	if h.HeroType == nil {
 		return
@@ -63,15 +68,36 @@ func (h *Hero) CalculateStats() {
 	
 	h.HP = int(float64(h.HeroType.BaseHP) * levelFactor)
 	h.ATK = int(float64(h.HeroType.BaseATK) * levelFactor)
+	
+	for _, item := range h.Equipment {
+		if item.Template != nil {
+			h.HP += item.Template.HPBonus
+			h.ATK += item.Template.ATKBonus
+		}
+	}
+}
+
+// EquippedInSlot returns the item the hero has equipped in the slot, or nil
+func (h *Hero) EquippedInSlot(slot EquipmentSlot) *Item {
+	for _, item := range h.Equipment {
+		if item.Template != nil && item.Template.Slot == slot {
+			return item
+		}
+	}
+	return nil
 }
 
-// AddExperience adds experience to the hero and levels up if necessary
+// AddExperience adds experience to the hero and levels up if necessary,
+// up to MaxHeroLevel
 func (h *Hero) AddExperience(amount int) bool {
 	oldLevel := h.Level
 	h.Experience += amount
 	
 	// Simple leveling formula: 100 XP per level
 	h.Level = 1 + (h.Experience / 100)
+	if h.Level > MaxHeroLevel {
+		h.Level = MaxHeroLevel
+	}
 	
 	// If level changed, recalculate stats
 	if h.Level != oldLevel {
@@ -92,6 +118,7 @@ type HeroWithDetails struct {
 	HP         int       `json:"hp"`         // Calculated
 	ATK        int       `json:"atk"`        // Calculated
 	Skills     []Skill   `json:"skills"`     // From HeroType
+	Equipment  []*ItemWithTemplate `json:"equipment,omitempty"`
 }
 
 // ToHeroWithDetails converts a Hero to HeroWithDetails
@@ -110,7 +137,7 @@ func (h *Hero) ToHeroWithDetails() *HeroWithDetails {
 		h.CalculateStats()
 	}
 	
-	return &HeroWithDetails{
+	details := &HeroWithDetails{
 		ID:         h.ID,
 		HeroTypeID: h.HeroTypeID,
 		Name:       h.HeroType.Name,
@@ -120,4 +147,8 @@ func (h *Hero) ToHeroWithDetails() *HeroWithDetails {
 		ATK:        h.ATK,
 		Skills:     h.Skills,
 	}
+	for _, item := range h.Equipment {
+		details.Equipment = append(details.Equipment, item.ToItemWithTemplate())
+	}
+	return details
 } 
\ No newline at end of file
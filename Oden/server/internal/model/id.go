@@ -0,0 +1,17 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewID returns a random ID with a readable type prefix, e.g. "hero_3f2a9c...".
+// The random part is 96 bits so that prefixed IDs still fit the VARCHAR(36)
+// primary keys of the schema.
+func NewID(prefix string) string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic("model: cannot read random bytes: " + err.Error())
+	}
+	return prefix + "_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a request a user sent with an Idempotency-Key header, and
+// the response to it once there is one. Retries with the same key are
+// answered with that response instead of running the request again.
+type IdempotencyKey struct {
+	UserID      string    `json:"user_id"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"` // SHA-256 of the method, path and body
+	StatusCode  int       `json:"status_code"`  // 0 while the request is still running
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// IsComplete reports whether the response to the request has been stored
+func (k *IdempotencyKey) IsComplete() bool {
+	return k.StatusCode != 0
+}
+
+// IsAbandoned reports whether the request of the key has run for longer than
+// lease without a response, so the server running it has likely stopped
+func (k *IdempotencyKey) IsAbandoned(at time.Time, lease time.Duration) bool {
+	return !k.IsComplete() && !at.Before(k.CreatedAt.Add(lease))
+}
+
+// IsExpired reports whether the key may be used for a new request at the
+// given time
+func (k *IdempotencyKey) IsExpired(at time.Time) bool {
+	return !at.Before(k.ExpiresAt)
+}
@@ -33,6 +33,13 @@ const (
 	EquipmentSlotAccessory EquipmentSlot = "accessory"
 )
 
+// Consumable effects applied when an item is used
+const (
+	ItemEffectGold    = "gold"     // Grants EffectValue gold
+	ItemEffectGems    = "gems"     // Grants EffectValue premium currency
+	ItemEffectHeroExp = "hero_exp" // Grants EffectValue experience to a hero
+)
+
 // ItemTemplate represents a template for item types in the game
 type ItemTemplate struct {
 	ID          string     `json:"id"`
@@ -105,11 +112,15 @@ func (i *Item) IsMaterial() bool {
 	return false
 }
 
-// EquipToHero equips the item to a hero
+// EquipToHero equips the item to a hero. The caller is responsible for
+// freeing the slot on the hero first.
 func (i *Item) EquipToHero(heroID string) error {
 	if !i.IsEquipment() {
 		return ErrNotEquipment
 	}
+	if i.Template.Slot == "" {
+		return ErrNoEquipmentSlot
+	}
 	
 	i.EquippedToHeroID = heroID
 	return nil
@@ -123,6 +134,7 @@ func (i *Item) UnequipFromHero() {
 // Errors for item operations
 var (
 	ErrNotEquipment = CustomError{Message: "item is not equipment", Code: "invalid_item_type"}
+	ErrNoEquipmentSlot = CustomError{Message: "item has no equipment slot", Code: "invalid_item_type"}
 )
 
 // CustomError represents a custom error with message and code
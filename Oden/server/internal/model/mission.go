@@ -1,7 +1,6 @@
 package model
 
 import (
-	"encoding/json"
 	"time"
 )
 
@@ -64,7 +63,7 @@ type MissionTemplate struct {
 	GoldReward      int      `json:"gold_reward"`
 	GemsReward      int      `json:"gems_reward"`
 	ExperienceReward int      `json:"experience_reward"`
-	ItemRewards     []string `json:"item_rewards,omitempty"` // ItemTemplate IDs
+	ItemRewards     []ItemReward `json:"item_rewards,omitempty"` // ItemTemplate IDs and quantities
 }
 
 // Mission represents a mission assigned to a user
@@ -83,31 +82,20 @@ type Mission struct {
 	Template        *MissionTemplate `json:"template,omitempty"`
 }
 
-// NewMission creates a new mission instance
-func NewMission(id, userID, missionTemplateID string, template *MissionTemplate) *Mission {
-	mission := &Mission{
+// NewMission creates a new mission instance. expiresAt is the end of the
+// daily or weekly period the mission belongs to, or nil for missions that
+// never expire.
+func NewMission(id, userID, missionTemplateID string, template *MissionTemplate, expiresAt *time.Time) *Mission {
+	return &Mission{
 		ID:                id,
 		UserID:            userID,
 		MissionTemplateID: missionTemplateID,
 		Status:            MissionStatusInProgress,
 		CurrentValue:      0,
 		AssignedAt:        time.Now(),
+		ExpiresAt:         expiresAt,
 		Template:          template,
 	}
-	
-	// Set expiration for time-limited missions
-	if template != nil {
-		switch template.Type {
-		case MissionTypeDaily:
-			expiry := time.Now().Add(24 * time.Hour).Truncate(24 * time.Hour)
-			mission.ExpiresAt = &expiry
-		case MissionTypeWeekly:
-			expiry := time.Now().Add(7 * 24 * time.Hour).Truncate(24 * time.Hour)
-			mission.ExpiresAt = &expiry
-		}
-	}
-	
-	return mission
 }
 
 // UpdateProgress updates the mission progress
@@ -173,8 +161,8 @@ type MissionRewards struct {
 
 // ItemReward represents an item reward for a mission
 type ItemReward struct {
-	ItemID   string `json:"item_id"`
-	Name     string `json:"name"`
+	ItemID   string `json:"item_id"`  // ItemTemplate ID
+	Name     string `json:"name"`     // Filled in by the service layer
 	Quantity int    `json:"quantity"`
 }
 
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+// Session is one login of a user. Access tokens name the session they were
+// issued for, and its refresh tokens form a single token family: every
+// refresh exchanges the current token for a new one. Revoking the session
+// ends the whole family.
+//
+// The session also records where the login came from. NewDevice and
+// NewCountry flag logins that look unlike the user's earlier ones.
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	Country    string     `json:"country,omitempty"` // ISO 3166-1 alpha-2 code, if known
+	NewDevice  bool       `json:"new_device"`
+	NewCountry bool       `json:"new_country"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"` // last login or refresh
+	ExpiresAt  time.Time  `json:"expires_at"`   // when the current refresh token expires
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// RefreshToken is a refresh token issued for a session. Only a hash of the
+// token is stored, so a leaked table cannot be used to refresh sessions.
+type RefreshToken struct {
+	Hash      string     `json:"-"`
+	SessionID string     `json:"session_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"` // set once exchanged for a new token
+}
+
+// NewSession creates a new session that lasts until expiresAt unless refreshed
+func NewSession(id, userID string, expiresAt time.Time) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         id,
+		UserID:     userID,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// IsActive reports whether the session can still be refreshed at the given time
+func (s *Session) IsActive(at time.Time) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt)
+}
+
+// Revoke ends the session. Revoking a revoked session keeps the first time.
+func (s *Session) Revoke(at time.Time) {
+	if s.RevokedAt == nil {
+		s.RevokedAt = &at
+	}
+}
+
+// FlagUnfamiliar flags the login as coming from a new device if none of the
+// previous sessions had its user agent, and from a new country if its
+// country differs from the latest known one. previous must be ordered newest
+// first; without previous sessions there is nothing to compare with.
+func (s *Session) FlagUnfamiliar(previous []*Session) {
+	if len(previous) == 0 {
+		return
+	}
+
+	s.NewDevice = true
+	for _, p := range previous {
+		if p.UserAgent == s.UserAgent {
+			s.NewDevice = false
+			break
+		}
+	}
+
+	for _, p := range previous {
+		if p.Country != "" {
+			s.NewCountry = s.Country != "" && s.Country != p.Country
+			break
+		}
+	}
+}
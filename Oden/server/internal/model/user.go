@@ -2,14 +2,22 @@ package model
 
 import "time"
 
-// User represents a user in the system
+// User represents a user in the system. Guests have no username, email or
+// password until they upgrade their account; until then they are identified
+// by the device they play on.
 type User struct {
-	ID           string    `json:"id"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"` // Never expose password hash in JSON
-	CreatedAt    time.Time `json:"created_at"`
-	LastLogin    time.Time `json:"last_login"`
+	ID              string     `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	PasswordHash    string     `json:"-"` // Never expose password hash in JSON
+	DeviceID        string     `json:"-"` // Only set for guests
+	CreatedAt       time.Time  `json:"created_at"`
+	LastLogin       time.Time  `json:"last_login"`
+	// FailedLogins counts wrong passwords since the last successful login.
+	// Once there are too many, password logins are refused until LockedUntil.
+	FailedLogins int        `json:"-"`
+	LockedUntil  *time.Time `json:"-"`
 }
 
 // PlayerResources represents a player's in-game resources
@@ -17,6 +25,10 @@ type PlayerResources struct {
 	UserID           string    `json:"user_id"`
 	Gold             int       `json:"gold"`
 	PremiumCurrency  int       `json:"premium_currency"`
+	SummonTickets    int       `json:"summon_tickets"`
+	SpecialTickets   int       `json:"special_tickets"`
+	AccountLevel     int       `json:"account_level"`
+	AccountExperience int      `json:"account_experience"`
 	LastIdleClaim    time.Time `json:"last_idle_claim"`
 }
 
@@ -33,12 +45,73 @@ func NewUser(id, username, email, passwordHash string) *User {
 	}
 }
 
+// NewGuestUser creates a new guest user tied to a device
+func NewGuestUser(id, deviceID string) *User {
+	now := time.Now()
+	return &User{
+		ID:        id,
+		DeviceID:  deviceID,
+		CreatedAt: now,
+		LastLogin: now,
+	}
+}
+
+// IsGuest reports whether the user has not registered credentials yet
+func (u *User) IsGuest() bool {
+	return u.PasswordHash == ""
+}
+
+// Upgrade turns a guest into a registered user with the given credentials.
+// The user keeps its ID, and with it everything it owns; the device no
+// longer logs in to the account.
+func (u *User) Upgrade(username, email, passwordHash string) {
+	u.Username = username
+	u.Email = email
+	u.PasswordHash = passwordHash
+	u.DeviceID = ""
+	u.EmailVerifiedAt = nil
+}
+
+// IsLocked reports whether password logins are refused at the given time
+func (u *User) IsLocked(at time.Time) bool {
+	return u.LockedUntil != nil && at.Before(*u.LockedUntil)
+}
+
+// FailLogin counts a wrong password. If lockFor is positive, password logins
+// are refused for that long from the given time.
+func (u *User) FailLogin(at time.Time, lockFor time.Duration) {
+	u.FailedLogins++
+	if lockFor > 0 {
+		until := at.Add(lockFor)
+		u.LockedUntil = &until
+	}
+}
+
+// ResetFailedLogins forgets the wrong passwords, unlocking the account
+func (u *User) ResetFailedLogins() {
+	u.FailedLogins = 0
+	u.LockedUntil = nil
+}
+
 // NewPlayerResources creates a new player resources instance
 func NewPlayerResources(userID string, gold, premiumCurrency int) *PlayerResources {
 	return &PlayerResources{
 		UserID:          userID,
 		Gold:            gold,
 		PremiumCurrency: premiumCurrency,
+		AccountLevel:    1,
 		LastIdleClaim:   time.Now(),
 	}
+}
+
+// AddAccountExperience adds experience to the player's account and levels it
+// up if necessary. It reports whether the account gained a level.
+func (r *PlayerResources) AddAccountExperience(amount int) bool {
+	oldLevel := r.AccountLevel
+	r.AccountExperience += amount
+	
+	// Same formula as heroes: 100 XP per level
+	r.AccountLevel = 1 + (r.AccountExperience / 100)
+	
+	return r.AccountLevel != oldLevel
 } 
\ No newline at end of file
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often ended windows are removed from a memory store
+const sweepInterval = time.Minute
+
+// window is the request count of a key in its current window
+type window struct {
+	count int
+	reset time.Time
+}
+
+// MemoryStore keeps the counts in memory, so each instance limits requests
+// on its own. It is safe for concurrent use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*window
+	nextSweep time.Time
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		windows:   make(map[string]*window),
+		nextSweep: time.Now().Add(sweepInterval),
+	}
+}
+
+// Hit counts a request for key
+func (s *MemoryStore) Hit(ctx context.Context, key string, length time.Duration) (int, time.Time, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop ended windows now and then, so clients that went away do not
+	// keep using memory
+	if now.After(s.nextSweep) {
+		for k, w := range s.windows {
+			if !now.Before(w.reset) {
+				delete(s.windows, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &window{reset: now.Add(length)}
+		s.windows[key] = w
+	}
+	w.count++
+	return w.count, w.reset, nil
+}
+
+// Close does nothing; the counts are garbage collected with the store
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,74 @@
+// Package ratelimit limits how often a client may make requests, so scripts
+// cannot brute-force passwords or flood the game with actions.
+//
+// Requests are counted per key in fixed windows, such as 10 requests per
+// minute. The counts live in a Store: in memory for a single instance, or in
+// Redis so that every instance shares them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+// Store counts requests in fixed windows
+type Store interface {
+	// Hit counts a request for key in its current window of length window,
+	// which starts with the first request, and returns the number of requests
+	// in the window so far and when it ends
+	Hit(ctx context.Context, key string, window time.Duration) (count int, reset time.Time, err error)
+	// Close releases the resources of the store
+	Close() error
+}
+
+// NewStore creates the store selected by the config
+func NewStore(cfg config.RateLimitConfig) (Store, error) {
+	switch cfg.Store {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		return NewRedisStore(cfg.Redis)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+}
+
+// Limiter allows a number of requests per key in every window
+type Limiter struct {
+	store    Store
+	name     string
+	requests int
+	window   time.Duration
+}
+
+// NewLimiter creates a limiter for the limit. name keeps the counts of
+// limiters that share a store apart.
+func NewLimiter(store Store, name string, limit config.RateLimit) *Limiter {
+	return &Limiter{
+		store:    store,
+		name:     name,
+		requests: limit.Requests,
+		window:   time.Duration(limit.Window) * time.Second,
+	}
+}
+
+// Allow counts a request for key and reports whether it is within the limit.
+// If it is not, it also returns how long until the client may try again.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	count, reset, err := l.store.Hit(ctx, l.name+":"+key, l.window)
+	if err != nil {
+		return false, 0, err
+	}
+	if count <= l.requests {
+		return true, 0, nil
+	}
+
+	retryAfter := time.Until(reset)
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	return false, retryAfter, nil
+}
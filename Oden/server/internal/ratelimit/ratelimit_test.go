@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yourusername/oden/internal/config"
+)
+
+func TestLimiterAllowsRequestsPerWindow(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(NewMemoryStore(), "test", config.RateLimit{Requests: 3, Window: 60})
+
+	for i := 1; i <= 3; i++ {
+		ok, _, err := l.Allow(ctx, "client")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("request %d refused, want 3 allowed", i)
+		}
+	}
+
+	ok, retryAfter, err := l.Allow(ctx, "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("request 4 allowed, want it refused")
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Errorf("retry after %v, want the rest of the minute", retryAfter)
+	}
+
+	// Other clients have their own count
+	if ok, _, _ := l.Allow(ctx, "other"); !ok {
+		t.Error("request of another client refused")
+	}
+}
+
+func TestLimitersSharingAStoreCountApart(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	login := NewLimiter(store, "auth", config.RateLimit{Requests: 1, Window: 60})
+	game := NewLimiter(store, "user", config.RateLimit{Requests: 1, Window: 60})
+
+	if ok, _, _ := login.Allow(ctx, "client"); !ok {
+		t.Fatal("first auth request refused")
+	}
+	if ok, _, _ := game.Allow(ctx, "client"); !ok {
+		t.Error("first user request refused after an auth request with the same key")
+	}
+}
+
+func TestMemoryStoreStartsNewWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	const window = 20 * time.Millisecond
+
+	count, reset, err := store.Hit(ctx, "client", window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("first count = %d, want 1", count)
+	}
+	if count, again, _ := store.Hit(ctx, "client", window); count != 2 || !again.Equal(reset) {
+		t.Errorf("second hit = %d ending %v, want 2 in the same window ending %v", count, again, reset)
+	}
+
+	time.Sleep(time.Until(reset) + time.Millisecond)
+	count, next, err := store.Hit(ctx, "client", window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || !next.After(reset) {
+		t.Errorf("hit after the window = %d ending %v, want 1 in a window after %v", count, next, reset)
+	}
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yourusername/oden/internal/config"
+)
+
+// keyPrefix starts the Redis key of every count, keeping them apart from
+// other data in the same database
+const keyPrefix = "oden:ratelimit:"
+
+// hitScript counts a request and starts the window with the first one. It
+// runs atomically, so concurrent requests on different instances cannot
+// leave a count without an expiry.
+var hitScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisStore keeps the counts in Redis, so every instance of the server
+// shares them. Each count expires with its window.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the Redis server of the config
+func NewRedisStore(cfg config.RedisConfig) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error connecting to Redis: %w", err)
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Hit counts a request for key
+func (s *RedisStore) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	res, err := hitScript.Run(ctx, s.client, []string{keyPrefix + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("error counting request in Redis: %w", err)
+	}
+	if len(res) != 2 {
+		return 0, time.Time{}, fmt.Errorf("unexpected reply from Redis: %v", res)
+	}
+	return int(res[0]), time.Now().Add(time.Duration(res[1]) * time.Millisecond), nil
+}
+
+// Close closes the connections to Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -3,24 +3,38 @@ package storage
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/yourusername/oden/internal/config"
+	"github.com/yourusername/oden/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates the spans of storage calls
+var tracer = otel.Tracer("github.com/yourusername/oden/internal/storage")
+
 // Client represents a storage client
 type Client struct {
 	client     *minio.Client
+	transport  *http.Transport
 	bucketName string
 }
 
 // NewClient creates a new storage client
 func NewClient(cfg *config.Config) (*Client, error) {
-	// Initialize MinIO client
+	// Initialize MinIO client, keeping its transport so Close can release connections
+	transport, err := minio.DefaultTransport(cfg.Storage.UseSSL)
+	if err != nil {
+		return nil, fmt.Errorf("error creating MinIO transport: %w", err)
+	}
 	minioClient, err := minio.New(cfg.Storage.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.Storage.AccessKey, cfg.Storage.SecretKey, ""),
-		Secure: cfg.Storage.UseSSL,
+		Creds:     credentials.NewStaticV4(cfg.Storage.AccessKey, cfg.Storage.SecretKey, ""),
+		Secure:    cfg.Storage.UseSSL,
+		Transport: transport,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating MinIO client: %w", err)
@@ -59,15 +73,35 @@ func NewClient(cfg *config.Config) (*Client, error) {
 
 	return &Client{
 		client:     minioClient,
+		transport:  transport,
 		bucketName: cfg.Storage.Bucket,
 	}, nil
 }
 
+// Ping checks that the storage server can be reached and the bucket exists
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, span := c.startSpan(ctx, "storage.Ping", "")
+	exists, err := c.client.BucketExists(ctx, c.bucketName)
+	if err == nil && !exists {
+		err = fmt.Errorf("bucket %q does not exist", c.bucketName)
+	}
+	tracing.End(span, err)
+	return err
+}
+
+// Close closes the idle connections to the storage server. Uploads still
+// running keep their connections until they finish.
+func (c *Client) Close() {
+	c.transport.CloseIdleConnections()
+}
+
 // UploadFile uploads a file to the storage
-func (c *Client) UploadFile(objectName string, filePath string, contentType string) error {
-	_, err := c.client.FPutObject(context.Background(), c.bucketName, objectName, filePath, minio.PutObjectOptions{
+func (c *Client) UploadFile(ctx context.Context, objectName string, filePath string, contentType string) error {
+	ctx, span := c.startSpan(ctx, "storage.UploadFile", objectName)
+	_, err := c.client.FPutObject(ctx, c.bucketName, objectName, filePath, minio.PutObjectOptions{
 		ContentType: contentType,
 	})
+	tracing.End(span, err)
 	if err != nil {
 		return fmt.Errorf("error uploading file: %w", err)
 	}
@@ -80,10 +114,22 @@ func (c *Client) GetFileURL(objectName string) string {
 }
 
 // DeleteFile deletes a file from the storage
-func (c *Client) DeleteFile(objectName string) error {
-	err := c.client.RemoveObject(context.Background(), c.bucketName, objectName, minio.RemoveObjectOptions{})
+func (c *Client) DeleteFile(ctx context.Context, objectName string) error {
+	ctx, span := c.startSpan(ctx, "storage.DeleteFile", objectName)
+	err := c.client.RemoveObject(ctx, c.bucketName, objectName, minio.RemoveObjectOptions{})
+	tracing.End(span, err)
 	if err != nil {
 		return fmt.Errorf("error deleting file: %w", err)
 	}
 	return nil
+}
+
+// startSpan starts the span of a call to the storage server about an object,
+// or about the bucket if objectName is empty
+func (c *Client) startSpan(ctx context.Context, name, objectName string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("storage.bucket", c.bucketName)}
+	if objectName != "" {
+		attrs = append(attrs, attribute.String("storage.object", objectName))
+	}
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
 } 
\ No newline at end of file
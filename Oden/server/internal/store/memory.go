@@ -0,0 +1,1207 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// Memory is a Store that keeps everything in process memory. It is meant for
+// local development and tests; all data is lost when the process exits.
+//
+// A single mutex guards the data. WithTx holds it for the whole transaction
+// and restores a snapshot if fn fails, so transactions are fully serialized.
+type Memory struct {
+	mu   sync.Mutex
+	data *memData
+}
+
+type memData struct {
+	users            map[string]*model.User
+	resources        map[string]*model.PlayerResources
+	heroTypes        map[string]*model.HeroType
+	heroes           map[string]*model.Hero
+	teams            map[string]*model.Team
+	stages           map[string]*model.Stage
+	enemyTypes       map[string]*model.EnemyType
+	battleResults    map[string]*model.BattleResult
+	itemTemplates    map[string]*model.ItemTemplate
+	items            map[string]*model.Item
+	missionTemplates map[string]*model.MissionTemplate
+	missions         map[string]*model.Mission
+	banners          map[string]*model.Banner
+	summonSessions   map[string]*model.SummonSession
+	summonResults    map[string]*model.SummonResult
+	sessions         map[string]*model.Session
+	refreshTokens    map[string]*model.RefreshToken
+	idempotencyKeys  map[string]*model.IdempotencyKey // by user ID and key
+}
+
+func newMemData() *memData {
+	return &memData{
+		users:            make(map[string]*model.User),
+		resources:        make(map[string]*model.PlayerResources),
+		heroTypes:        make(map[string]*model.HeroType),
+		heroes:           make(map[string]*model.Hero),
+		teams:            make(map[string]*model.Team),
+		stages:           make(map[string]*model.Stage),
+		enemyTypes:       make(map[string]*model.EnemyType),
+		battleResults:    make(map[string]*model.BattleResult),
+		itemTemplates:    make(map[string]*model.ItemTemplate),
+		items:            make(map[string]*model.Item),
+		missionTemplates: make(map[string]*model.MissionTemplate),
+		missions:         make(map[string]*model.Mission),
+		banners:          make(map[string]*model.Banner),
+		summonSessions:   make(map[string]*model.SummonSession),
+		summonResults:    make(map[string]*model.SummonResult),
+		sessions:         make(map[string]*model.Session),
+		refreshTokens:    make(map[string]*model.RefreshToken),
+		idempotencyKeys:  make(map[string]*model.IdempotencyKey),
+	}
+}
+
+// snapshot copies every table. Stored values are never mutated in place, so
+// copying the maps is enough to be able to roll back.
+func (d *memData) snapshot() *memData {
+	return &memData{
+		users:            copyMap(d.users),
+		resources:        copyMap(d.resources),
+		heroTypes:        copyMap(d.heroTypes),
+		heroes:           copyMap(d.heroes),
+		teams:            copyMap(d.teams),
+		stages:           copyMap(d.stages),
+		enemyTypes:       copyMap(d.enemyTypes),
+		battleResults:    copyMap(d.battleResults),
+		itemTemplates:    copyMap(d.itemTemplates),
+		items:            copyMap(d.items),
+		missionTemplates: copyMap(d.missionTemplates),
+		missions:         copyMap(d.missions),
+		banners:          copyMap(d.banners),
+		summonSessions:   copyMap(d.summonSessions),
+		summonResults:    copyMap(d.summonResults),
+		sessions:         copyMap(d.sessions),
+		refreshTokens:    copyMap(d.refreshTokens),
+		idempotencyKeys:  copyMap(d.idempotencyKeys),
+	}
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	out := make(map[string]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{data: newMemData()}
+}
+
+func (m *Memory) root() *memStore { return &memStore{m: m} }
+
+func (m *Memory) Users() UserRepository                     { return m.root().Users() }
+func (m *Memory) Resources() ResourceRepository             { return m.root().Resources() }
+func (m *Memory) HeroTypes() HeroTypeRepository             { return m.root().HeroTypes() }
+func (m *Memory) Heroes() HeroRepository                    { return m.root().Heroes() }
+func (m *Memory) Teams() TeamRepository                     { return m.root().Teams() }
+func (m *Memory) Stages() StageRepository                   { return m.root().Stages() }
+func (m *Memory) EnemyTypes() EnemyTypeRepository           { return m.root().EnemyTypes() }
+func (m *Memory) BattleResults() BattleResultRepository     { return m.root().BattleResults() }
+func (m *Memory) ItemTemplates() ItemTemplateRepository     { return m.root().ItemTemplates() }
+func (m *Memory) Items() ItemRepository                     { return m.root().Items() }
+func (m *Memory) Missions() MissionRepository               { return m.root().Missions() }
+func (m *Memory) Banners() BannerRepository                 { return m.root().Banners() }
+func (m *Memory) Summons() SummonRepository                 { return m.root().Summons() }
+func (m *Memory) Sessions() SessionRepository               { return m.root().Sessions() }
+func (m *Memory) IdempotencyKeys() IdempotencyKeyRepository { return m.root().IdempotencyKeys() }
+
+// WithTx runs fn while holding the store lock and rolls back on error
+func (m *Memory) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return m.root().WithTx(ctx, fn)
+}
+
+// AfterCommit runs fn at once, as there is no transaction
+func (m *Memory) AfterCommit(fn func()) {
+	m.root().AfterCommit(fn)
+}
+
+// memStore is a view of Memory that knows whether it already holds the lock
+type memStore struct {
+	m     *Memory
+	inTx  bool
+	hooks *commitHooks // run after the transaction commits
+}
+
+func (s *memStore) Users() UserRepository                     { return memUsers{s} }
+func (s *memStore) Resources() ResourceRepository             { return memResources{s} }
+func (s *memStore) HeroTypes() HeroTypeRepository             { return memHeroTypes{s} }
+func (s *memStore) Heroes() HeroRepository                    { return memHeroes{s} }
+func (s *memStore) Teams() TeamRepository                     { return memTeams{s} }
+func (s *memStore) Stages() StageRepository                   { return memStages{s} }
+func (s *memStore) EnemyTypes() EnemyTypeRepository           { return memEnemyTypes{s} }
+func (s *memStore) BattleResults() BattleResultRepository     { return memBattleResults{s} }
+func (s *memStore) ItemTemplates() ItemTemplateRepository     { return memItemTemplates{s} }
+func (s *memStore) Items() ItemRepository                     { return memItems{s} }
+func (s *memStore) Missions() MissionRepository               { return memMissions{s} }
+func (s *memStore) Banners() BannerRepository                 { return memBanners{s} }
+func (s *memStore) Summons() SummonRepository                 { return memSummons{s} }
+func (s *memStore) Sessions() SessionRepository               { return memSessions{s} }
+func (s *memStore) IdempotencyKeys() IdempotencyKeyRepository { return memIdempotencyKeys{s} }
+
+func (s *memStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	tx := &memStore{m: s.m, inTx: true, hooks: &commitHooks{}}
+	if err := tx.run(fn); err != nil {
+		return err
+	}
+	// The lock is released, so the hooks may use the store
+	tx.hooks.run()
+	return nil
+}
+
+// run runs fn on the transaction s with the store locked, restoring the
+// data if it fails
+func (s *memStore) run(fn func(tx Store) error) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	saved := s.m.data.snapshot()
+	if err := fn(s); err != nil {
+		s.m.data = saved
+		return err
+	}
+	return nil
+}
+
+func (s *memStore) AfterCommit(fn func()) {
+	afterCommit(s.hooks, fn)
+}
+
+// do runs fn with the data locked, unless the lock is already held by a transaction
+func (s *memStore) do(fn func(d *memData) error) error {
+	if !s.inTx {
+		s.m.mu.Lock()
+		defer s.m.mu.Unlock()
+	}
+	return fn(s.m.data)
+}
+
+type memUsers struct{ s *memStore }
+
+func (r memUsers) Create(ctx context.Context, user *model.User) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.users[user.ID]; ok {
+			return ErrDuplicate
+		}
+		if err := checkUniqueUser(d, user); err != nil {
+			return err
+		}
+		cp := *user
+		d.users[user.ID] = &cp
+		return nil
+	})
+}
+
+// checkUniqueUser enforces the unique keys of the users table. Guests leave
+// username and email empty, registered users the device, like NULLs in MySQL.
+func checkUniqueUser(d *memData, user *model.User) error {
+	for _, u := range d.users {
+		if u.ID == user.ID {
+			continue
+		}
+		if (user.Username != "" && u.Username == user.Username) ||
+			(user.Email != "" && u.Email == user.Email) ||
+			(user.DeviceID != "" && u.DeviceID == user.DeviceID) {
+			return ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (r memUsers) find(match func(u *model.User) bool) (*model.User, error) {
+	var out *model.User
+	err := r.s.do(func(d *memData) error {
+		for _, u := range d.users {
+			if match(u) {
+				cp := *u
+				out = &cp
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return out, err
+}
+
+func (r memUsers) GetByID(ctx context.Context, id string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.ID == id })
+}
+
+func (r memUsers) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return username != "" && u.Username == username })
+}
+
+func (r memUsers) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return email != "" && u.Email == email })
+}
+
+func (r memUsers) GetByDeviceID(ctx context.Context, deviceID string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return deviceID != "" && u.DeviceID == deviceID })
+}
+
+func (r memUsers) Update(ctx context.Context, user *model.User) error {
+	return r.s.do(func(d *memData) error {
+		u, ok := d.users[user.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		if err := checkUniqueUser(d, user); err != nil {
+			return err
+		}
+		cp := *u
+		cp.Username = user.Username
+		cp.Email = user.Email
+		cp.EmailVerifiedAt = user.EmailVerifiedAt
+		cp.PasswordHash = user.PasswordHash
+		cp.DeviceID = user.DeviceID
+		cp.FailedLogins = user.FailedLogins
+		cp.LockedUntil = user.LockedUntil
+		d.users[user.ID] = &cp
+		return nil
+	})
+}
+
+func (r memUsers) UpdateLastLogin(ctx context.Context, id string, at time.Time) error {
+	return r.s.do(func(d *memData) error {
+		u, ok := d.users[id]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *u
+		cp.LastLogin = at
+		d.users[id] = &cp
+		return nil
+	})
+}
+
+type memResources struct{ s *memStore }
+
+func (r memResources) Create(ctx context.Context, res *model.PlayerResources) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.resources[res.UserID]; ok {
+			return ErrDuplicate
+		}
+		cp := *res
+		d.resources[res.UserID] = &cp
+		return nil
+	})
+}
+
+func (r memResources) Get(ctx context.Context, userID string) (*model.PlayerResources, error) {
+	var out *model.PlayerResources
+	err := r.s.do(func(d *memData) error {
+		res, ok := d.resources[userID]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *res
+		out = &cp
+		return nil
+	})
+	return out, err
+}
+
+func (r memResources) Update(ctx context.Context, res *model.PlayerResources) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.resources[res.UserID]; !ok {
+			return ErrNotFound
+		}
+		cp := *res
+		d.resources[res.UserID] = &cp
+		return nil
+	})
+}
+
+type memHeroTypes struct{ s *memStore }
+
+func copyHeroType(ht *model.HeroType) *model.HeroType {
+	cp := *ht
+	cp.Skills = append([]model.Skill(nil), ht.Skills...)
+	return &cp
+}
+
+func (r memHeroTypes) Get(ctx context.Context, id string) (*model.HeroType, error) {
+	var out *model.HeroType
+	err := r.s.do(func(d *memData) error {
+		ht, ok := d.heroTypes[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = copyHeroType(ht)
+		return nil
+	})
+	return out, err
+}
+
+func (r memHeroTypes) List(ctx context.Context) ([]*model.HeroType, error) {
+	var out []*model.HeroType
+	err := r.s.do(func(d *memData) error {
+		for _, ht := range d.heroTypes {
+			out = append(out, copyHeroType(ht))
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, err
+}
+
+type memHeroes struct{ s *memStore }
+
+func (r memHeroes) Create(ctx context.Context, hero *model.Hero) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.heroes[hero.ID]; ok {
+			return ErrDuplicate
+		}
+		d.heroes[hero.ID] = storedHero(hero)
+		return nil
+	})
+}
+
+// storedHero keeps only the columns of the heroes table
+func storedHero(h *model.Hero) *model.Hero {
+	return &model.Hero{
+		ID:         h.ID,
+		UserID:     h.UserID,
+		HeroTypeID: h.HeroTypeID,
+		Level:      h.Level,
+		Experience: h.Experience,
+		CreatedAt:  h.CreatedAt,
+	}
+}
+
+func (r memHeroes) Get(ctx context.Context, id string) (*model.Hero, error) {
+	var out *model.Hero
+	err := r.s.do(func(d *memData) error {
+		h, ok := d.heroes[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = storedHero(h)
+		return nil
+	})
+	return out, err
+}
+
+func (r memHeroes) ListByUser(ctx context.Context, userID string) ([]*model.Hero, error) {
+	var out []*model.Hero
+	err := r.s.do(func(d *memData) error {
+		for _, h := range d.heroes {
+			if h.UserID == userID {
+				out = append(out, storedHero(h))
+			}
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, err
+}
+
+func (r memHeroes) Update(ctx context.Context, hero *model.Hero) error {
+	return r.s.do(func(d *memData) error {
+		h, ok := d.heroes[hero.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := storedHero(h)
+		cp.Level = hero.Level
+		cp.Experience = hero.Experience
+		d.heroes[hero.ID] = cp
+		return nil
+	})
+}
+
+type memTeams struct{ s *memStore }
+
+// storedTeam keeps only the columns of the teams table
+func storedTeam(t *model.Team) *model.Team {
+	cp := *t
+	cp.Heroes = nil
+	return &cp
+}
+
+func (r memTeams) GetByUser(ctx context.Context, userID string) (*model.Team, error) {
+	var out *model.Team
+	err := r.s.do(func(d *memData) error {
+		for _, t := range d.teams {
+			if t.UserID == userID {
+				out = storedTeam(t)
+			}
+		}
+		if out == nil {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (r memTeams) Save(ctx context.Context, team *model.Team) error {
+	return r.s.do(func(d *memData) error {
+		cp := storedTeam(team)
+		// Like the unique key on user_id, update the user's team whatever its ID
+		for id, existing := range d.teams {
+			if id == team.ID || existing.UserID == team.UserID {
+				cp.ID = existing.ID
+				cp.UserID = existing.UserID
+				cp.CreatedAt = existing.CreatedAt
+				break
+			}
+		}
+		d.teams[cp.ID] = cp
+		return nil
+	})
+}
+
+type memStages struct{ s *memStore }
+
+// storedStage keeps only the columns of the stages table
+func storedStage(st *model.Stage) *model.Stage {
+	cp := *st
+	cp.Enemies = nil
+	return &cp
+}
+
+func (r memStages) Get(ctx context.Context, id string) (*model.Stage, error) {
+	var out *model.Stage
+	err := r.s.do(func(d *memData) error {
+		st, ok := d.stages[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = storedStage(st)
+		return nil
+	})
+	return out, err
+}
+
+func (r memStages) List(ctx context.Context) ([]*model.Stage, error) {
+	var out []*model.Stage
+	err := r.s.do(func(d *memData) error {
+		for _, st := range d.stages {
+			out = append(out, storedStage(st))
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, err
+}
+
+type memEnemyTypes struct{ s *memStore }
+
+func copyEnemyType(et *model.EnemyType) *model.EnemyType {
+	cp := *et
+	cp.Skills = append([]model.Skill(nil), et.Skills...)
+	return &cp
+}
+
+func (r memEnemyTypes) Get(ctx context.Context, id string) (*model.EnemyType, error) {
+	var out *model.EnemyType
+	err := r.s.do(func(d *memData) error {
+		et, ok := d.enemyTypes[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = copyEnemyType(et)
+		return nil
+	})
+	return out, err
+}
+
+func (r memEnemyTypes) List(ctx context.Context) ([]*model.EnemyType, error) {
+	var out []*model.EnemyType
+	err := r.s.do(func(d *memData) error {
+		for _, et := range d.enemyTypes {
+			out = append(out, copyEnemyType(et))
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, err
+}
+
+type memBattleResults struct{ s *memStore }
+
+// storedBattleResult keeps only the columns of the battle_results table
+func storedBattleResult(br *model.BattleResult) (*model.BattleResult, error) {
+	cp := &model.BattleResult{
+		ID:          br.ID,
+		UserID:      br.UserID,
+		TeamID:      br.TeamID,
+		StageID:     br.StageID,
+		Result:      br.Result,
+		RewardsJSON: br.RewardsJSON,
+		Seed:        br.Seed,
+		CreatedAt:   br.CreatedAt,
+	}
+	if err := cp.ParseRewardsJSON(); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func (r memBattleResults) Create(ctx context.Context, br *model.BattleResult) error {
+	if err := br.SetRewardsJSON(); err != nil {
+		return err
+	}
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.battleResults[br.ID]; ok {
+			return ErrDuplicate
+		}
+		cp, err := storedBattleResult(br)
+		if err != nil {
+			return err
+		}
+		d.battleResults[br.ID] = cp
+		return nil
+	})
+}
+
+func (r memBattleResults) Get(ctx context.Context, id string) (*model.BattleResult, error) {
+	var out *model.BattleResult
+	err := r.s.do(func(d *memData) error {
+		br, ok := d.battleResults[id]
+		if !ok {
+			return ErrNotFound
+		}
+		var err error
+		out, err = storedBattleResult(br)
+		return err
+	})
+	return out, err
+}
+
+func (r memBattleResults) ListByUser(ctx context.Context, userID string, limit int) ([]*model.BattleResult, error) {
+	var out []*model.BattleResult
+	err := r.s.do(func(d *memData) error {
+		for _, br := range d.battleResults {
+			if br.UserID != userID {
+				continue
+			}
+			cp, err := storedBattleResult(br)
+			if err != nil {
+				return err
+			}
+			out = append(out, cp)
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if limit >= 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, err
+}
+
+func (r memBattleResults) ListClearedStageIDs(ctx context.Context, userID string) ([]string, error) {
+	var ids []string
+	err := r.s.do(func(d *memData) error {
+		seen := make(map[string]bool)
+		for _, br := range d.battleResults {
+			if br.UserID == userID && br.Result == "victory" && !seen[br.StageID] {
+				seen[br.StageID] = true
+				ids = append(ids, br.StageID)
+			}
+		}
+		return nil
+	})
+	sort.Strings(ids)
+	return ids, err
+}
+
+type memItemTemplates struct{ s *memStore }
+
+func copyItemTemplate(it *model.ItemTemplate) *model.ItemTemplate {
+	cp := *it
+	cp.UsedForCrafting = append([]string(nil), it.UsedForCrafting...)
+	return &cp
+}
+
+func (r memItemTemplates) Get(ctx context.Context, id string) (*model.ItemTemplate, error) {
+	var out *model.ItemTemplate
+	err := r.s.do(func(d *memData) error {
+		it, ok := d.itemTemplates[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = copyItemTemplate(it)
+		return nil
+	})
+	return out, err
+}
+
+func (r memItemTemplates) List(ctx context.Context) ([]*model.ItemTemplate, error) {
+	var out []*model.ItemTemplate
+	err := r.s.do(func(d *memData) error {
+		for _, it := range d.itemTemplates {
+			out = append(out, copyItemTemplate(it))
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, err
+}
+
+type memItems struct{ s *memStore }
+
+// storedItem keeps only the columns of the items table
+func storedItem(it *model.Item) *model.Item {
+	cp := *it
+	cp.Template = nil
+	return &cp
+}
+
+func (r memItems) Create(ctx context.Context, item *model.Item) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.items[item.ID]; ok {
+			return ErrDuplicate
+		}
+		d.items[item.ID] = storedItem(item)
+		return nil
+	})
+}
+
+func (r memItems) Get(ctx context.Context, id string) (*model.Item, error) {
+	var out *model.Item
+	err := r.s.do(func(d *memData) error {
+		it, ok := d.items[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = storedItem(it)
+		return nil
+	})
+	return out, err
+}
+
+func (r memItems) ListByUser(ctx context.Context, userID string) ([]*model.Item, error) {
+	return r.list(func(it *model.Item) bool { return it.UserID == userID })
+}
+
+func (r memItems) ListEquipped(ctx context.Context, heroID string) ([]*model.Item, error) {
+	return r.list(func(it *model.Item) bool { return it.EquippedToHeroID == heroID })
+}
+
+func (r memItems) ListStacks(ctx context.Context, userID, templateID string) ([]*model.Item, error) {
+	return r.list(func(it *model.Item) bool {
+		return it.UserID == userID && it.ItemTemplateID == templateID && it.EquippedToHeroID == ""
+	})
+}
+
+// list returns the items that match in the order of ListByUser
+func (r memItems) list(match func(it *model.Item) bool) ([]*model.Item, error) {
+	var out []*model.Item
+	err := r.s.do(func(d *memData) error {
+		for _, it := range d.items {
+			if match(it) {
+				out = append(out, storedItem(it))
+			}
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].AcquiredAt.Equal(out[j].AcquiredAt) {
+			return out[i].AcquiredAt.Before(out[j].AcquiredAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, err
+}
+
+func (r memItems) Update(ctx context.Context, item *model.Item) error {
+	return r.s.do(func(d *memData) error {
+		it, ok := d.items[item.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := storedItem(it)
+		cp.Quantity = item.Quantity
+		cp.EquippedToHeroID = item.EquippedToHeroID
+		d.items[item.ID] = cp
+		return nil
+	})
+}
+
+func (r memItems) Delete(ctx context.Context, id string) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.items[id]; !ok {
+			return ErrNotFound
+		}
+		delete(d.items, id)
+		return nil
+	})
+}
+
+type memMissions struct{ s *memStore }
+
+func copyMissionTemplate(mt *model.MissionTemplate) *model.MissionTemplate {
+	cp := *mt
+	cp.ItemRewards = append([]model.ItemReward(nil), mt.ItemRewards...)
+	return &cp
+}
+
+// storedMission keeps only the columns of the missions table
+func storedMission(m *model.Mission) *model.Mission {
+	cp := *m
+	cp.Template = nil
+	return &cp
+}
+
+func (r memMissions) GetTemplate(ctx context.Context, id string) (*model.MissionTemplate, error) {
+	var out *model.MissionTemplate
+	err := r.s.do(func(d *memData) error {
+		mt, ok := d.missionTemplates[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = copyMissionTemplate(mt)
+		return nil
+	})
+	return out, err
+}
+
+func (r memMissions) ListTemplates(ctx context.Context) ([]*model.MissionTemplate, error) {
+	var out []*model.MissionTemplate
+	err := r.s.do(func(d *memData) error {
+		for _, mt := range d.missionTemplates {
+			out = append(out, copyMissionTemplate(mt))
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, err
+}
+
+func (r memMissions) Create(ctx context.Context, m *model.Mission) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.missions[m.ID]; ok {
+			return ErrDuplicate
+		}
+		d.missions[m.ID] = storedMission(m)
+		return nil
+	})
+}
+
+func (r memMissions) Get(ctx context.Context, id string) (*model.Mission, error) {
+	var out *model.Mission
+	err := r.s.do(func(d *memData) error {
+		m, ok := d.missions[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = storedMission(m)
+		return nil
+	})
+	return out, err
+}
+
+func (r memMissions) ListByUser(ctx context.Context, userID string) ([]*model.Mission, error) {
+	return r.list(func(m *model.Mission) bool { return m.UserID == userID })
+}
+
+func (r memMissions) ListInProgress(ctx context.Context, userID string, at time.Time) ([]*model.Mission, error) {
+	return r.list(func(m *model.Mission) bool {
+		return m.UserID == userID && m.Status == model.MissionStatusInProgress && (m.ExpiresAt == nil || m.ExpiresAt.After(at))
+	})
+}
+
+// list lists the missions matching fn in assignment order
+func (r memMissions) list(fn func(m *model.Mission) bool) ([]*model.Mission, error) {
+	var out []*model.Mission
+	err := r.s.do(func(d *memData) error {
+		for _, m := range d.missions {
+			if fn(m) {
+				out = append(out, storedMission(m))
+			}
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].AssignedAt.Equal(out[j].AssignedAt) {
+			return out[i].AssignedAt.Before(out[j].AssignedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, err
+}
+
+func (r memMissions) Update(ctx context.Context, m *model.Mission) error {
+	return r.s.do(func(d *memData) error {
+		existing, ok := d.missions[m.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := storedMission(existing)
+		cp.Status = m.Status
+		cp.CurrentValue = m.CurrentValue
+		cp.CompletedAt = m.CompletedAt
+		cp.ClaimedAt = m.ClaimedAt
+		cp.ExpiresAt = m.ExpiresAt
+		d.missions[m.ID] = cp
+		return nil
+	})
+}
+
+func (r memMissions) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := r.s.do(func(d *memData) error {
+		for id, m := range d.missions {
+			if m.ExpiresAt != nil && !m.ExpiresAt.After(before) {
+				delete(d.missions, id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+type memBanners struct{ s *memStore }
+
+func copyBanner(b *model.Banner) *model.Banner {
+	cp := *b
+	cp.FeaturedHeroes = append([]string{}, b.FeaturedHeroes...)
+	cp.FeaturedItems = append([]string{}, b.FeaturedItems...)
+	cp.HeroPool = append([]string(nil), b.HeroPool...)
+	cp.ItemPool = append([]string(nil), b.ItemPool...)
+	return &cp
+}
+
+func (r memBanners) Get(ctx context.Context, id string) (*model.Banner, error) {
+	var out *model.Banner
+	err := r.s.do(func(d *memData) error {
+		b, ok := d.banners[id]
+		if !ok {
+			return ErrNotFound
+		}
+		out = copyBanner(b)
+		return nil
+	})
+	return out, err
+}
+
+func (r memBanners) List(ctx context.Context) ([]*model.Banner, error) {
+	var out []*model.Banner
+	err := r.s.do(func(d *memData) error {
+		for _, b := range d.banners {
+			out = append(out, copyBanner(b))
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].StartTime.Equal(out[j].StartTime) {
+			return out[i].StartTime.Before(out[j].StartTime)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, err
+}
+
+type memSummons struct{ s *memStore }
+
+func (r memSummons) GetSession(ctx context.Context, userID, bannerID string) (*model.SummonSession, error) {
+	var out *model.SummonSession
+	err := r.s.do(func(d *memData) error {
+		for _, ss := range d.summonSessions {
+			if ss.UserID == userID && ss.BannerID == bannerID {
+				cp := *ss
+				out = &cp
+			}
+		}
+		if out == nil {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (r memSummons) SaveSession(ctx context.Context, session *model.SummonSession) error {
+	return r.s.do(func(d *memData) error {
+		cp := *session
+		// Like the unique key on user_id and banner_id, update the user's
+		// session for the banner whatever its ID
+		for id, existing := range d.summonSessions {
+			if id == session.ID || (existing.UserID == session.UserID && existing.BannerID == session.BannerID) {
+				cp.ID = existing.ID
+				cp.UserID = existing.UserID
+				cp.BannerID = existing.BannerID
+				cp.CreatedAt = existing.CreatedAt
+				break
+			}
+		}
+		d.summonSessions[cp.ID] = &cp
+		return nil
+	})
+}
+
+func (r memSummons) CreateResult(ctx context.Context, result *model.SummonResult) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.summonResults[result.ID]; ok {
+			return ErrDuplicate
+		}
+		cp := *result
+		d.summonResults[result.ID] = &cp
+		return nil
+	})
+}
+
+func (r memSummons) ListResults(ctx context.Context, userID, bannerID string, limit int) ([]*model.SummonResult, error) {
+	var out []*model.SummonResult
+	err := r.s.do(func(d *memData) error {
+		for _, sr := range d.summonResults {
+			if sr.UserID == userID && sr.BannerID == bannerID {
+				cp := *sr
+				out = append(out, &cp)
+			}
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].PullNumber > out[j].PullNumber })
+	if limit >= 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, err
+}
+
+type memSessions struct{ s *memStore }
+
+func (r memSessions) Create(ctx context.Context, session *model.Session) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.sessions[session.ID]; ok {
+			return ErrDuplicate
+		}
+		cp := *session
+		d.sessions[session.ID] = &cp
+		return nil
+	})
+}
+
+func (r memSessions) Get(ctx context.Context, id string) (*model.Session, error) {
+	var out *model.Session
+	err := r.s.do(func(d *memData) error {
+		s, ok := d.sessions[id]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *s
+		out = &cp
+		return nil
+	})
+	return out, err
+}
+
+func (r memSessions) ListByUser(ctx context.Context, userID string, limit int) ([]*model.Session, error) {
+	var out []*model.Session
+	err := r.s.do(func(d *memData) error {
+		for _, s := range d.sessions {
+			if s.UserID == userID {
+				cp := *s
+				out = append(out, &cp)
+			}
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	if limit >= 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, err
+}
+
+func (r memSessions) Update(ctx context.Context, session *model.Session) error {
+	return r.s.do(func(d *memData) error {
+		existing, ok := d.sessions[session.ID]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *existing
+		cp.LastUsedAt = session.LastUsedAt
+		cp.ExpiresAt = session.ExpiresAt
+		cp.RevokedAt = session.RevokedAt
+		d.sessions[session.ID] = &cp
+		return nil
+	})
+}
+
+func (r memSessions) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	return r.s.do(func(d *memData) error {
+		for id, s := range d.sessions {
+			if s.UserID == userID && s.RevokedAt == nil {
+				cp := *s
+				cp.Revoke(at)
+				d.sessions[id] = &cp
+			}
+		}
+		return nil
+	})
+}
+
+func (r memSessions) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := d.refreshTokens[token.Hash]; ok {
+			return ErrDuplicate
+		}
+		if _, ok := d.sessions[token.SessionID]; !ok {
+			return ErrNotFound
+		}
+		cp := *token
+		d.refreshTokens[token.Hash] = &cp
+		return nil
+	})
+}
+
+func (r memSessions) GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var out *model.RefreshToken
+	err := r.s.do(func(d *memData) error {
+		t, ok := d.refreshTokens[hash]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *t
+		out = &cp
+		return nil
+	})
+	return out, err
+}
+
+func (r memSessions) UpdateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return r.s.do(func(d *memData) error {
+		existing, ok := d.refreshTokens[token.Hash]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *existing
+		cp.UsedAt = token.UsedAt
+		d.refreshTokens[token.Hash] = &cp
+		return nil
+	})
+}
+
+type memIdempotencyKeys struct{ s *memStore }
+
+// idempotencyKeyID is the map key of a user's idempotency key
+func idempotencyKeyID(userID, key string) string {
+	return userID + "\x00" + key
+}
+
+func (r memIdempotencyKeys) Create(ctx context.Context, key *model.IdempotencyKey) error {
+	return r.s.do(func(d *memData) error {
+		id := idempotencyKeyID(key.UserID, key.Key)
+		if _, ok := d.idempotencyKeys[id]; ok {
+			return ErrDuplicate
+		}
+		cp := *key
+		d.idempotencyKeys[id] = &cp
+		return nil
+	})
+}
+
+func (r memIdempotencyKeys) Get(ctx context.Context, userID, key string) (*model.IdempotencyKey, error) {
+	var out *model.IdempotencyKey
+	err := r.s.do(func(d *memData) error {
+		k, ok := d.idempotencyKeys[idempotencyKeyID(userID, key)]
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *k
+		out = &cp
+		return nil
+	})
+	return out, err
+}
+
+func (r memIdempotencyKeys) Replace(ctx context.Context, old, key *model.IdempotencyKey) error {
+	return r.s.do(func(d *memData) error {
+		id := idempotencyKeyID(old.UserID, old.Key)
+		existing, ok := d.idempotencyKeys[id]
+		if !ok || !existing.CreatedAt.Equal(old.CreatedAt) || existing.StatusCode != old.StatusCode {
+			return ErrNotFound
+		}
+		cp := *key
+		cp.Body = append([]byte(nil), key.Body...)
+		d.idempotencyKeys[id] = &cp
+		return nil
+	})
+}
+
+// reservation returns the key reserved at key.CreatedAt, if it has no
+// response yet
+func (r memIdempotencyKeys) reservation(d *memData, key *model.IdempotencyKey) (*model.IdempotencyKey, bool) {
+	existing, ok := d.idempotencyKeys[idempotencyKeyID(key.UserID, key.Key)]
+	if !ok || !existing.CreatedAt.Equal(key.CreatedAt) || existing.IsComplete() {
+		return nil, false
+	}
+	return existing, true
+}
+
+func (r memIdempotencyKeys) Update(ctx context.Context, key *model.IdempotencyKey) error {
+	return r.s.do(func(d *memData) error {
+		existing, ok := r.reservation(d, key)
+		if !ok {
+			return ErrNotFound
+		}
+		cp := *existing
+		cp.StatusCode = key.StatusCode
+		cp.ContentType = key.ContentType
+		cp.Body = append([]byte(nil), key.Body...)
+		d.idempotencyKeys[idempotencyKeyID(key.UserID, key.Key)] = &cp
+		return nil
+	})
+}
+
+func (r memIdempotencyKeys) Delete(ctx context.Context, key *model.IdempotencyKey) error {
+	return r.s.do(func(d *memData) error {
+		if _, ok := r.reservation(d, key); ok {
+			delete(d.idempotencyKeys, idempotencyKeyID(key.UserID, key.Key))
+		}
+		return nil
+	})
+}
+
+func (r memIdempotencyKeys) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := r.s.do(func(d *memData) error {
+		for id, k := range d.idempotencyKeys {
+			if !k.ExpiresAt.After(before) {
+				delete(d.idempotencyKeys, id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
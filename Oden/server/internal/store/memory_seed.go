@@ -0,0 +1,99 @@
+package store
+
+import (
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// LoadSampleData fills the catalogue tables with the same rows as the sample
+// data migrations in db/migrations, so a memory-backed server is playable.
+func (m *Memory) LoadSampleData() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := m.data
+
+	heroTypes := []*model.HeroType{
+		{ID: "hero_type_001", Name: "Warrior", Rarity: "rare", BaseHP: 500, BaseATK: 50, Description: "A strong warrior with balanced stats", ImageURL: "heroes/warrior.png",
+			Skills: []model.Skill{{ID: "skill_001", Name: "Mighty Slash", Description: "Deal 150% ATK to a single enemy", DamageMultiplier: 1.5, Cooldown: 3}}},
+		{ID: "hero_type_002", Name: "Mage", Rarity: "rare", BaseHP: 300, BaseATK: 70, Description: "A powerful mage with high attack", ImageURL: "heroes/mage.png",
+			Skills: []model.Skill{{ID: "skill_002", Name: "Fireball", Description: "Deal 120% ATK to all enemies", DamageMultiplier: 1.2, Cooldown: 4, TargetsAll: true}}},
+		{ID: "hero_type_003", Name: "Archer", Rarity: "rare", BaseHP: 350, BaseATK: 60, Description: "A skilled archer with good range", ImageURL: "heroes/archer.png",
+			Skills: []model.Skill{{ID: "skill_003", Name: "Quick Shot", Description: "Deal 130% ATK to a single enemy", DamageMultiplier: 1.3, Cooldown: 2}}},
+		{ID: "hero_type_004", Name: "Knight", Rarity: "epic", BaseHP: 600, BaseATK: 55, Description: "A heavily armored knight with high HP", ImageURL: "heroes/knight.png",
+			Skills: []model.Skill{{ID: "skill_004", Name: "Shield Bash", Description: "Deal 140% ATK to a single enemy", DamageMultiplier: 1.4, Cooldown: 3}}},
+		{ID: "hero_type_005", Name: "Assassin", Rarity: "epic", BaseHP: 400, BaseATK: 75, Description: "A stealthy assassin with high attack", ImageURL: "heroes/assassin.png",
+			Skills: []model.Skill{{ID: "skill_005", Name: "Backstab", Description: "Deal 160% ATK to a single enemy", DamageMultiplier: 1.6, Cooldown: 3}}},
+	}
+	for _, ht := range heroTypes {
+		d.heroTypes[ht.ID] = ht
+	}
+
+	stages := []*model.Stage{
+		{ID: "stage_001", Name: "Forest Path", Description: "A peaceful forest path with weak enemies", Enemy1: "enemy_001", Enemy2: "enemy_002", EnemyLevel: 1, GoldReward: 100, ExpReward: 50},
+		{ID: "stage_002", Name: "Dark Cave", Description: "A dangerous cave with stronger enemies", Enemy1: "enemy_003", Enemy2: "enemy_004", Enemy3: "enemy_005", EnemyLevel: 3, GoldReward: 150, ExpReward: 75},
+		{ID: "stage_003", Name: "Mountain Pass", Description: "A treacherous mountain pass with powerful enemies", Enemy1: "enemy_006", Enemy2: "enemy_007", Enemy3: "enemy_008", EnemyLevel: 5, GoldReward: 200, ExpReward: 100},
+	}
+	for _, st := range stages {
+		d.stages[st.ID] = st
+	}
+
+	enemyTypes := []*model.EnemyType{
+		{ID: "enemy_001", Name: "Forest Slime", Description: "A sticky slime that lurks on forest paths", BaseHP: 180, BaseATK: 18, HPPerLevel: 20, ATKPerLevel: 2, ImageURL: "enemies/forest_slime.png"},
+		{ID: "enemy_002", Name: "Wild Wolf", Description: "A hungry wolf that hunts in packs", BaseHP: 150, BaseATK: 25, HPPerLevel: 15, ATKPerLevel: 3, ImageURL: "enemies/wild_wolf.png",
+			Skills: []model.Skill{{ID: "enemy_skill_001", Name: "Bite", Description: "Deal 130% ATK to a single hero", DamageMultiplier: 1.3, Cooldown: 2}}},
+		{ID: "enemy_003", Name: "Cave Bat", Description: "A swarm of bats that dives from the ceiling", BaseHP: 200, BaseATK: 30, HPPerLevel: 20, ATKPerLevel: 3, ImageURL: "enemies/cave_bat.png",
+			Skills: []model.Skill{{ID: "enemy_skill_002", Name: "Screech", Description: "Deal 80% ATK to all heroes", DamageMultiplier: 0.8, Cooldown: 3, TargetsAll: true}}},
+		{ID: "enemy_004", Name: "Goblin Scout", Description: "A sneaky goblin that strikes from the shadows", BaseHP: 260, BaseATK: 32, HPPerLevel: 25, ATKPerLevel: 3, ImageURL: "enemies/goblin_scout.png",
+			Skills: []model.Skill{{ID: "enemy_skill_003", Name: "Ambush", Description: "Deal 150% ATK to a single hero", DamageMultiplier: 1.5, Cooldown: 3}}},
+		{ID: "enemy_005", Name: "Cave Troll", Description: "A slow but brutal troll", BaseHP: 450, BaseATK: 38, HPPerLevel: 40, ATKPerLevel: 4, ImageURL: "enemies/cave_troll.png",
+			Skills: []model.Skill{{ID: "enemy_skill_004", Name: "Club Smash", Description: "Deal 180% ATK to a single hero", DamageMultiplier: 1.8, Cooldown: 4}}},
+		{ID: "enemy_006", Name: "Mountain Harpy", Description: "A shrieking harpy that rides the mountain winds", BaseHP: 320, BaseATK: 42, HPPerLevel: 30, ATKPerLevel: 4, ImageURL: "enemies/mountain_harpy.png",
+			Skills: []model.Skill{{ID: "enemy_skill_005", Name: "Gale", Description: "Deal 100% ATK to all heroes", DamageMultiplier: 1.0, Cooldown: 3, TargetsAll: true}}},
+		{ID: "enemy_007", Name: "Stone Golem", Description: "A golem of living rock", BaseHP: 600, BaseATK: 40, HPPerLevel: 50, ATKPerLevel: 4, ImageURL: "enemies/stone_golem.png",
+			Skills: []model.Skill{{ID: "enemy_skill_006", Name: "Rock Throw", Description: "Deal 150% ATK to a single hero", DamageMultiplier: 1.5, Cooldown: 3}}},
+		{ID: "enemy_008", Name: "Frost Giant", Description: "A towering giant that guards the pass", BaseHP: 800, BaseATK: 50, HPPerLevel: 60, ATKPerLevel: 5, ImageURL: "enemies/frost_giant.png",
+			Skills: []model.Skill{{ID: "enemy_skill_007", Name: "Avalanche", Description: "Deal 130% ATK to all heroes", DamageMultiplier: 1.3, Cooldown: 4, TargetsAll: true}}},
+	}
+	for _, et := range enemyTypes {
+		d.enemyTypes[et.ID] = et
+	}
+
+	itemTemplates := []*model.ItemTemplate{
+		{ID: "item_template_001", Name: "Iron Sword", Description: "A basic iron sword", Type: model.ItemTypeEquipment, Rarity: model.ItemRarityCommon, ImageURL: "items/iron_sword.png", Slot: model.EquipmentSlotWeapon, ATKBonus: 10},
+		{ID: "item_template_002", Name: "Steel Armor", Description: "Sturdy steel armor", Type: model.ItemTypeEquipment, Rarity: model.ItemRarityCommon, ImageURL: "items/steel_armor.png", Slot: model.EquipmentSlotArmor, HPBonus: 20},
+		{ID: "item_template_003", Name: "Silver Ring", Description: "A magical silver ring", Type: model.ItemTypeEquipment, Rarity: model.ItemRarityUncommon, ImageURL: "items/silver_ring.png", Slot: model.EquipmentSlotAccessory, ATKBonus: 5, HPBonus: 5},
+		{ID: "item_template_004", Name: "Health Potion", Description: "Restores 100 HP", Type: model.ItemTypeConsumable, Rarity: model.ItemRarityCommon, ImageURL: "items/health_potion.png"},
+		{ID: "item_template_005", Name: "Iron Ore", Description: "Used for crafting weapons", Type: model.ItemTypeMaterial, Rarity: model.ItemRarityCommon, ImageURL: "items/iron_ore.png"},
+	}
+	for _, it := range itemTemplates {
+		d.itemTemplates[it.ID] = it
+	}
+
+	missionTemplates := []*model.MissionTemplate{
+		{ID: "mission_template_001", Title: "Win 3 Battles", Description: "Win 3 battles in any stage", Type: model.MissionTypeDaily, RequirementType: model.RequirementWinBattles, TargetValue: 3, GoldReward: 100, GemsReward: 10, ExperienceReward: 50},
+		{ID: "mission_template_002", Title: "Level Up a Hero", Description: "Level up any hero", Type: model.MissionTypeDaily, RequirementType: model.RequirementLevelUpHero, TargetValue: 1, GoldReward: 150, GemsReward: 15, ExperienceReward: 75},
+		{ID: "mission_template_003", Title: "Collect 5 Items", Description: "Collect any 5 items", Type: model.MissionTypeWeekly, RequirementType: model.RequirementCollectItems, TargetValue: 5, GoldReward: 300, GemsReward: 30, ExperienceReward: 100,
+			ItemRewards: []model.ItemReward{{ItemID: "item_template_002", Quantity: 1}, {ItemID: "item_template_005", Quantity: 5}}},
+	}
+	for _, mt := range missionTemplates {
+		d.missionTemplates[mt.ID] = mt
+	}
+
+	bannerStart := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	eventEnd := time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC)
+	banners := []*model.Banner{
+		{ID: "banner_001", Name: "Standard Summon", Description: "The standard summon banner with all heroes", Type: model.BannerTypeStandard, ImageURL: "banners/standard.png",
+			StartTime: bannerStart, StandardHeroRate: 0.03, FeaturedHeroRate: 0.01, GuaranteeThreshold: 100,
+			SingleSummonCost: 300, TenSummonCost: 2700, CostType: model.SummonCostGem, HasDailyFreeSummon: true,
+			FeaturedHeroes: []string{}, FeaturedItems: []string{}},
+		{ID: "banner_002", Name: "Knight & Assassin", Description: "Featured banner with Knight and Assassin", Type: model.BannerTypeEvent, ImageURL: "banners/knight_assassin.png",
+			StartTime: bannerStart, EndTime: &eventEnd, StandardHeroRate: 0.02, FeaturedHeroRate: 0.02, GuaranteeThreshold: 80,
+			SingleSummonCost: 300, TenSummonCost: 2700, CostType: model.SummonCostGem,
+			FeaturedHeroes: []string{"hero_type_004", "hero_type_005"}, FeaturedItems: []string{}},
+	}
+	for _, b := range banners {
+		d.banners[b.ID] = b
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/yourusername/oden/internal/db"
+	"github.com/yourusername/oden/internal/tracing"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for unique key violations
+const mysqlDuplicateEntry = 1062
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// MySQL is a Store backed by a MySQL database
+type MySQL struct {
+	db    *db.DB
+	q     querier
+	inTx  bool
+	hooks *commitHooks // run after the transaction commits
+}
+
+var _ Store = (*MySQL)(nil)
+
+// NewMySQL creates a new MySQL store
+func NewMySQL(database *db.DB) *MySQL {
+	return &MySQL{db: database, q: tracedQuerier{database.DB}}
+}
+
+func (s *MySQL) Users() UserRepository                     { return mysqlUsers{s} }
+func (s *MySQL) Resources() ResourceRepository             { return mysqlResources{s} }
+func (s *MySQL) HeroTypes() HeroTypeRepository             { return mysqlHeroTypes{s} }
+func (s *MySQL) Heroes() HeroRepository                    { return mysqlHeroes{s} }
+func (s *MySQL) Teams() TeamRepository                     { return mysqlTeams{s} }
+func (s *MySQL) Stages() StageRepository                   { return mysqlStages{s} }
+func (s *MySQL) EnemyTypes() EnemyTypeRepository           { return mysqlEnemyTypes{s} }
+func (s *MySQL) BattleResults() BattleResultRepository     { return mysqlBattleResults{s} }
+func (s *MySQL) ItemTemplates() ItemTemplateRepository     { return mysqlItemTemplates{s} }
+func (s *MySQL) Items() ItemRepository                     { return mysqlItems{s} }
+func (s *MySQL) Missions() MissionRepository               { return mysqlMissions{s} }
+func (s *MySQL) Banners() BannerRepository                 { return mysqlBanners{s} }
+func (s *MySQL) Summons() SummonRepository                 { return mysqlSummons{s} }
+func (s *MySQL) Sessions() SessionRepository               { return mysqlSessions{s} }
+func (s *MySQL) IdempotencyKeys() IdempotencyKeyRepository { return mysqlIdempotencyKeys{s} }
+
+// WithTx runs fn inside a database transaction. Nested calls reuse the
+// outer transaction.
+func (s *MySQL) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	_, span := startStatement(ctx, "BEGIN")
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	tx := &MySQL{db: s.db, q: tracedQuerier{sqlTx}, inTx: true, hooks: &commitHooks{}}
+	if err := fn(tx); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+
+	_, span = startStatement(ctx, "COMMIT")
+	err = sqlTx.Commit()
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	tx.hooks.run()
+	return nil
+}
+
+// AfterCommit runs fn once the transaction commits
+func (s *MySQL) AfterCommit(fn func()) {
+	afterCommit(s.hooks, fn)
+}
+
+// forUpdate returns the locking clause to append to a SELECT inside a transaction
+func (s *MySQL) forUpdate() string {
+	if s.inTx {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// wrapErr maps driver errors to store errors
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return fmt.Errorf("%w: %s", ErrDuplicate, mysqlErr.Message)
+	}
+	return err
+}
+
+// expectAffected returns ErrNotFound if the statement did not touch any row
+func expectAffected(res sql.Result, err error) error {
+	if err != nil {
+		return wrapErr(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// nullString converts an empty string to SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullTime converts a nil time to SQL NULL
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// timePtr converts SQL NULL to a nil time
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
+// inPlaceholders returns "?, ?, ?" for n arguments
+func inPlaceholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	b := make([]byte, 0, n*3)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, '?')
+	}
+	return string(b)
+}
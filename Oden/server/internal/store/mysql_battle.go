@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlStages struct{ s *MySQL }
+
+const stageColumns = "id, name, description, enemy_1, enemy_2, enemy_3, enemy_4, enemy_5, enemy_level, gold_reward, exp_reward"
+
+func scanStage(row scanner) (*model.Stage, error) {
+	var st model.Stage
+	var description, e1, e2, e3, e4, e5 sql.NullString
+	if err := row.Scan(&st.ID, &st.Name, &description, &e1, &e2, &e3, &e4, &e5, &st.EnemyLevel, &st.GoldReward, &st.ExpReward); err != nil {
+		return nil, wrapErr(err)
+	}
+	st.Description = description.String
+	st.Enemy1, st.Enemy2, st.Enemy3, st.Enemy4, st.Enemy5 = e1.String, e2.String, e3.String, e4.String, e5.String
+	return &st, nil
+}
+
+func (r mysqlStages) Get(ctx context.Context, id string) (*model.Stage, error) {
+	return scanStage(r.s.q.QueryRowContext(ctx, "SELECT "+stageColumns+" FROM stages WHERE id = ?", id))
+}
+
+func (r mysqlStages) List(ctx context.Context) ([]*model.Stage, error) {
+	rows, err := r.s.q.QueryContext(ctx, "SELECT "+stageColumns+" FROM stages ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stages []*model.Stage
+	for rows.Next() {
+		st, err := scanStage(rows)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, st)
+	}
+	return stages, rows.Err()
+}
+
+type mysqlEnemyTypes struct{ s *MySQL }
+
+const enemyTypeColumns = "id, name, description, base_hp, base_atk, hp_per_level, atk_per_level, image_url"
+
+func scanEnemyType(row scanner) (*model.EnemyType, error) {
+	var et model.EnemyType
+	var description, imageURL sql.NullString
+	if err := row.Scan(&et.ID, &et.Name, &description, &et.BaseHP, &et.BaseATK,
+		&et.HPPerLevel, &et.ATKPerLevel, &imageURL); err != nil {
+		return nil, wrapErr(err)
+	}
+	et.Description = description.String
+	et.ImageURL = imageURL.String
+	return &et, nil
+}
+
+func (r mysqlEnemyTypes) Get(ctx context.Context, id string) (*model.EnemyType, error) {
+	et, err := scanEnemyType(r.s.q.QueryRowContext(ctx,
+		"SELECT "+enemyTypeColumns+" FROM enemy_types WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadSkills(ctx, []*model.EnemyType{et}); err != nil {
+		return nil, err
+	}
+	return et, nil
+}
+
+func (r mysqlEnemyTypes) List(ctx context.Context) ([]*model.EnemyType, error) {
+	rows, err := r.s.q.QueryContext(ctx, "SELECT "+enemyTypeColumns+" FROM enemy_types ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []*model.EnemyType
+	for rows.Next() {
+		et, err := scanEnemyType(rows)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, et)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadSkills(ctx, types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// loadSkills fills the Skills of every enemy type with one query
+func (r mysqlEnemyTypes) loadSkills(ctx context.Context, types []*model.EnemyType) error {
+	if len(types) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.EnemyType, len(types))
+	args := make([]interface{}, 0, len(types))
+	for _, et := range types {
+		byID[et.ID] = et
+		args = append(args, et.ID)
+	}
+
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT id, enemy_type_id, name, description, damage_multiplier, cooldown, targets_all FROM enemy_skills WHERE enemy_type_id IN ("+
+			inPlaceholders(len(args))+") ORDER BY id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var skill model.Skill
+		var enemyTypeID string
+		var description sql.NullString
+		if err := rows.Scan(&skill.ID, &enemyTypeID, &skill.Name, &description,
+			&skill.DamageMultiplier, &skill.Cooldown, &skill.TargetsAll); err != nil {
+			return err
+		}
+		skill.Description = description.String
+		if et := byID[enemyTypeID]; et != nil {
+			et.Skills = append(et.Skills, skill)
+		}
+	}
+	return rows.Err()
+}
+
+type mysqlBattleResults struct{ s *MySQL }
+
+const battleResultColumns = "id, user_id, team_id, stage_id, result, rewards_json, seed, created_at"
+
+func scanBattleResult(row scanner) (*model.BattleResult, error) {
+	var br model.BattleResult
+	if err := row.Scan(&br.ID, &br.UserID, &br.TeamID, &br.StageID, &br.Result, &br.RewardsJSON, &br.Seed, &br.CreatedAt); err != nil {
+		return nil, wrapErr(err)
+	}
+	if err := br.ParseRewardsJSON(); err != nil {
+		return nil, err
+	}
+	return &br, nil
+}
+
+func (r mysqlBattleResults) Create(ctx context.Context, br *model.BattleResult) error {
+	if err := br.SetRewardsJSON(); err != nil {
+		return err
+	}
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO battle_results ("+battleResultColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		br.ID, br.UserID, br.TeamID, br.StageID, br.Result, br.RewardsJSON, br.Seed, br.CreatedAt)
+	return wrapErr(err)
+}
+
+func (r mysqlBattleResults) Get(ctx context.Context, id string) (*model.BattleResult, error) {
+	return scanBattleResult(r.s.q.QueryRowContext(ctx,
+		"SELECT "+battleResultColumns+" FROM battle_results WHERE id = ?", id))
+}
+
+func (r mysqlBattleResults) ListByUser(ctx context.Context, userID string, limit int) ([]*model.BattleResult, error) {
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT "+battleResultColumns+" FROM battle_results WHERE user_id = ? ORDER BY created_at DESC, id LIMIT ?",
+		userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.BattleResult
+	for rows.Next() {
+		br, err := scanBattleResult(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, br)
+	}
+	return results, rows.Err()
+}
+
+func (r mysqlBattleResults) ListClearedStageIDs(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT DISTINCT stage_id FROM battle_results WHERE user_id = ? AND result = 'victory' ORDER BY stage_id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
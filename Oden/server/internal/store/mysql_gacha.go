@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlBanners struct{ s *MySQL }
+
+const bannerColumns = "id, name, description, type, image_url, start_time, end_time, standard_hero_rate, featured_hero_rate, " +
+	"guarantee_threshold, single_summon_cost, ten_summon_cost, cost_type, has_daily_free_summon"
+
+func scanBanner(row scanner) (*model.Banner, error) {
+	var b model.Banner
+	var description, imageURL sql.NullString
+	var endTime sql.NullTime
+	if err := row.Scan(&b.ID, &b.Name, &description, &b.Type, &imageURL, &b.StartTime, &endTime,
+		&b.StandardHeroRate, &b.FeaturedHeroRate, &b.GuaranteeThreshold,
+		&b.SingleSummonCost, &b.TenSummonCost, &b.CostType, &b.HasDailyFreeSummon); err != nil {
+		return nil, wrapErr(err)
+	}
+	b.Description = description.String
+	b.ImageURL = imageURL.String
+	b.EndTime = timePtr(endTime)
+	b.FeaturedHeroes = []string{}
+	b.FeaturedItems = []string{}
+	return &b, nil
+}
+
+func (r mysqlBanners) Get(ctx context.Context, id string) (*model.Banner, error) {
+	b, err := scanBanner(r.s.q.QueryRowContext(ctx, "SELECT "+bannerColumns+" FROM banners WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadFeatured(ctx, []*model.Banner{b}); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r mysqlBanners) List(ctx context.Context) ([]*model.Banner, error) {
+	rows, err := r.s.q.QueryContext(ctx, "SELECT "+bannerColumns+" FROM banners ORDER BY start_time, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banners []*model.Banner
+	for rows.Next() {
+		b, err := scanBanner(rows)
+		if err != nil {
+			return nil, err
+		}
+		banners = append(banners, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadFeatured(ctx, banners); err != nil {
+		return nil, err
+	}
+	return banners, nil
+}
+
+// loadFeatured fills FeaturedHeroes and FeaturedItems of every banner
+func (r mysqlBanners) loadFeatured(ctx context.Context, banners []*model.Banner) error {
+	if len(banners) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.Banner, len(banners))
+	args := make([]interface{}, 0, len(banners))
+	for _, b := range banners {
+		byID[b.ID] = b
+		args = append(args, b.ID)
+	}
+	in := inPlaceholders(len(args))
+
+	heroRows, err := r.s.q.QueryContext(ctx,
+		"SELECT banner_id, hero_type_id FROM banner_featured_heroes WHERE banner_id IN ("+in+") ORDER BY hero_type_id", args...)
+	if err != nil {
+		return err
+	}
+	defer heroRows.Close()
+	for heroRows.Next() {
+		var bannerID, heroTypeID string
+		if err := heroRows.Scan(&bannerID, &heroTypeID); err != nil {
+			return err
+		}
+		if b := byID[bannerID]; b != nil {
+			b.FeaturedHeroes = append(b.FeaturedHeroes, heroTypeID)
+		}
+	}
+	if err := heroRows.Err(); err != nil {
+		return err
+	}
+
+	itemRows, err := r.s.q.QueryContext(ctx,
+		"SELECT banner_id, item_template_id FROM banner_featured_items WHERE banner_id IN ("+in+") ORDER BY item_template_id", args...)
+	if err != nil {
+		return err
+	}
+	defer itemRows.Close()
+	for itemRows.Next() {
+		var bannerID, itemTemplateID string
+		if err := itemRows.Scan(&bannerID, &itemTemplateID); err != nil {
+			return err
+		}
+		if b := byID[bannerID]; b != nil {
+			b.FeaturedItems = append(b.FeaturedItems, itemTemplateID)
+		}
+	}
+	return itemRows.Err()
+}
+
+type mysqlSummons struct{ s *MySQL }
+
+const summonSessionColumns = "id, user_id, banner_id, pull_count, last_legendary_at, has_guarantee, last_free_summon, created_at, updated_at"
+
+const summonResultColumns = "id, user_id, banner_id, result_type, result_id, rarity, is_featured, is_pity_break, pull_number, seed, timestamp"
+
+func (r mysqlSummons) GetSession(ctx context.Context, userID, bannerID string) (*model.SummonSession, error) {
+	var ss model.SummonSession
+	var lastFree sql.NullTime
+	err := r.s.q.QueryRowContext(ctx,
+		"SELECT "+summonSessionColumns+" FROM summon_sessions WHERE user_id = ? AND banner_id = ?"+r.s.forUpdate(),
+		userID, bannerID).
+		Scan(&ss.ID, &ss.UserID, &ss.BannerID, &ss.PullCount, &ss.LastLegendaryAt, &ss.HasGuarantee,
+			&lastFree, &ss.CreatedAt, &ss.UpdatedAt)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	ss.LastFreeSummon = timePtr(lastFree)
+	return &ss, nil
+}
+
+func (r mysqlSummons) SaveSession(ctx context.Context, ss *model.SummonSession) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO summon_sessions ("+summonSessionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE pull_count = VALUES(pull_count), last_legendary_at = VALUES(last_legendary_at), "+
+			"has_guarantee = VALUES(has_guarantee), last_free_summon = VALUES(last_free_summon), updated_at = VALUES(updated_at)",
+		ss.ID, ss.UserID, ss.BannerID, ss.PullCount, ss.LastLegendaryAt, ss.HasGuarantee,
+		nullTime(ss.LastFreeSummon), ss.CreatedAt, ss.UpdatedAt)
+	return wrapErr(err)
+}
+
+func (r mysqlSummons) CreateResult(ctx context.Context, sr *model.SummonResult) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO summon_results ("+summonResultColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		sr.ID, sr.UserID, sr.BannerID, sr.ResultType, sr.ResultID, sr.Rarity,
+		sr.IsFeatured, sr.IsPityBreak, sr.PullNumber, sr.Seed, sr.Timestamp)
+	return wrapErr(err)
+}
+
+func (r mysqlSummons) ListResults(ctx context.Context, userID, bannerID string, limit int) ([]*model.SummonResult, error) {
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT "+summonResultColumns+" FROM summon_results WHERE user_id = ? AND banner_id = ? "+
+			"ORDER BY pull_number DESC LIMIT ?", userID, bannerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.SummonResult
+	for rows.Next() {
+		var sr model.SummonResult
+		if err := rows.Scan(&sr.ID, &sr.UserID, &sr.BannerID, &sr.ResultType, &sr.ResultID, &sr.Rarity,
+			&sr.IsFeatured, &sr.IsPityBreak, &sr.PullNumber, &sr.Seed, &sr.Timestamp); err != nil {
+			return nil, err
+		}
+		results = append(results, &sr)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlHeroTypes struct{ s *MySQL }
+
+const heroTypeColumns = "id, name, rarity, base_hp, base_atk, description, image_url"
+
+func scanHeroType(row scanner) (*model.HeroType, error) {
+	var ht model.HeroType
+	var description, imageURL sql.NullString
+	if err := row.Scan(&ht.ID, &ht.Name, &ht.Rarity, &ht.BaseHP, &ht.BaseATK, &description, &imageURL); err != nil {
+		return nil, wrapErr(err)
+	}
+	ht.Description = description.String
+	ht.ImageURL = imageURL.String
+	return &ht, nil
+}
+
+func (r mysqlHeroTypes) Get(ctx context.Context, id string) (*model.HeroType, error) {
+	ht, err := scanHeroType(r.s.q.QueryRowContext(ctx,
+		"SELECT "+heroTypeColumns+" FROM hero_types WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadSkills(ctx, []*model.HeroType{ht}); err != nil {
+		return nil, err
+	}
+	return ht, nil
+}
+
+func (r mysqlHeroTypes) List(ctx context.Context) ([]*model.HeroType, error) {
+	rows, err := r.s.q.QueryContext(ctx, "SELECT "+heroTypeColumns+" FROM hero_types ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []*model.HeroType
+	for rows.Next() {
+		ht, err := scanHeroType(rows)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, ht)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadSkills(ctx, types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// loadSkills fills the Skills of every hero type with one query
+func (r mysqlHeroTypes) loadSkills(ctx context.Context, types []*model.HeroType) error {
+	if len(types) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.HeroType, len(types))
+	args := make([]interface{}, 0, len(types))
+	for _, ht := range types {
+		byID[ht.ID] = ht
+		args = append(args, ht.ID)
+	}
+
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT id, hero_type_id, name, description, damage_multiplier, cooldown, targets_all FROM skills WHERE hero_type_id IN ("+
+			inPlaceholders(len(args))+") ORDER BY id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var skill model.Skill
+		var heroTypeID string
+		var description sql.NullString
+		if err := rows.Scan(&skill.ID, &heroTypeID, &skill.Name, &description,
+			&skill.DamageMultiplier, &skill.Cooldown, &skill.TargetsAll); err != nil {
+			return err
+		}
+		skill.Description = description.String
+		if ht := byID[heroTypeID]; ht != nil {
+			ht.Skills = append(ht.Skills, skill)
+		}
+	}
+	return rows.Err()
+}
+
+type mysqlHeroes struct{ s *MySQL }
+
+const heroColumns = "id, user_id, hero_type_id, level, experience, created_at"
+
+func scanHero(row scanner) (*model.Hero, error) {
+	var h model.Hero
+	if err := row.Scan(&h.ID, &h.UserID, &h.HeroTypeID, &h.Level, &h.Experience, &h.CreatedAt); err != nil {
+		return nil, wrapErr(err)
+	}
+	return &h, nil
+}
+
+func (r mysqlHeroes) Create(ctx context.Context, hero *model.Hero) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO heroes ("+heroColumns+") VALUES (?, ?, ?, ?, ?, ?)",
+		hero.ID, hero.UserID, hero.HeroTypeID, hero.Level, hero.Experience, hero.CreatedAt)
+	return wrapErr(err)
+}
+
+func (r mysqlHeroes) Get(ctx context.Context, id string) (*model.Hero, error) {
+	return scanHero(r.s.q.QueryRowContext(ctx, "SELECT "+heroColumns+" FROM heroes WHERE id = ?"+r.s.forUpdate(), id))
+}
+
+func (r mysqlHeroes) ListByUser(ctx context.Context, userID string) ([]*model.Hero, error) {
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT "+heroColumns+" FROM heroes WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heroes []*model.Hero
+	for rows.Next() {
+		h, err := scanHero(rows)
+		if err != nil {
+			return nil, err
+		}
+		heroes = append(heroes, h)
+	}
+	return heroes, rows.Err()
+}
+
+func (r mysqlHeroes) Update(ctx context.Context, hero *model.Hero) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE heroes SET level = ?, experience = ? WHERE id = ?",
+		hero.Level, hero.Experience, hero.ID))
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlIdempotencyKeys struct{ s *MySQL }
+
+const idempotencyKeyColumns = "user_id, idempotency_key, request_hash, status_code, content_type, body, created_at, expires_at"
+
+func (r mysqlIdempotencyKeys) Create(ctx context.Context, key *model.IdempotencyKey) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO idempotency_keys ("+idempotencyKeyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		key.UserID, key.Key, key.RequestHash, key.StatusCode, key.ContentType, key.Body, key.CreatedAt, key.ExpiresAt)
+	return wrapErr(err)
+}
+
+func (r mysqlIdempotencyKeys) Get(ctx context.Context, userID, key string) (*model.IdempotencyKey, error) {
+	var k model.IdempotencyKey
+	err := r.s.q.QueryRowContext(ctx,
+		"SELECT "+idempotencyKeyColumns+" FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ?"+r.s.forUpdate(),
+		userID, key).
+		Scan(&k.UserID, &k.Key, &k.RequestHash, &k.StatusCode, &k.ContentType, &k.Body, &k.CreatedAt, &k.ExpiresAt)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return &k, nil
+}
+
+func (r mysqlIdempotencyKeys) Replace(ctx context.Context, old, key *model.IdempotencyKey) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE idempotency_keys SET request_hash = ?, status_code = ?, content_type = ?, body = ?, created_at = ?, expires_at = ? "+
+			"WHERE user_id = ? AND idempotency_key = ? AND created_at = ? AND status_code = ?",
+		key.RequestHash, key.StatusCode, key.ContentType, key.Body, key.CreatedAt, key.ExpiresAt,
+		old.UserID, old.Key, old.CreatedAt, old.StatusCode))
+}
+
+func (r mysqlIdempotencyKeys) Update(ctx context.Context, key *model.IdempotencyKey) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status_code = ?, content_type = ?, body = ? "+
+			"WHERE user_id = ? AND idempotency_key = ? AND created_at = ? AND status_code = 0",
+		key.StatusCode, key.ContentType, key.Body, key.UserID, key.Key, key.CreatedAt))
+}
+
+func (r mysqlIdempotencyKeys) Delete(ctx context.Context, key *model.IdempotencyKey) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND created_at = ? AND status_code = 0",
+		key.UserID, key.Key, key.CreatedAt)
+	return wrapErr(err)
+}
+
+func (r mysqlIdempotencyKeys) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.s.q.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
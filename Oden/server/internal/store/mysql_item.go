@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlItemTemplates struct{ s *MySQL }
+
+const itemTemplateColumns = "id, name, description, type, rarity, image_url, slot, atk_bonus, hp_bonus, effect, effect_value"
+
+func scanItemTemplate(row scanner) (*model.ItemTemplate, error) {
+	var it model.ItemTemplate
+	var description, imageURL, slot, effect sql.NullString
+	var atkBonus, hpBonus, effectValue sql.NullInt64
+	if err := row.Scan(&it.ID, &it.Name, &description, &it.Type, &it.Rarity, &imageURL,
+		&slot, &atkBonus, &hpBonus, &effect, &effectValue); err != nil {
+		return nil, wrapErr(err)
+	}
+	it.Description = description.String
+	it.ImageURL = imageURL.String
+	it.Slot = model.EquipmentSlot(slot.String)
+	it.ATKBonus = int(atkBonus.Int64)
+	it.HPBonus = int(hpBonus.Int64)
+	it.Effect = effect.String
+	it.EffectValue = int(effectValue.Int64)
+	return &it, nil
+}
+
+func (r mysqlItemTemplates) Get(ctx context.Context, id string) (*model.ItemTemplate, error) {
+	return scanItemTemplate(r.s.q.QueryRowContext(ctx,
+		"SELECT "+itemTemplateColumns+" FROM item_templates WHERE id = ?", id))
+}
+
+func (r mysqlItemTemplates) List(ctx context.Context) ([]*model.ItemTemplate, error) {
+	rows, err := r.s.q.QueryContext(ctx, "SELECT "+itemTemplateColumns+" FROM item_templates ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*model.ItemTemplate
+	for rows.Next() {
+		it, err := scanItemTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, it)
+	}
+	return templates, rows.Err()
+}
+
+type mysqlItems struct{ s *MySQL }
+
+const itemColumns = "id, user_id, item_template_id, quantity, equipped_to_hero_id, acquired_at"
+
+func scanItem(row scanner) (*model.Item, error) {
+	var it model.Item
+	var equippedTo sql.NullString
+	if err := row.Scan(&it.ID, &it.UserID, &it.ItemTemplateID, &it.Quantity, &equippedTo, &it.AcquiredAt); err != nil {
+		return nil, wrapErr(err)
+	}
+	it.EquippedToHeroID = equippedTo.String
+	return &it, nil
+}
+
+func (r mysqlItems) Create(ctx context.Context, item *model.Item) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO items ("+itemColumns+") VALUES (?, ?, ?, ?, ?, ?)",
+		item.ID, item.UserID, item.ItemTemplateID, item.Quantity, nullString(item.EquippedToHeroID), item.AcquiredAt)
+	return wrapErr(err)
+}
+
+func (r mysqlItems) Get(ctx context.Context, id string) (*model.Item, error) {
+	return scanItem(r.s.q.QueryRowContext(ctx,
+		"SELECT "+itemColumns+" FROM items WHERE id = ?"+r.s.forUpdate(), id))
+}
+
+func (r mysqlItems) ListByUser(ctx context.Context, userID string) ([]*model.Item, error) {
+	return r.list(ctx, "SELECT "+itemColumns+" FROM items WHERE user_id = ? ORDER BY acquired_at, id", userID)
+}
+
+func (r mysqlItems) ListEquipped(ctx context.Context, heroID string) ([]*model.Item, error) {
+	return r.list(ctx, "SELECT "+itemColumns+" FROM items WHERE equipped_to_hero_id = ? ORDER BY acquired_at, id"+r.s.forUpdate(), heroID)
+}
+
+func (r mysqlItems) ListStacks(ctx context.Context, userID, templateID string) ([]*model.Item, error) {
+	return r.list(ctx, "SELECT "+itemColumns+" FROM items WHERE user_id = ? AND item_template_id = ? AND equipped_to_hero_id IS NULL ORDER BY acquired_at, id"+r.s.forUpdate(), userID, templateID)
+}
+
+// list runs a query for items
+func (r mysqlItems) list(ctx context.Context, query string, args ...interface{}) ([]*model.Item, error) {
+	rows, err := r.s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*model.Item
+	for rows.Next() {
+		it, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+func (r mysqlItems) Update(ctx context.Context, item *model.Item) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE items SET quantity = ?, equipped_to_hero_id = ? WHERE id = ?",
+		item.Quantity, nullString(item.EquippedToHeroID), item.ID))
+}
+
+func (r mysqlItems) Delete(ctx context.Context, id string) error {
+	return expectAffected(r.s.q.ExecContext(ctx, "DELETE FROM items WHERE id = ?", id))
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlMissions struct{ s *MySQL }
+
+const missionTemplateColumns = "id, title, description, type, requirement_type, target_value, target_id, gold_reward, gems_reward, experience_reward"
+
+const missionColumns = "id, user_id, mission_template_id, status, current_value, assigned_at, completed_at, claimed_at, expires_at"
+
+func scanMissionTemplate(row scanner) (*model.MissionTemplate, error) {
+	var mt model.MissionTemplate
+	var description, targetID sql.NullString
+	if err := row.Scan(&mt.ID, &mt.Title, &description, &mt.Type, &mt.RequirementType, &mt.TargetValue,
+		&targetID, &mt.GoldReward, &mt.GemsReward, &mt.ExperienceReward); err != nil {
+		return nil, wrapErr(err)
+	}
+	mt.Description = description.String
+	mt.TargetID = targetID.String
+	return &mt, nil
+}
+
+func scanMission(row scanner) (*model.Mission, error) {
+	var m model.Mission
+	var completedAt, claimedAt, expiresAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.UserID, &m.MissionTemplateID, &m.Status, &m.CurrentValue,
+		&m.AssignedAt, &completedAt, &claimedAt, &expiresAt); err != nil {
+		return nil, wrapErr(err)
+	}
+	m.CompletedAt = timePtr(completedAt)
+	m.ClaimedAt = timePtr(claimedAt)
+	m.ExpiresAt = timePtr(expiresAt)
+	return &m, nil
+}
+
+func (r mysqlMissions) GetTemplate(ctx context.Context, id string) (*model.MissionTemplate, error) {
+	mt, err := scanMissionTemplate(r.s.q.QueryRowContext(ctx,
+		"SELECT "+missionTemplateColumns+" FROM mission_templates WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadItemRewards(ctx, []*model.MissionTemplate{mt}); err != nil {
+		return nil, err
+	}
+	return mt, nil
+}
+
+func (r mysqlMissions) ListTemplates(ctx context.Context) ([]*model.MissionTemplate, error) {
+	rows, err := r.s.q.QueryContext(ctx, "SELECT "+missionTemplateColumns+" FROM mission_templates ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*model.MissionTemplate
+	for rows.Next() {
+		mt, err := scanMissionTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, mt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadItemRewards(ctx, templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// loadItemRewards fills the ItemRewards of every template with one query
+func (r mysqlMissions) loadItemRewards(ctx context.Context, templates []*model.MissionTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.MissionTemplate, len(templates))
+	args := make([]interface{}, 0, len(templates))
+	for _, mt := range templates {
+		byID[mt.ID] = mt
+		args = append(args, mt.ID)
+	}
+
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT mission_template_id, item_template_id, quantity FROM mission_item_rewards WHERE mission_template_id IN ("+
+			inPlaceholders(len(args))+") ORDER BY item_template_id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var missionTemplateID string
+		var reward model.ItemReward
+		if err := rows.Scan(&missionTemplateID, &reward.ItemID, &reward.Quantity); err != nil {
+			return err
+		}
+		if mt := byID[missionTemplateID]; mt != nil {
+			mt.ItemRewards = append(mt.ItemRewards, reward)
+		}
+	}
+	return rows.Err()
+}
+
+func (r mysqlMissions) Create(ctx context.Context, m *model.Mission) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO missions ("+missionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		m.ID, m.UserID, m.MissionTemplateID, m.Status, m.CurrentValue, m.AssignedAt,
+		nullTime(m.CompletedAt), nullTime(m.ClaimedAt), nullTime(m.ExpiresAt))
+	return wrapErr(err)
+}
+
+func (r mysqlMissions) Get(ctx context.Context, id string) (*model.Mission, error) {
+	return scanMission(r.s.q.QueryRowContext(ctx,
+		"SELECT "+missionColumns+" FROM missions WHERE id = ?"+r.s.forUpdate(), id))
+}
+
+func (r mysqlMissions) ListByUser(ctx context.Context, userID string) ([]*model.Mission, error) {
+	return r.list(ctx, "WHERE user_id = ?", userID)
+}
+
+func (r mysqlMissions) ListInProgress(ctx context.Context, userID string, at time.Time) ([]*model.Mission, error) {
+	return r.list(ctx, "WHERE user_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)",
+		userID, model.MissionStatusInProgress, at)
+}
+
+// list lists the missions matching the where clause in assignment order
+func (r mysqlMissions) list(ctx context.Context, where string, args ...interface{}) ([]*model.Mission, error) {
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT "+missionColumns+" FROM missions "+where+" ORDER BY assigned_at, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var missions []*model.Mission
+	for rows.Next() {
+		m, err := scanMission(rows)
+		if err != nil {
+			return nil, err
+		}
+		missions = append(missions, m)
+	}
+	return missions, rows.Err()
+}
+
+func (r mysqlMissions) Update(ctx context.Context, m *model.Mission) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE missions SET status = ?, current_value = ?, completed_at = ?, claimed_at = ?, expires_at = ? WHERE id = ?",
+		m.Status, m.CurrentValue, nullTime(m.CompletedAt), nullTime(m.ClaimedAt), nullTime(m.ExpiresAt), m.ID))
+}
+
+func (r mysqlMissions) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.s.q.ExecContext(ctx, "DELETE FROM missions WHERE expires_at <= ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
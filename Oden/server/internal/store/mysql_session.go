@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlSessions struct{ s *MySQL }
+
+const sessionColumns = "id, user_id, ip, user_agent, country, new_device, new_country, created_at, last_used_at, expires_at, revoked_at"
+
+func scanSession(row scanner) (*model.Session, error) {
+	var s model.Session
+	var revokedAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.UserID, &s.IP, &s.UserAgent, &s.Country, &s.NewDevice, &s.NewCountry,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &revokedAt); err != nil {
+		return nil, wrapErr(err)
+	}
+	s.RevokedAt = timePtr(revokedAt)
+	return &s, nil
+}
+
+func (r mysqlSessions) Create(ctx context.Context, session *model.Session) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO sessions ("+sessionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		session.ID, session.UserID, session.IP, session.UserAgent, session.Country, session.NewDevice, session.NewCountry,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt, nullTime(session.RevokedAt))
+	return wrapErr(err)
+}
+
+func (r mysqlSessions) Get(ctx context.Context, id string) (*model.Session, error) {
+	return scanSession(r.s.q.QueryRowContext(ctx,
+		"SELECT "+sessionColumns+" FROM sessions WHERE id = ?"+r.s.forUpdate(), id))
+}
+
+func (r mysqlSessions) ListByUser(ctx context.Context, userID string, limit int) ([]*model.Session, error) {
+	rows, err := r.s.q.QueryContext(ctx,
+		"SELECT "+sessionColumns+" FROM sessions WHERE user_id = ? ORDER BY created_at DESC, id LIMIT ?",
+		userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (r mysqlSessions) Update(ctx context.Context, session *model.Session) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE sessions SET last_used_at = ?, expires_at = ?, revoked_at = ? WHERE id = ?",
+		session.LastUsedAt, session.ExpiresAt, nullTime(session.RevokedAt), session.ID))
+}
+
+func (r mysqlSessions) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", at, userID)
+	return wrapErr(err)
+}
+
+const refreshTokenColumns = "token_hash, session_id, created_at, expires_at, used_at"
+
+func (r mysqlSessions) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO refresh_tokens ("+refreshTokenColumns+") VALUES (?, ?, ?, ?, ?)",
+		token.Hash, token.SessionID, token.CreatedAt, token.ExpiresAt, nullTime(token.UsedAt))
+	return wrapErr(err)
+}
+
+func (r mysqlSessions) GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var t model.RefreshToken
+	var usedAt sql.NullTime
+	err := r.s.q.QueryRowContext(ctx,
+		"SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = ?"+r.s.forUpdate(), hash).
+		Scan(&t.Hash, &t.SessionID, &t.CreatedAt, &t.ExpiresAt, &usedAt)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	t.UsedAt = timePtr(usedAt)
+	return &t, nil
+}
+
+func (r mysqlSessions) UpdateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at = ? WHERE token_hash = ?", nullTime(token.UsedAt), token.Hash))
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlTeams struct{ s *MySQL }
+
+const teamColumns = "id, user_id, position_1, position_2, position_3, position_4, position_5, created_at, updated_at"
+
+func (r mysqlTeams) GetByUser(ctx context.Context, userID string) (*model.Team, error) {
+	var t model.Team
+	var p1, p2, p3, p4, p5 sql.NullString
+	err := r.s.q.QueryRowContext(ctx,
+		"SELECT "+teamColumns+" FROM teams WHERE user_id = ?"+r.s.forUpdate(), userID).
+		Scan(&t.ID, &t.UserID, &p1, &p2, &p3, &p4, &p5, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	t.Position1, t.Position2, t.Position3, t.Position4, t.Position5 = p1.String, p2.String, p3.String, p4.String, p5.String
+	return &t, nil
+}
+
+func (r mysqlTeams) Save(ctx context.Context, t *model.Team) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO teams ("+teamColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE position_1 = VALUES(position_1), position_2 = VALUES(position_2), "+
+			"position_3 = VALUES(position_3), position_4 = VALUES(position_4), position_5 = VALUES(position_5), "+
+			"updated_at = VALUES(updated_at)",
+		t.ID, t.UserID,
+		nullString(t.Position1), nullString(t.Position2), nullString(t.Position3),
+		nullString(t.Position4), nullString(t.Position5),
+		t.CreatedAt, t.UpdatedAt)
+	return wrapErr(err)
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/oden/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of SQL statements
+var tracer = otel.Tracer("github.com/yourusername/oden/internal/store")
+
+// statementTable finds the table a statement reads or writes first
+var statementTable = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE)\\s+`?(\\w+)")
+
+// tracedQuerier runs statements with q, each in a span named after its
+// operation and table, such as "SELECT heroes". Queries end their span when
+// the first rows are available, so reading the rest is not included.
+type tracedQuerier struct {
+	q querier
+}
+
+func (t tracedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatement(ctx, query)
+	res, err := t.q.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (t tracedQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatement(ctx, query)
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (t tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatement(ctx, query)
+	row := t.q.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
+}
+
+// startStatement starts the span of a statement, or of BEGIN and COMMIT.
+// Statements only hold placeholders, so recording them does not record
+// player data.
+func startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := ""
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	name := operation
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemMySQL,
+		semconv.DBOperation(operation),
+		semconv.DBStatement(query),
+	}
+	if m := statementTable.FindStringSubmatch(query); m != nil {
+		name += " " + m[1]
+		attrs = append(attrs, semconv.DBSQLTable(m[1]))
+	}
+
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
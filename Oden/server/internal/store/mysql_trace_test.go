@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+func TestStartStatementNamesSpans(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+
+	tests := []struct {
+		query, name, table string
+	}{
+		{"SELECT id, name FROM heroes WHERE user_id = ?", "SELECT heroes", "heroes"},
+		{"insert into `items` (id) values (?)", "INSERT items", "items"},
+		{"UPDATE missions SET status = ? WHERE id = ?", "UPDATE missions", "missions"},
+		{"DELETE FROM sessions WHERE id = ?", "DELETE sessions", "sessions"},
+		{"BEGIN", "BEGIN", ""},
+	}
+	for _, tt := range tests {
+		_, span := startStatement(context.Background(), tt.query)
+		span.End()
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != len(tests) {
+		t.Fatalf("%d spans ended, want %d", len(spans), len(tests))
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span of %q named %q, want %q", tt.query, span.Name(), tt.name)
+		}
+		attrs := attribute.NewSet(span.Attributes()...)
+		table, ok := attrs.Value(semconv.DBSQLTableKey)
+		if table.AsString() != tt.table || ok != (tt.table != "") {
+			t.Errorf("table of %q = %q, want %q", tt.query, table.AsString(), tt.table)
+		}
+		if statement, _ := attrs.Value(semconv.DBStatementKey); statement.AsString() != tt.query {
+			t.Errorf("statement recorded as %q, want %q", statement.AsString(), tt.query)
+		}
+	}
+}
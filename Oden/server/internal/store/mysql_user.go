@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+type mysqlUsers struct{ s *MySQL }
+
+const userColumns = "id, username, email, email_verified_at, password_hash, device_id, created_at, last_login, failed_logins, locked_until"
+
+// scanUser reads a user row. Guests have NULL credentials and registered
+// users a NULL device.
+func scanUser(row scanner) (*model.User, error) {
+	var u model.User
+	var username, email, passwordHash, deviceID sql.NullString
+	var emailVerifiedAt, lockedUntil sql.NullTime
+	if err := row.Scan(&u.ID, &username, &email, &emailVerifiedAt, &passwordHash, &deviceID, &u.CreatedAt, &u.LastLogin,
+		&u.FailedLogins, &lockedUntil); err != nil {
+		return nil, wrapErr(err)
+	}
+	u.Username, u.Email, u.PasswordHash, u.DeviceID = username.String, email.String, passwordHash.String, deviceID.String
+	u.EmailVerifiedAt = timePtr(emailVerifiedAt)
+	u.LockedUntil = timePtr(lockedUntil)
+	return &u, nil
+}
+
+func (r mysqlUsers) Create(ctx context.Context, user *model.User) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		user.ID, nullString(user.Username), nullString(user.Email), nullTime(user.EmailVerifiedAt),
+		nullString(user.PasswordHash), nullString(user.DeviceID),
+		user.CreatedAt, user.LastLogin, user.FailedLogins, nullTime(user.LockedUntil))
+	return wrapErr(err)
+}
+
+func (r mysqlUsers) GetByID(ctx context.Context, id string) (*model.User, error) {
+	return scanUser(r.s.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?"+r.s.forUpdate(), id))
+}
+
+func (r mysqlUsers) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	return scanUser(r.s.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE username = ?", username))
+}
+
+func (r mysqlUsers) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	return scanUser(r.s.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email = ?", email))
+}
+
+func (r mysqlUsers) GetByDeviceID(ctx context.Context, deviceID string) (*model.User, error) {
+	return scanUser(r.s.q.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE device_id = ?", deviceID))
+}
+
+func (r mysqlUsers) Update(ctx context.Context, user *model.User) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE users SET username = ?, email = ?, email_verified_at = ?, password_hash = ?, device_id = ?, "+
+			"failed_logins = ?, locked_until = ? WHERE id = ?",
+		nullString(user.Username), nullString(user.Email), nullTime(user.EmailVerifiedAt),
+		nullString(user.PasswordHash), nullString(user.DeviceID),
+		user.FailedLogins, nullTime(user.LockedUntil), user.ID))
+}
+
+func (r mysqlUsers) UpdateLastLogin(ctx context.Context, id string, at time.Time) error {
+	return expectAffected(r.s.q.ExecContext(ctx, "UPDATE users SET last_login = ? WHERE id = ?", at, id))
+}
+
+type mysqlResources struct{ s *MySQL }
+
+const resourceColumns = "user_id, gold, premium_currency, summon_tickets, special_tickets, account_level, account_experience, last_idle_claim"
+
+func scanResources(row scanner) (*model.PlayerResources, error) {
+	var res model.PlayerResources
+	if err := row.Scan(&res.UserID, &res.Gold, &res.PremiumCurrency, &res.SummonTickets, &res.SpecialTickets,
+		&res.AccountLevel, &res.AccountExperience, &res.LastIdleClaim); err != nil {
+		return nil, wrapErr(err)
+	}
+	return &res, nil
+}
+
+func (r mysqlResources) Create(ctx context.Context, res *model.PlayerResources) error {
+	_, err := r.s.q.ExecContext(ctx,
+		"INSERT INTO player_resources ("+resourceColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		res.UserID, res.Gold, res.PremiumCurrency, res.SummonTickets, res.SpecialTickets,
+		res.AccountLevel, res.AccountExperience, res.LastIdleClaim)
+	return wrapErr(err)
+}
+
+func (r mysqlResources) Get(ctx context.Context, userID string) (*model.PlayerResources, error) {
+	return scanResources(r.s.q.QueryRowContext(ctx,
+		"SELECT "+resourceColumns+" FROM player_resources WHERE user_id = ?"+r.s.forUpdate(), userID))
+}
+
+func (r mysqlResources) Update(ctx context.Context, res *model.PlayerResources) error {
+	return expectAffected(r.s.q.ExecContext(ctx,
+		"UPDATE player_resources SET gold = ?, premium_currency = ?, summon_tickets = ?, special_tickets = ?, "+
+			"account_level = ?, account_experience = ?, last_idle_claim = ? WHERE user_id = ?",
+		res.Gold, res.PremiumCurrency, res.SummonTickets, res.SpecialTickets,
+		res.AccountLevel, res.AccountExperience, res.LastIdleClaim, res.UserID))
+}
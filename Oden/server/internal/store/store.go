@@ -0,0 +1,231 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yourusername/oden/internal/model"
+)
+
+// Errors returned by repositories
+var (
+	ErrNotFound  = errors.New("store: record not found")
+	ErrDuplicate = errors.New("store: duplicate record")
+)
+
+// Store gives access to every repository backed by the same connection.
+// Repositories only read and write stored columns; computed model fields
+// (Hero.HeroType, Item.Template, Mission.Template, ...) are left to callers.
+//
+// Inside WithTx, single-row reads of player-owned rows (users by ID,
+// resources, heroes, teams, items, missions, summon sessions, login sessions,
+// refresh tokens and idempotency keys) lock the row until the transaction ends, so
+// read-modify-write sequences cannot interleave.
+type Store interface {
+	Users() UserRepository
+	Resources() ResourceRepository
+	HeroTypes() HeroTypeRepository
+	Heroes() HeroRepository
+	Teams() TeamRepository
+	Stages() StageRepository
+	EnemyTypes() EnemyTypeRepository
+	BattleResults() BattleResultRepository
+	ItemTemplates() ItemTemplateRepository
+	Items() ItemRepository
+	Missions() MissionRepository
+	Banners() BannerRepository
+	Summons() SummonRepository
+	Sessions() SessionRepository
+	IdempotencyKeys() IdempotencyKeyRepository
+
+	// WithTx runs fn inside a transaction. The Store passed to fn must be
+	// used for every call that should be part of the transaction. The
+	// transaction is committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+	// AfterCommit runs fn once the transaction commits, and never if it
+	// rolls back or fails to commit. Outside a transaction fn runs at once.
+	AfterCommit(fn func())
+}
+
+// commitHooks holds the functions to run after a transaction commits
+type commitHooks struct {
+	fns []func()
+}
+
+// afterCommit adds fn to hooks, or runs it at once if there is no transaction
+func afterCommit(hooks *commitHooks, fn func()) {
+	if hooks == nil {
+		fn()
+		return
+	}
+	hooks.fns = append(hooks.fns, fn)
+}
+
+// run runs the hooks in the order they were added
+func (h *commitHooks) run() {
+	for _, fn := range h.fns {
+		fn()
+	}
+}
+
+// UserRepository persists users
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	// GetByDeviceID returns the guest user playing on the device
+	GetByDeviceID(ctx context.Context, deviceID string) (*model.User, error)
+	// Update saves the user's username, email and its verification, password
+	// hash, device and failed logins
+	Update(ctx context.Context, user *model.User) error
+	UpdateLastLogin(ctx context.Context, id string, at time.Time) error
+}
+
+// ResourceRepository persists player resources
+type ResourceRepository interface {
+	Create(ctx context.Context, resources *model.PlayerResources) error
+	Get(ctx context.Context, userID string) (*model.PlayerResources, error)
+	Update(ctx context.Context, resources *model.PlayerResources) error
+}
+
+// HeroTypeRepository reads hero templates and their skills
+type HeroTypeRepository interface {
+	Get(ctx context.Context, id string) (*model.HeroType, error)
+	List(ctx context.Context) ([]*model.HeroType, error)
+}
+
+// HeroRepository persists player heroes
+type HeroRepository interface {
+	Create(ctx context.Context, hero *model.Hero) error
+	Get(ctx context.Context, id string) (*model.Hero, error)
+	ListByUser(ctx context.Context, userID string) ([]*model.Hero, error)
+	Update(ctx context.Context, hero *model.Hero) error
+}
+
+// TeamRepository persists team formations. A user has at most one team.
+type TeamRepository interface {
+	GetByUser(ctx context.Context, userID string) (*model.Team, error)
+	// Save inserts the team, or updates the positions of the user's team if
+	// they already have one, whatever its ID. Concurrent first saves of a
+	// user therefore leave a single team.
+	Save(ctx context.Context, team *model.Team) error
+}
+
+// StageRepository reads stage definitions
+type StageRepository interface {
+	Get(ctx context.Context, id string) (*model.Stage, error)
+	List(ctx context.Context) ([]*model.Stage, error)
+}
+
+// EnemyTypeRepository reads the enemy catalogue with each type's skills
+type EnemyTypeRepository interface {
+	Get(ctx context.Context, id string) (*model.EnemyType, error)
+	List(ctx context.Context) ([]*model.EnemyType, error)
+}
+
+// BattleResultRepository persists battle results
+type BattleResultRepository interface {
+	Create(ctx context.Context, result *model.BattleResult) error
+	Get(ctx context.Context, id string) (*model.BattleResult, error)
+	ListByUser(ctx context.Context, userID string, limit int) ([]*model.BattleResult, error)
+	// ListClearedStageIDs returns the IDs of the stages the user has won at least once
+	ListClearedStageIDs(ctx context.Context, userID string) ([]string, error)
+}
+
+// ItemTemplateRepository reads item templates
+type ItemTemplateRepository interface {
+	Get(ctx context.Context, id string) (*model.ItemTemplate, error)
+	List(ctx context.Context) ([]*model.ItemTemplate, error)
+}
+
+// ItemRepository persists player items
+type ItemRepository interface {
+	Create(ctx context.Context, item *model.Item) error
+	Get(ctx context.Context, id string) (*model.Item, error)
+	ListByUser(ctx context.Context, userID string) ([]*model.Item, error)
+	// ListEquipped lists the items equipped to the hero. Inside a
+	// transaction the rows are locked, and items equipped by transactions
+	// that committed since it started are seen.
+	ListEquipped(ctx context.Context, heroID string) ([]*model.Item, error)
+	// ListStacks lists the user's unequipped items of the template, locked
+	// like ListEquipped
+	ListStacks(ctx context.Context, userID, templateID string) ([]*model.Item, error)
+	Update(ctx context.Context, item *model.Item) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MissionRepository persists mission templates and assigned missions
+type MissionRepository interface {
+	GetTemplate(ctx context.Context, id string) (*model.MissionTemplate, error)
+	ListTemplates(ctx context.Context) ([]*model.MissionTemplate, error)
+
+	Create(ctx context.Context, mission *model.Mission) error
+	Get(ctx context.Context, id string) (*model.Mission, error)
+	ListByUser(ctx context.Context, userID string) ([]*model.Mission, error)
+	// ListInProgress lists the user's missions that are in progress and have
+	// not expired at the given time
+	ListInProgress(ctx context.Context, userID string, at time.Time) ([]*model.Mission, error)
+	Update(ctx context.Context, mission *model.Mission) error
+	// DeleteExpired deletes every mission that expired at or before the given
+	// time and returns how many were deleted
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// BannerRepository reads summon banners with their featured heroes and items
+type BannerRepository interface {
+	Get(ctx context.Context, id string) (*model.Banner, error)
+	List(ctx context.Context) ([]*model.Banner, error)
+}
+
+// SummonRepository persists summon sessions and results
+type SummonRepository interface {
+	GetSession(ctx context.Context, userID, bannerID string) (*model.SummonSession, error)
+	// SaveSession inserts the session, or updates the user's session for the
+	// banner if they already have one, whatever its ID. Concurrent first saves
+	// therefore leave a single session.
+	SaveSession(ctx context.Context, session *model.SummonSession) error
+	CreateResult(ctx context.Context, result *model.SummonResult) error
+	ListResults(ctx context.Context, userID, bannerID string, limit int) ([]*model.SummonResult, error)
+}
+
+// SessionRepository persists login sessions and their refresh tokens
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	Get(ctx context.Context, id string) (*model.Session, error)
+	// ListByUser returns the user's latest sessions, newest first
+	ListByUser(ctx context.Context, userID string, limit int) ([]*model.Session, error)
+	// Update saves the session's last use, expiry and revocation
+	Update(ctx context.Context, session *model.Session) error
+	// RevokeByUser revokes every session of the user that is not revoked yet
+	RevokeByUser(ctx context.Context, userID string, at time.Time) error
+
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error)
+	// UpdateRefreshToken saves when the token was used
+	UpdateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+}
+
+// IdempotencyKeyRepository persists idempotency keys and the responses to
+// their requests
+type IdempotencyKeyRepository interface {
+	// Create saves a new key, failing with ErrDuplicate if the user already
+	// has a key with the same value
+	Create(ctx context.Context, key *model.IdempotencyKey) error
+	Get(ctx context.Context, userID, key string) (*model.IdempotencyKey, error)
+	// Replace overwrites old with key, a new reservation of the same key, if
+	// the stored key is still old: reserved at the same time and with the
+	// same status. Otherwise it fails with ErrNotFound.
+	Replace(ctx context.Context, old, key *model.IdempotencyKey) error
+	// Update saves the response of the key reserved at key.CreatedAt. It
+	// fails with ErrNotFound if the key already has a response or has been
+	// reserved again since.
+	Update(ctx context.Context, key *model.IdempotencyKey) error
+	// Delete deletes the key reserved at key.CreatedAt, unless it already
+	// has a response or has been reserved again since
+	Delete(ctx context.Context, key *model.IdempotencyKey) error
+	// DeleteExpired deletes every key that expired at or before the given
+	// time and returns how many were deleted
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
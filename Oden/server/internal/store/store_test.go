@@ -0,0 +1,515 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/oden/internal/db"
+	"github.com/yourusername/oden/internal/model"
+)
+
+// mysqlTestDSNEnv names the environment variable holding the DSN of an empty
+// MySQL database for the conformance tests, such as
+// oden:oden@tcp(localhost:3306)/oden_test?parseTime=true&clientFoundRows=true.
+// The MySQL tests are skipped without it.
+const mysqlTestDSNEnv = "ODEN_TEST_MYSQL_DSN"
+
+func TestMemoryConformance(t *testing.T) {
+	m := NewMemory()
+	m.LoadSampleData()
+	testConformance(t, m)
+}
+
+func TestMySQLConformance(t *testing.T) {
+	dsn := os.Getenv(mysqlTestDSNEnv)
+	if dsn == "" {
+		t.Skipf("set %s to run the MySQL store tests", mysqlTestDSNEnv)
+	}
+	sqlDB, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	database := &db.DB{DB: sqlDB}
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	testConformance(t, NewMySQL(database))
+}
+
+// testConformance checks the behavior every Store implementation must share.
+// st must hold the sample data. Every test creates its own users, so the
+// tests can run against a database that is not empty.
+func testConformance(t *testing.T, st Store) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, st) })
+	t.Run("Teams", func(t *testing.T) { testTeams(t, st) })
+	t.Run("EnemyTypes", func(t *testing.T) { testEnemyTypes(t, st) })
+	t.Run("Items", func(t *testing.T) { testItems(t, st) })
+	t.Run("SummonSessions", func(t *testing.T) { testSummonSessions(t, st) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, st) })
+	t.Run("Missions", func(t *testing.T) { testMissions(t, st) })
+	t.Run("IdempotencyKeys", func(t *testing.T) { testIdempotencyKeys(t, st) })
+	t.Run("WithTx", func(t *testing.T) { testWithTx(t, st) })
+	t.Run("AfterCommit", func(t *testing.T) { testAfterCommit(t, st) })
+}
+
+// createUser creates a registered user with a unique name
+func createUser(t *testing.T, st Store) *model.User {
+	t.Helper()
+	id := uuid.New().String()
+	user := model.NewUser(id, "user_"+id[:8], id[:8]+"@example.com", "hash")
+	if err := st.Users().Create(context.Background(), user); err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return user
+}
+
+func testUsers(t *testing.T, st Store) {
+	ctx := context.Background()
+	user := createUser(t, st)
+
+	got, err := st.Users().GetByUsername(ctx, user.Username)
+	if err != nil {
+		t.Fatalf("GetByUsername: %v", err)
+	}
+	if got.ID != user.ID || got.Email != user.Email || got.PasswordHash != user.PasswordHash {
+		t.Errorf("GetByUsername = %+v, want %+v", got, user)
+	}
+
+	dup := model.NewUser(uuid.New().String(), user.Username, "other_"+user.Email, "hash")
+	if err := st.Users().Create(ctx, dup); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("creating a user with a taken username: got %v, want ErrDuplicate", err)
+	}
+
+	lockedUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+	got.FailedLogins = 3
+	got.LockedUntil = &lockedUntil
+	if err := st.Users().Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = st.Users().GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.FailedLogins != 3 || got.LockedUntil == nil || !got.LockedUntil.Equal(lockedUntil) {
+		t.Errorf("failed logins after Update = %d until %v, want 3 until %v", got.FailedLogins, got.LockedUntil, lockedUntil)
+	}
+
+	if _, err := st.Users().GetByID(ctx, uuid.New().String()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID of an unknown user: got %v, want ErrNotFound", err)
+	}
+}
+
+func testTeams(t *testing.T, st Store) {
+	ctx := context.Background()
+	user := createUser(t, st)
+
+	first := model.NewTeam(model.NewID("team"), user.ID)
+	if err := st.Teams().Save(ctx, first); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	hero := model.NewHero(model.NewID("hero"), user.ID, "hero_type_001")
+	if err := st.Heroes().Create(ctx, hero); err != nil {
+		t.Fatalf("creating hero: %v", err)
+	}
+
+	// A second first save, as from a concurrent request, updates the same team
+	second := model.NewTeam(model.NewID("team"), user.ID)
+	second.Position1 = hero.ID
+	if err := st.Teams().Save(ctx, second); err != nil {
+		t.Fatalf("Save with another ID: %v", err)
+	}
+
+	got, err := st.Teams().GetByUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetByUser: %v", err)
+	}
+	if got.ID != first.ID || got.Position1 != hero.ID {
+		t.Errorf("GetByUser = team %s with %q in position 1, want team %s with %s", got.ID, got.Position1, first.ID, hero.ID)
+	}
+}
+
+func testEnemyTypes(t *testing.T, st Store) {
+	ctx := context.Background()
+
+	wolf, err := st.EnemyTypes().Get(ctx, "enemy_002")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if wolf.Name != "Wild Wolf" || wolf.BaseHP != 150 || wolf.HPPerLevel != 15 || wolf.ATKPerLevel != 3 {
+		t.Errorf("Get = %+v, want the sample Wild Wolf", wolf)
+	}
+	if len(wolf.Skills) != 1 || wolf.Skills[0].ID != "enemy_skill_001" || wolf.Skills[0].DamageMultiplier != 1.3 {
+		t.Errorf("Wild Wolf skills = %+v, want Bite", wolf.Skills)
+	}
+	if _, err := st.EnemyTypes().Get(ctx, "enemy_unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of an unknown enemy type: got %v, want ErrNotFound", err)
+	}
+
+	// Every enemy of every stage is in the catalogue
+	types, err := st.EnemyTypes().List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	catalogue := make(map[string]bool, len(types))
+	for _, et := range types {
+		catalogue[et.ID] = true
+	}
+	stages, err := st.Stages().List(ctx)
+	if err != nil {
+		t.Fatalf("listing stages: %v", err)
+	}
+	for _, stage := range stages {
+		if stage.EnemyLevel < 1 {
+			t.Errorf("stage %s has enemy level %d", stage.ID, stage.EnemyLevel)
+		}
+		for _, id := range stage.GetEnemyIDs() {
+			if !catalogue[id] {
+				t.Errorf("stage %s has enemy %s, which List does not return", stage.ID, id)
+			}
+		}
+	}
+}
+
+func testItems(t *testing.T, st Store) {
+	ctx := context.Background()
+	user := createUser(t, st)
+	hero := model.NewHero(model.NewID("hero"), user.ID, "hero_type_001")
+	if err := st.Heroes().Create(ctx, hero); err != nil {
+		t.Fatalf("creating hero: %v", err)
+	}
+
+	items := map[string]*model.Item{
+		"stack":    model.NewItem(model.NewID("item"), user.ID, "item_template_001", 3),
+		"equipped": model.NewItem(model.NewID("item"), user.ID, "item_template_001", 1),
+		"other":    model.NewItem(model.NewID("item"), user.ID, "item_template_002", 1),
+	}
+	items["equipped"].EquippedToHeroID = hero.ID
+	for name, it := range items {
+		if err := st.Items().Create(ctx, it); err != nil {
+			t.Fatalf("creating %s item: %v", name, err)
+		}
+	}
+
+	err := st.WithTx(ctx, func(tx Store) error {
+		equipped, err := tx.Items().ListEquipped(ctx, hero.ID)
+		if err != nil {
+			return err
+		}
+		if len(equipped) != 1 || equipped[0].ID != items["equipped"].ID {
+			t.Errorf("ListEquipped returned %d items, want the equipped one", len(equipped))
+		}
+
+		stacks, err := tx.Items().ListStacks(ctx, user.ID, "item_template_001")
+		if err != nil {
+			return err
+		}
+		if len(stacks) != 1 || stacks[0].ID != items["stack"].ID || stacks[0].Quantity != 3 {
+			t.Errorf("ListStacks returned %d items, want the stack of 3", len(stacks))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+}
+
+func testSummonSessions(t *testing.T, st Store) {
+	ctx := context.Background()
+	user := createUser(t, st)
+
+	first := model.NewSummonSession(model.NewID("summon"), user.ID, "banner_001")
+	first.PullCount = 10
+	if err := st.Summons().SaveSession(ctx, first); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+
+	// A second first save for the banner updates the same session
+	second := model.NewSummonSession(model.NewID("summon"), user.ID, "banner_001")
+	second.PullCount = 11
+	second.HasGuarantee = true
+	if err := st.Summons().SaveSession(ctx, second); err != nil {
+		t.Fatalf("SaveSession with another ID: %v", err)
+	}
+
+	got, err := st.Summons().GetSession(ctx, user.ID, "banner_001")
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got.ID != first.ID || got.PullCount != 11 || !got.HasGuarantee {
+		t.Errorf("GetSession = %s with %d pulls, guarantee %v; want %s with 11 pulls and the guarantee",
+			got.ID, got.PullCount, got.HasGuarantee, first.ID)
+	}
+
+	// Other banners keep their own session
+	if _, err := st.Summons().GetSession(ctx, user.ID, "banner_002"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetSession of another banner: got %v, want ErrNotFound", err)
+	}
+}
+
+func testSessions(t *testing.T, st Store) {
+	ctx := context.Background()
+	user := createUser(t, st)
+
+	now := time.Now().Truncate(time.Second)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		s := model.NewSession(model.NewID("session"), user.ID, now.Add(time.Hour))
+		s.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		s.UserAgent = "agent"
+		if err := st.Sessions().Create(ctx, s); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, s.ID)
+	}
+
+	list, err := st.Sessions().ListByUser(ctx, user.ID, 2)
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != ids[2] || list[1].ID != ids[1] {
+		t.Errorf("ListByUser returned %d sessions, want the 2 newest first", len(list))
+	}
+
+	if err := st.Sessions().RevokeByUser(ctx, user.ID, now); err != nil {
+		t.Fatalf("RevokeByUser: %v", err)
+	}
+	for _, id := range ids {
+		s, err := st.Sessions().Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if s.RevokedAt == nil {
+			t.Errorf("session %s not revoked", id)
+		}
+	}
+
+	token := &model.RefreshToken{Hash: uuid.New().String(), SessionID: ids[0], CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := st.Sessions().CreateRefreshToken(ctx, token); err != nil {
+		t.Fatalf("CreateRefreshToken: %v", err)
+	}
+	token.UsedAt = &now
+	if err := st.Sessions().UpdateRefreshToken(ctx, token); err != nil {
+		t.Fatalf("UpdateRefreshToken: %v", err)
+	}
+	got, err := st.Sessions().GetRefreshToken(ctx, token.Hash)
+	if err != nil {
+		t.Fatalf("GetRefreshToken: %v", err)
+	}
+	if got.UsedAt == nil || !got.UsedAt.Equal(now) {
+		t.Errorf("refresh token used at %v, want %v", got.UsedAt, now)
+	}
+}
+
+func testMissions(t *testing.T, st Store) {
+	ctx := context.Background()
+	user := createUser(t, st)
+	now := time.Now().Truncate(time.Second)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	missions := map[string]*model.Mission{
+		"current":   model.NewMission(model.NewID("mission"), user.ID, "mission_template_001", nil, &later),
+		"permanent": model.NewMission(model.NewID("mission"), user.ID, "mission_template_003", nil, nil),
+		"expired":   model.NewMission(model.NewID("mission"), user.ID, "mission_template_002", nil, &earlier),
+		"completed": model.NewMission(model.NewID("mission"), user.ID, "mission_template_001", nil, &later),
+	}
+	missions["completed"].Status = model.MissionStatusCompleted
+	for name, m := range missions {
+		if err := st.Missions().Create(ctx, m); err != nil {
+			t.Fatalf("creating %s mission: %v", name, err)
+		}
+	}
+
+	got, err := st.Missions().ListInProgress(ctx, user.ID, now)
+	if err != nil {
+		t.Fatalf("ListInProgress: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, m := range got {
+		ids[m.ID] = true
+	}
+	if len(got) != 2 || !ids[missions["current"].ID] || !ids[missions["permanent"].ID] {
+		t.Errorf("ListInProgress returned %d missions, want the current and the permanent one", len(got))
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, st Store) {
+	ctx := context.Background()
+	user := createUser(t, st)
+
+	now := time.Now().Truncate(time.Second)
+	key := &model.IdempotencyKey{UserID: user.ID, Key: "key", RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := st.IdempotencyKeys().Create(ctx, key); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := st.IdempotencyKeys().Create(ctx, key); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("creating the key twice: got %v, want ErrDuplicate", err)
+	}
+
+	// Only the reservation made at the key's creation time is answered
+	// or deleted
+	stale := *key
+	stale.CreatedAt = now.Add(-time.Minute)
+	stale.StatusCode = 200
+	if err := st.IdempotencyKeys().Update(ctx, &stale); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of an earlier reservation: got %v, want ErrNotFound", err)
+	}
+	if err := st.IdempotencyKeys().Delete(ctx, &stale); err != nil {
+		t.Fatalf("Delete of an earlier reservation: %v", err)
+	}
+	if _, err := st.IdempotencyKeys().Get(ctx, user.ID, "key"); err != nil {
+		t.Errorf("Get after deleting an earlier reservation: %v", err)
+	}
+
+	key.StatusCode = 200
+	key.ContentType = "application/json"
+	key.Body = []byte(`{"success":true}`)
+	if err := st.IdempotencyKeys().Update(ctx, key); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := st.IdempotencyKeys().Update(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of an answered key: got %v, want ErrNotFound", err)
+	}
+	if err := st.IdempotencyKeys().Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of an answered key: %v", err)
+	}
+	got, err := st.IdempotencyKeys().Get(ctx, user.ID, "key")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.StatusCode != 200 || got.ContentType != key.ContentType || string(got.Body) != string(key.Body) {
+		t.Errorf("Get = %d %q %q, want the stored response", got.StatusCode, got.ContentType, got.Body)
+	}
+
+	// Replace takes over the key only if it is still the one that was read
+	later := now.Add(time.Hour)
+	next := &model.IdempotencyKey{UserID: user.ID, Key: "key", RequestHash: "other hash", CreatedAt: later, ExpiresAt: later.Add(time.Hour)}
+	if err := st.IdempotencyKeys().Replace(ctx, &stale, next); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Replace of a key changed since: got %v, want ErrNotFound", err)
+	}
+	if err := st.IdempotencyKeys().Replace(ctx, got, next); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	got, err = st.IdempotencyKeys().Get(ctx, user.ID, "key")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.RequestHash != "other hash" || got.IsComplete() || !got.CreatedAt.Equal(later) {
+		t.Errorf("Get after Replace = %+v, want the new reservation", got)
+	}
+	if err := st.IdempotencyKeys().Delete(ctx, next); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := st.IdempotencyKeys().Get(ctx, user.ID, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+
+	// Concurrent reservations of a new key: one wins and the others find it
+	// taken, rather than failing or deadlocking
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- st.IdempotencyKeys().Create(ctx, &model.IdempotencyKey{UserID: user.ID, Key: "contended", RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrDuplicate):
+			t.Errorf("concurrent Create: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d concurrent Creates succeeded, want 1", created)
+	}
+
+	if _, err := st.IdempotencyKeys().DeleteExpired(ctx, now.Add(3*time.Hour)); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if _, err := st.IdempotencyKeys().Get(ctx, user.ID, "contended"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of an expired key after DeleteExpired: got %v, want ErrNotFound", err)
+	}
+}
+
+func testWithTx(t *testing.T, st Store) {
+	ctx := context.Background()
+	id := uuid.New().String()
+	failure := errors.New("failure")
+
+	err := st.WithTx(ctx, func(tx Store) error {
+		if err := tx.Users().Create(ctx, model.NewUser(id, "user_"+id[:8], id[:8]+"@example.com", "hash")); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithTx returned %v, want the error of fn", err)
+	}
+	if _, err := st.Users().GetByID(ctx, id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("user created in a rolled back transaction: got %v, want ErrNotFound", err)
+	}
+}
+
+func testAfterCommit(t *testing.T, st Store) {
+	ctx := context.Background()
+	var ran []string
+
+	err := st.WithTx(ctx, func(tx Store) error {
+		tx.AfterCommit(func() { ran = append(ran, "rolled back") })
+		return errors.New("failure")
+	})
+	if err == nil {
+		t.Fatal("WithTx succeeded")
+	}
+
+	user := createUser(t, st)
+	err = st.WithTx(ctx, func(tx Store) error {
+		tx.AfterCommit(func() {
+			// The hook runs outside the transaction and sees its writes
+			got, err := st.Users().GetByID(ctx, user.ID)
+			if err != nil || got.FailedLogins != 1 {
+				t.Errorf("user in the hook: %+v, %v; want the update", got, err)
+			}
+			ran = append(ran, "first")
+		})
+		user.FailedLogins = 1
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return err
+		}
+		tx.AfterCommit(func() { ran = append(ran, "second") })
+		if len(ran) != 0 {
+			t.Errorf("hooks ran before the commit: %v", ran)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if len(ran) != 2 || ran[0] != "first" || ran[1] != "second" {
+		t.Errorf("hooks ran: %v, want first and second", ran)
+	}
+
+	st.AfterCommit(func() { ran = append(ran, "no transaction") })
+	if len(ran) != 3 {
+		t.Error("hook outside a transaction did not run at once")
+	}
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yourusername/oden/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// restoreGlobals puts back the global tracer provider and propagator after
+// the test
+func restoreGlobals(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestConfigureWithoutExporterPropagatesOnly(t *testing.T) {
+	restoreGlobals(t)
+	provider := otel.GetTracerProvider()
+
+	shutdown, err := Configure(context.Background(), config.TracingConfig{Exporter: "none"})
+	if err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+	if otel.GetTracerProvider() != provider {
+		t.Error("Configure without an exporter replaced the tracer provider")
+	}
+	fields := otel.GetTextMapPropagator().Fields()
+	if len(fields) != 3 {
+		t.Errorf("propagated fields %v, want traceparent, tracestate and baggage", fields)
+	}
+}
+
+func TestConfigureStdoutExporter(t *testing.T) {
+	restoreGlobals(t)
+
+	shutdown, err := Configure(context.Background(), config.TracingConfig{Exporter: "stdout", ServiceName: "oden-test", SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); !ok {
+		t.Errorf("tracer provider = %T, want the SDK's", otel.GetTracerProvider())
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
+
+func TestConfigureRejectsUnknownExporters(t *testing.T) {
+	restoreGlobals(t)
+	if _, err := Configure(context.Background(), config.TracingConfig{Exporter: "zipkin"}); err == nil {
+		t.Error("Configure with an unknown exporter succeeded")
+	}
+}
+
+func TestEndRecordsErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "ok")
+	End(span, nil)
+	_, span = tracer.Start(context.Background(), "failed")
+	End(span, errors.New("connection refused"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans ended, want 2", len(spans))
+	}
+	if status := spans[0].Status(); status.Code != codes.Unset {
+		t.Errorf("status of a span without error = %v", status)
+	}
+	if status := spans[1].Status(); status.Code != codes.Error || status.Description != "connection refused" {
+		t.Errorf("status of a failed span = %v, want the error", status)
+	}
+	if events := spans[1].Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("events of a failed span = %v, want the error recorded", events)
+	}
+}